@@ -0,0 +1,315 @@
+package figtree
+
+import (
+	"path"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/coryb/walky"
+	"gopkg.in/yaml.v3"
+)
+
+// ageTag is the yaml tag for values encrypted with age, like:
+//
+//	password: !age |
+//	  -----BEGIN AGE ENCRYPTED FILE-----
+//	  ...
+//	  -----END AGE ENCRYPTED FILE-----
+const ageTag = "!age"
+
+// ageStrTag is the yaml tag for encrypted strings that would otherwise be
+// resolved as another type when decrypted, like "123", see EncryptValues.
+const ageStrTag = "!age:str"
+
+// isEncrypted returns true if node is an encrypted scalar.
+func isEncrypted(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && (node.Tag == ageTag || node.Tag == ageStrTag)
+}
+
+// Decrypter returns the plaintext for the armored ciphertext of an encrypted
+// config value.
+type Decrypter func(ciphertext string) (string, error)
+
+// Decrypt calls d, so a Decrypter can be used as an AgeIdentity.
+func (d Decrypter) Decrypt(ciphertext string) (string, error) {
+	return d(ciphertext)
+}
+
+// WithAgeDecrypter enables `!age` tagged values in config files, each value
+// is decrypted with decrypt while loading.  The plaintext is resolved like
+// a plain YAML scalar, so `8080` can be loaded into an int.  Options
+// assigned from decrypted values have Source.Secret set.  figtree does not
+// depend on an age implementation directly, with filippo.io/age the
+// decrypter would be:
+//
+//	func(ciphertext string) (string, error) {
+//		r, err := age.Decrypt(armor.NewReader(strings.NewReader(ciphertext)), identities...)
+//		if err != nil {
+//			return "", err
+//		}
+//		plaintext, err := io.ReadAll(r)
+//		return string(plaintext), err
+//	}
+func WithAgeDecrypter(decrypt Decrypter) CreateOption {
+	return func(f *FigTree) {
+		f.ageDecrypter = decrypt
+	}
+}
+
+// AgeIdentity decrypts the armored ciphertext of values encrypted for the
+// identity.  With filippo.io/age an identity would be adapted like:
+//
+//	type ageIdentity struct{ age.Identity }
+//
+//	func (i ageIdentity) Decrypt(ciphertext string) (string, error) {
+//		r, err := age.Decrypt(armor.NewReader(strings.NewReader(ciphertext)), i.Identity)
+//		if err != nil {
+//			return "", err
+//		}
+//		plaintext, err := io.ReadAll(r)
+//		return string(plaintext), err
+//	}
+type AgeIdentity interface {
+	Decrypt(ciphertext string) (string, error)
+}
+
+// WithAgeIdentities enables `!age` tagged values in config files like
+// WithAgeDecrypter, each value is decrypted with the first of the identities
+// that can decrypt it.
+func WithAgeIdentities(identities ...AgeIdentity) CreateOption {
+	identities = append([]AgeIdentity{}, identities...)
+	return WithAgeDecrypter(func(ciphertext string) (string, error) {
+		errs := []error{}
+		for _, identity := range identities {
+			plaintext, err := identity.Decrypt(ciphertext)
+			if err == nil {
+				return plaintext, nil
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return "", errors.New("no age identities configured")
+		}
+		return "", errors.Combine(errs...)
+	})
+}
+
+func (f *FigTree) WithAgeIdentities(identities ...AgeIdentity) {
+	WithAgeIdentities(identities...)(f)
+}
+
+// secretKey identifies a decrypted value by its location, so values are
+// still secret in copies of the decrypted nodes.
+type secretKey struct {
+	file         string
+	line, column int
+	value        string
+}
+
+// decryptValues returns node with the `!age` values replaced by the
+// decrypted plaintext.  node is copied first, so the ConfigSource keeps the
+// encrypted values for later loads, reports and fingerprints.  The
+// decrypted values are recorded as secrets in the Merger.
+func (f *FigTree) decryptValues(m *Merger, node *yaml.Node) (*yaml.Node, error) {
+	if !hasEncryptedValues(node) {
+		return node, nil
+	}
+	node = walky.CopyNode(node)
+	return node, f.decryptNode(m, node)
+}
+
+// hasEncryptedValues returns true if node contains any `!age` values.
+func hasEncryptedValues(node *yaml.Node) bool {
+	if node == nil || node.Kind == yaml.AliasNode {
+		return false
+	}
+	if node.Kind == yaml.ScalarNode {
+		return isEncrypted(node)
+	}
+	for _, content := range node.Content {
+		if hasEncryptedValues(content) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FigTree) decryptNode(m *Merger, node *yaml.Node) error {
+	if node == nil || node.Kind == yaml.AliasNode {
+		// the anchored node for aliases is decrypted where it is defined
+		return nil
+	}
+	if isEncrypted(node) {
+		if f.ageDecrypter == nil {
+			return errors.Errorf("%s: encrypted value found but no age decrypter was configured", sourceLine(m.sourceFile, node))
+		}
+		plaintext, err := f.ageDecrypter(node.Value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decrypt value at %s", sourceLine(m.sourceFile, node))
+		}
+		// resolve the tag like a plain scalar, so numbers and bools can be
+		// encrypted
+		tag := plainScalarTag(plaintext)
+		if node.Tag == ageStrTag {
+			tag = "!!str"
+		}
+		node.Value = plaintext
+		node.Style = 0
+		node.Tag = tag
+		if m.secrets == nil {
+			m.secrets = map[secretKey]struct{}{}
+		}
+		m.secrets[m.secretKey(node)] = struct{}{}
+		return nil
+	}
+	for _, content := range node.Content {
+		if err := f.decryptNode(m, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Merger) secretKey(node *yaml.Node) secretKey {
+	return secretKey{m.sourceFile, node.Line, node.Column, node.Value}
+}
+
+// isSecret returns true if src was decrypted from an encrypted value.
+func (m *Merger) isSecret(src mergeSource) bool {
+	if src.node == nil || len(m.secrets) == 0 {
+		return false
+	}
+	_, ok := m.secrets[m.secretKey(src.node)]
+	return ok
+}
+
+// Encrypter returns the armored ciphertext for the plaintext of a config
+// value, the inverse of Decrypter.
+type Encrypter func(plaintext string) (string, error)
+
+// WithAgeEncrypter sets the function used to encrypt the values of the keys
+// set with WithEncryptOnSave, with filippo.io/age the encrypter would be:
+//
+//	func(plaintext string) (string, error) {
+//		buf := &strings.Builder{}
+//		a := armor.NewWriter(buf)
+//		w, err := age.Encrypt(a, recipients...)
+//		if err != nil {
+//			return "", err
+//		}
+//		if _, err := io.WriteString(w, plaintext); err != nil {
+//			return "", err
+//		}
+//		if err := w.Close(); err != nil {
+//			return "", err
+//		}
+//		err = a.Close()
+//		return buf.String(), err
+//	}
+func WithAgeEncrypter(encrypt Encrypter) CreateOption {
+	return func(f *FigTree) {
+		f.ageEncrypter = encrypt
+	}
+}
+
+// WithEncryptOnSave sets the keys that are encrypted by EncryptValues before
+// a config is written to disk, so values like tokens are never written in
+// plaintext.  Keys are dot separated patterns matched like WithIgnoreKeys,
+// all values nested under a matching key are encrypted.
+func WithEncryptOnSave(fields ...string) CreateOption {
+	return func(f *FigTree) {
+		for _, field := range fields {
+			f.encryptKeys = append(f.encryptKeys, strings.Split(field, "."))
+		}
+	}
+}
+
+// EncryptValues replaces the values in the config document node for the keys
+// set with WithEncryptOnSave with `!age` tagged ciphertext from the
+// WithAgeEncrypter function.  Values that are already encrypted are left
+// as-is.  This should be used on a config before it is written, the
+// encrypted values are decrypted when the config is loaded with
+// WithAgeDecrypter.  Numbers and bools are resolved to their type again
+// when decrypted, strings that look like another type, like "123", are
+// tagged `!age:str` so they are still strings.  Other tagged values, like
+// `!!binary`, cannot be encrypted.
+func (f *FigTree) EncryptValues(node *yaml.Node) error {
+	if len(f.encryptKeys) == 0 {
+		return nil
+	}
+	return f.encryptValues(node, nil, false)
+}
+
+func (f *FigTree) encryptValues(node *yaml.Node, keyPath []string, matched bool) error {
+	if node == nil || node.Kind == yaml.AliasNode {
+		// the anchored node for aliases is encrypted where it is defined
+		return nil
+	}
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, content := range node.Content {
+			if err := f.encryptValues(content, keyPath, matched); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := append(keyPath[:len(keyPath):len(keyPath)], node.Content[i].Value)
+			childMatched := matched || matchesKeyPatterns(f.encryptKeys, childPath)
+			if err := f.encryptValues(node.Content[i+1], childPath, childMatched); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !matched || isEncrypted(node) || node.ShortTag() == "!!null" {
+			return nil
+		}
+		// the type is restored when decrypted by resolving the plaintext
+		// like a plain scalar, strings that would be resolved as another
+		// type are tagged as encrypted strings
+		tag := ageTag
+		if resolved := plainScalarTag(node.Value); resolved != node.ShortTag() {
+			if node.ShortTag() != "!!str" {
+				return errors.Errorf("%s cannot be encrypted, %s values are not supported", strings.Join(keyPath, "."), node.ShortTag())
+			}
+			tag = ageStrTag
+		}
+		if f.ageEncrypter == nil {
+			return errors.Errorf("%s must be encrypted but no age encrypter was configured", strings.Join(keyPath, "."))
+		}
+		ciphertext, err := f.ageEncrypter(node.Value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encrypt value for %s", strings.Join(keyPath, "."))
+		}
+		node.Value = ciphertext
+		node.Tag = tag
+		node.Style = yaml.LiteralStyle
+	}
+	return nil
+}
+
+// plainScalarTag returns the tag value is resolved to as a plain scalar.
+func plainScalarTag(value string) string {
+	return (&yaml.Node{Kind: yaml.ScalarNode, Value: value}).ShortTag()
+}
+
+// matchesKeyPatterns returns true if keyPath matches any of the patterns,
+// each element of a pattern is matched with path.Match.
+func matchesKeyPatterns(patterns [][]string, keyPath []string) bool {
+	for _, pattern := range patterns {
+		if len(pattern) != len(keyPath) {
+			continue
+		}
+		matched := true
+		for i, part := range pattern {
+			if ok, _ := path.Match(part, keyPath[i]); !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
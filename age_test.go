@@ -0,0 +1,217 @@
+package figtree
+
+import (
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/coryb/walky"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// reverseDecrypter is a stand in for age decryption, the ciphertext is just
+// the reversed plaintext.
+func reverseDecrypter(ciphertext string) (string, error) {
+	ciphertext = strings.TrimSpace(ciphertext)
+	if ciphertext == "bad" {
+		return "", errors.New("no identity matched")
+	}
+	runes := []rune(ciphertext)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes), nil
+}
+
+func TestAgeDecrypter(t *testing.T) {
+	type config struct {
+		User     StringOption     `yaml:"user"`
+		Password StringOption     `yaml:"password"`
+		Tokens   ListStringOption `yaml:"tokens"`
+	}
+
+	src, err := SourceFromString("config.yml", `
+user: bob
+password: !age |
+  2retnuh
+tokens:
+  - !age 1nekot
+  - plain
+`)
+	require.NoError(t, err)
+
+	opts := config{}
+	fig := newFigTreeFromEnv(WithAgeDecrypter(reverseDecrypter))
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	secret := func(line, col int) SourceLocation {
+		s := tSrc("config.yml", line, col)
+		s.Secret = true
+		return s
+	}
+	assert.Equal(t, StringOption{tSrc("config.yml", 2, 7), true, "bob"}, opts.User)
+	assert.Equal(t, StringOption{secret(3, 11), true, "hunter2"}, opts.Password)
+	assert.Equal(t, ListStringOption{
+		{secret(6, 5), true, "token1"},
+		{tSrc("config.yml", 7, 5), true, "plain"},
+	}, opts.Tokens)
+
+	// decryption failures are reported with the value location
+	src, err = SourceFromString("config.yml", "password: !age bad\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt value at config.yml:1:11: no identity matched")
+
+	// encrypted values require a decrypter
+	src, err = SourceFromString("config.yml", "password: !age 2retnuh\n")
+	require.NoError(t, err)
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:11: encrypted value found but no age decrypter was configured")
+}
+
+func TestEncryptOnSave(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+user: bob
+token: hunter2
+servers:
+  prod:
+    host: prod.example.com
+    password: secret
+`), &node))
+
+	fig := newFigTreeFromEnv(
+		WithAgeEncrypter(Encrypter(reverseDecrypter)),
+		WithAgeDecrypter(reverseDecrypter),
+		WithEncryptOnSave("token", "servers.*.password"),
+	)
+	require.NoError(t, fig.EncryptValues(&node))
+	data, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, `user: bob
+token: !age |-
+    2retnuh
+servers:
+    prod:
+        host: prod.example.com
+        password: !age |-
+            terces
+`, string(data))
+
+	// encrypted values are not encrypted again
+	require.NoError(t, fig.EncryptValues(&node))
+	again, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+
+	// the saved config loads with the plaintext values
+	type config struct {
+		User    StringOption `yaml:"user"`
+		Token   StringOption `yaml:"token"`
+		Servers map[string]struct {
+			Password StringOption `yaml:"password"`
+		} `yaml:"servers"`
+	}
+	src, err := SourceFromString("config.yml", string(data))
+	require.NoError(t, err)
+	opts := config{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &opts))
+	assert.Equal(t, "hunter2", opts.Token.Value)
+	assert.True(t, opts.Token.Source.Secret)
+	assert.Equal(t, "secret", opts.Servers["prod"].Password.Value)
+	assert.False(t, opts.User.Source.Secret)
+
+	// encrypting without an encrypter fails rather than writing plaintext
+	fig = newFigTreeFromEnv(WithEncryptOnSave("user"))
+	err = fig.EncryptValues(&node)
+	require.Error(t, err)
+	assert.Equal(t, "user must be encrypted but no age encrypter was configured", err.Error())
+}
+
+func TestAgeDecryptTypes(t *testing.T) {
+	type config struct {
+		Port    IntOption    `yaml:"port"`
+		Enabled bool         `yaml:"enabled"`
+		Token   StringOption `yaml:"token"`
+	}
+	src, err := SourceFromString("config.yml", "port: !age 0808\nenabled: !age eurt\ntoken: !age 1nekot\n")
+	require.NoError(t, err)
+
+	failing := Decrypter(func(string) (string, error) {
+		return "", errors.New("wrong key")
+	})
+	fig := newFigTreeFromEnv(WithAgeIdentities(failing, Decrypter(reverseDecrypter)))
+	for i := 0; i < 2; i++ {
+		// the source keeps the encrypted values, so loading it again
+		// still decrypts the values as secrets
+		opts := config{}
+		require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &opts))
+		assert.Equal(t, 8080, opts.Port.Value)
+		assert.True(t, opts.Port.Source.Secret)
+		assert.True(t, opts.Enabled)
+		assert.Equal(t, "token1", opts.Token.Value)
+		assert.True(t, opts.Token.Source.Secret)
+	}
+	assert.Equal(t, ageTag, walky.GetKey(walky.UnwrapDocument(src.Config), "port").Tag)
+
+	fig = newFigTreeFromEnv(WithAgeIdentities(failing))
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt value at config.yml:1:7: wrong key")
+}
+
+func TestEncryptOnSaveTypes(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+port: 8080
+enabled: true
+pin: "1234"
+token: hunter2
+`), &node))
+
+	fig := newFigTreeFromEnv(
+		WithAgeEncrypter(Encrypter(reverseDecrypter)),
+		WithAgeDecrypter(reverseDecrypter),
+		WithEncryptOnSave("port", "enabled", "pin", "token"),
+	)
+	require.NoError(t, fig.EncryptValues(&node))
+	data, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, `port: !age |-
+    0808
+enabled: !age |-
+    eurt
+pin: !age:str |-
+    4321
+token: !age |-
+    2retnuh
+`, string(data))
+
+	type config struct {
+		Port    IntOption    `yaml:"port"`
+		Enabled BoolOption   `yaml:"enabled"`
+		Pin     StringOption `yaml:"pin"`
+		Token   StringOption `yaml:"token"`
+	}
+	src, err := SourceFromString("config.yml", string(data))
+	require.NoError(t, err)
+	opts := config{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &opts))
+	assert.Equal(t, 8080, opts.Port.Value)
+	assert.True(t, opts.Enabled.Value)
+	assert.Equal(t, "1234", opts.Pin.Value)
+	assert.Equal(t, "hunter2", opts.Token.Value)
+	untyped := map[string]any{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &untyped))
+	assert.Equal(t, map[string]any{"port": 8080, "enabled": true, "pin": "1234", "token": "hunter2"}, untyped)
+
+	require.NoError(t, yaml.Unmarshal([]byte("token: !!binary aGVsbG8=\n"), &node))
+	err = fig.EncryptValues(&node)
+	require.Error(t, err)
+	assert.Equal(t, "token cannot be encrypted, !!binary values are not supported", err.Error())
+}
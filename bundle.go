@@ -0,0 +1,84 @@
+package figtree
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// OpenBundle returns the directory tree of configs in a zip, tar or gzip
+// compressed tar bundle file as an fs.FS, so the configs can be loaded with
+// WithFS without unpacking the bundle.  The paths in the bundle are rooted
+// at `/`, so with WithCwd("/app") the configs are found in `app/` and its
+// parents in the bundle, and with WithSourceNames(SourceNameAbsolute) the
+// source names are the paths in the bundle.  The bundle format is detected
+// from the file contents.
+func OpenBundle(file string) (fs.FS, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	fsys, err := bundleFS(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open bundle %s", file)
+	}
+	return fsys, nil
+}
+
+// bundleFS returns the fs.FS for the bundle data, tar bundles are converted
+// to an in memory zip so both formats share the zip fs.FS implementation.
+func bundleFS(data []byte) (fs.FS, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) || bytes.HasPrefix(data, []byte("PK\x05\x06")) {
+		return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	}
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			// directories are implied by the file paths, links and
+			// other special files are not supported
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Store,
+			Modified: hdr.ModTime,
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, err := io.Copy(w, tr); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
@@ -0,0 +1,77 @@
+package figtree
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenBundle(t *testing.T) {
+	files := map[string]string{
+		"figtree.yml":         "str1: top\nint1: 1\n",
+		"app/figtree.yml":     "str1: app\n",
+		"app/sub/figtree.yml": "bool1: true\n",
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	dir := t.TempDir()
+
+	zipFile := filepath.Join(dir, "configs.zip")
+	out, err := os.Create(zipFile)
+	require.NoError(t, err)
+	zw := zip.NewWriter(out)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, out.Close())
+
+	tarFile := filepath.Join(dir, "configs.tar.gz")
+	out, err = os.Create(tarFile)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "./app/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, name := range names {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "./" + name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(files[name]))}))
+		_, err = tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, out.Close())
+
+	for _, bundle := range []string{zipFile, tarFile} {
+		t.Run(filepath.Base(bundle), func(t *testing.T) {
+			fsys, err := OpenBundle(bundle)
+			require.NoError(t, err)
+			fig := newFigTreeFromEnv(
+				WithFS(fsys),
+				WithHome("/home"),
+				WithCwd("/app/sub"),
+				WithSourceNames(SourceNameAbsolute),
+			)
+			opts := TestOptions{}
+			require.NoError(t, fig.LoadAllConfigs("figtree.yml", &opts))
+			assert.Equal(t, StringOption{tSrc("/app/figtree.yml", 1, 7), true, "app"}, opts.String1)
+			assert.Equal(t, IntOption{tSrc("/figtree.yml", 2, 7), true, 1}, opts.Int1)
+			assert.Equal(t, BoolOption{tSrc("/app/sub/figtree.yml", 1, 8), true, true}, opts.Bool1)
+		})
+	}
+
+	_, err = OpenBundle(filepath.Join(dir, "missing.zip"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+// Command figtree provides development time utilities for the figtree
+// config library.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/coryb/figtree"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	app := kingpin.New("figtree", "Development utilities for figtree configs.")
+
+	generate := app.Command("generate", "Generate a typed options struct from an example config file.")
+	pkg := generate.Flag("package", "Package name for the generated file.").Default("main").String()
+	name := generate.Flag("name", "Name of the generated struct type.").Default("Options").String()
+	file := generate.Arg("file", "Example yaml config file.").Required().ExistingFile()
+
+	lint := app.Command("lint", "Check config files for suspicious patterns.")
+	lintFiles := lint.Arg("files", "Config files to check.").Required().ExistingFiles()
+
+	switch kingpin.MustParse(app.Parse(os.Args[1:])) {
+	case generate.FullCommand():
+		if err := runGenerate(*pkg, *name, *file); err != nil {
+			app.Fatalf("%s", err)
+		}
+	case lint.FullCommand():
+		ok, err := runLint(*lintFiles)
+		if err != nil {
+			app.Fatalf("%s", err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	}
+}
+
+func runGenerate(pkg, name, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	src, err := figtree.GenerateStruct(&node, figtree.WithStructName(name))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("package %s\n\nimport \"github.com/coryb/figtree\"\n\n%s", pkg, src)
+	return nil
+}
+
+func runLint(files []string) (bool, error) {
+	fig := figtree.NewFigTree(figtree.WithoutExec())
+	sources := []figtree.ConfigSource{}
+	for _, file := range files {
+		source, err := fig.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		if source != nil {
+			sources = append(sources, *source)
+		}
+	}
+	findings := fig.Lint(sources)
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+	return len(findings) == 0, nil
+}
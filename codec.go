@@ -0,0 +1,525 @@
+package figtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// EncodeOptions control how Options are serialized by Marshal and
+// MarshalJSON.
+type EncodeOptions struct {
+	// Sources will serialize each Option as an object with explicit value,
+	// source and defined properties rather than just the Option value.
+	Sources bool
+	// Indent is the number of spaces used to indent nested data.  For JSON
+	// a zero value produces compact output, for YAML the yaml library
+	// default is used.
+	Indent int
+	// DefinedOnly will omit all Options that are undefined or only have
+	// a default value, along with any empty fields, lists, maps and structs
+	// that contain them.
+	DefinedOnly bool
+}
+
+type EncodeOption func(*EncodeOptions)
+
+// WithSources will serialize Options as `{value, source, defined}` objects
+// so that the option provenance is preserved.
+func WithSources() EncodeOption {
+	return func(o *EncodeOptions) {
+		o.Sources = true
+	}
+}
+
+// WithDefinedOnly will serialize only the Options that have been defined by a
+// source other than the defaults, regardless of any `omitempty` tags.  This is
+// useful to write minimal config files that contain just the values that
+// were explicitly set.
+func WithDefinedOnly() EncodeOption {
+	return func(o *EncodeOptions) {
+		o.DefinedOnly = true
+	}
+}
+
+// WithIndent sets the number of spaces used to indent nested data.
+func WithIndent(spaces int) EncodeOption {
+	return func(o *EncodeOptions) {
+		o.Indent = spaces
+	}
+}
+
+func newEncodeOptions(opts ...EncodeOption) EncodeOptions {
+	eo := EncodeOptions{}
+	for _, opt := range opts {
+		opt(&eo)
+	}
+	return eo
+}
+
+// Marshal will serialize v to YAML.  Unlike yaml.Marshal the output does not
+// depend on the global StringifyValue setting: Options are serialized as just
+// their value (undefined Options are null) unless WithSources is used.  Map
+// keys are sorted so the output is identical for identical options.
+func Marshal(v any, opts ...EncodeOption) ([]byte, error) {
+	eo := newEncodeOptions(opts...)
+	encoded, err := encoder{sources: eo.Sources, definedOnly: eo.DefinedOnly}.convert(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	if eo.Indent > 0 {
+		enc.SetIndent(eo.Indent)
+	}
+	if err := enc.Encode(encoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON will serialize v to JSON.  Unlike json.Marshal the output does
+// not depend on the global StringifyValue setting: Options are serialized as
+// just their value (undefined Options are null) unless WithSources is used.
+func MarshalJSON(v any, opts ...EncodeOption) ([]byte, error) {
+	eo := newEncodeOptions(opts...)
+	encoded, err := encoder{sources: eo.Sources, definedOnly: eo.DefinedOnly}.convert(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if eo.Indent > 0 {
+		return json.MarshalIndent(encoded, "", strings.Repeat(" ", eo.Indent))
+	}
+	return json.Marshal(encoded)
+}
+
+// MarshalJSONWithSource will serialize v to JSON where all Options are
+// serialized as `{"value": ..., "source": ..., "defined": ...}` objects so
+// that the option provenance is preserved.  The output can be restored with
+// UnmarshalJSONWithSource.  This is equivalent to
+// `MarshalJSON(v, WithSources())`.
+func MarshalJSONWithSource(v any) ([]byte, error) {
+	return MarshalJSON(v, WithSources())
+}
+
+// MarshalIndentWithSource is like MarshalJSONWithSource but applies Indent to
+// format the output, see json.MarshalIndent.
+func MarshalIndentWithSource(v any, prefix, indent string) ([]byte, error) {
+	encoded, err := encoder{sources: true}.convert(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(encoded, prefix, indent)
+}
+
+// UnmarshalJSONWithSource will parse JSON data generated by
+// MarshalJSONWithSource into v, which must be a pointer.  Options will have
+// their Source and Defined properties restored from the data.
+func UnmarshalJSONWithSource(data []byte, v any) error {
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() {
+		return errors.Errorf("UnmarshalJSONWithSource requires a non-nil pointer, got %T", v)
+	}
+	dst = dst.Elem()
+	tmp := reflect.New(encoder{sources: true}.encodedType(dst.Type()))
+	if err := json.Unmarshal(data, tmp.Interface()); err != nil {
+		return errors.WithStack(err)
+	}
+	return fromSourced(tmp.Elem(), dst)
+}
+
+var (
+	optionInterfaceType = reflect.TypeOf((*option)(nil)).Elem()
+	encodedSourceType   = reflect.TypeOf(&encodedSource{})
+	encodedTypeCache    sync.Map
+	dynamicTypeCache    sync.Map
+)
+
+func isOptionType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(optionInterfaceType)
+}
+
+// encoder converts values containing Options into equivalent values where
+// the Options have been replaced, so the result can be serialized by the
+// json and yaml libraries without using the Option marshalers.
+type encoder struct {
+	sources     bool
+	definedOnly bool
+}
+
+type encodedTypeKey struct {
+	typ         reflect.Type
+	sources     bool
+	definedOnly bool
+}
+
+// encodedType returns a type equivalent to t where every Option has been
+// replaced.  When encoding with sources the Option is replaced with a struct
+// with explicit value, source and defined fields, otherwise it is replaced
+// with a pointer to the value, which will be nil for undefined Options.
+// Types that do not contain any Options are returned unchanged so that any
+// custom marshaling is preserved.
+func (e encoder) encodedType(t reflect.Type) reflect.Type {
+	key := encodedTypeKey{typ: t, sources: e.sources, definedOnly: e.definedOnly}
+	if cached, ok := encodedTypeCache.Load(key); ok {
+		return cached.(reflect.Type)
+	}
+	et := e.buildEncodedType(t, map[reflect.Type]bool{})
+	encodedTypeCache.Store(key, et)
+	return et
+}
+
+func (e encoder) buildEncodedType(t reflect.Type, inProgress map[reflect.Type]bool) reflect.Type {
+	if inProgress[t] {
+		// recursive types are left as-is, Options nested within the
+		// recursion will be serialized with their default encoding.
+		return t
+	}
+	inProgress[t] = true
+	defer delete(inProgress, t)
+
+	if isOptionType(t) {
+		valueField, _ := t.FieldByName("Value")
+		valueType := e.buildEncodedType(valueField.Type, inProgress)
+		if !e.sources {
+			return reflect.PointerTo(valueType)
+		}
+		sourced := reflect.StructOf([]reflect.StructField{{
+			Name: "Value",
+			Type: valueType,
+			Tag:  `json:"value" yaml:"value"`,
+		}, {
+			Name: "Source",
+			Type: encodedSourceType,
+			Tag:  `json:"source,omitempty" yaml:"source,omitempty"`,
+		}, {
+			Name: "Defined",
+			Type: reflect.TypeOf(true),
+			Tag:  `json:"defined" yaml:"defined"`,
+		}})
+		if e.definedOnly {
+			// undefined Options are nil so they can be omitted
+			return reflect.PointerTo(sourced)
+		}
+		return sourced
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		if elem := e.buildEncodedType(t.Elem(), inProgress); elem != t.Elem() {
+			return reflect.PointerTo(elem)
+		}
+	case reflect.Slice:
+		if elem := e.buildEncodedType(t.Elem(), inProgress); elem != t.Elem() {
+			return reflect.SliceOf(elem)
+		}
+	case reflect.Array:
+		if elem := e.buildEncodedType(t.Elem(), inProgress); elem != t.Elem() {
+			return reflect.ArrayOf(t.Len(), elem)
+		}
+	case reflect.Map:
+		if elem := e.buildEncodedType(t.Elem(), inProgress); elem != t.Elem() {
+			return reflect.MapOf(t.Key(), elem)
+		}
+	case reflect.Struct:
+		changed := false
+		fields := []reflect.StructField{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				// unexported fields are never serialized
+				changed = true
+				continue
+			}
+			fieldType := e.buildEncodedType(field.Type, inProgress)
+			if fieldType != field.Type {
+				changed = true
+			}
+			fields = append(fields, reflect.StructField{
+				Name:      field.Name,
+				Type:      fieldType,
+				Tag:       field.Tag,
+				Anonymous: field.Anonymous,
+			})
+		}
+		if changed && e.definedOnly {
+			for i, field := range fields {
+				if field.Anonymous || isInlineTag(field.Tag) {
+					continue
+				}
+				fields[i].Tag = omitEmptyTag(field.Tag)
+				if field.Type.Kind() == reflect.Struct && !isOptionType(t.Field(i).Type) {
+					// empty structs are nil so they can be omitted
+					fields[i].Type = reflect.PointerTo(field.Type)
+				}
+			}
+		}
+		if changed {
+			return reflect.StructOf(fields)
+		}
+	}
+	return t
+}
+
+// isInlineTag returns true if the yaml tag has the inline flag.
+func isInlineTag(tag reflect.StructTag) bool {
+	yamlTag, _ := tag.Lookup("yaml")
+	for _, flag := range strings.Split(yamlTag, ",")[1:] {
+		if flag == "inline" {
+			return true
+		}
+	}
+	return false
+}
+
+// omitEmptyTag returns tag with the omitempty flag added to the json and
+// yaml tags.
+func omitEmptyTag(tag reflect.StructTag) reflect.StructTag {
+	result := string(tag)
+	for _, key := range []string{"json", "yaml"} {
+		value, ok := tag.Lookup(key)
+		if value == "-" {
+			continue
+		}
+		parts := strings.Split(value, ",")
+		hasOmitEmpty := false
+		for _, flag := range parts[1:] {
+			if flag == "omitempty" {
+				hasOmitEmpty = true
+			}
+		}
+		if hasOmitEmpty {
+			continue
+		}
+		updated := key + ":" + strconv.Quote(value+",omitempty")
+		if ok {
+			result = strings.Replace(result, key+":"+strconv.Quote(value), updated, 1)
+		} else {
+			result = strings.TrimSpace(result + " " + updated)
+		}
+	}
+	return reflect.StructTag(result)
+}
+
+// isDynamicType returns true if t contains any interface types which might
+// hold Options at runtime.
+func isDynamicType(t reflect.Type) bool {
+	if cached, ok := dynamicTypeCache.Load(t); ok {
+		return cached.(bool)
+	}
+	dynamic := buildIsDynamicType(t, map[reflect.Type]bool{})
+	dynamicTypeCache.Store(t, dynamic)
+	return dynamic
+}
+
+func buildIsDynamicType(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return buildIsDynamicType(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath == "" && buildIsDynamicType(field.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// convert returns src as a value of the encodedType for src.
+func (e encoder) convert(src reflect.Value) (any, error) {
+	if !src.IsValid() {
+		return nil, nil
+	}
+	dst := reflect.New(e.encodedType(src.Type())).Elem()
+	if err := e.copy(src, dst); err != nil {
+		return nil, err
+	}
+	return dst.Interface(), nil
+}
+
+// copy copies src into dst, where dst is the encodedType of src.
+func (e encoder) copy(src, dst reflect.Value) error {
+	if src.Type() == dst.Type() && !isDynamicType(src.Type()) {
+		dst.Set(src)
+		return nil
+	}
+	if isOptionType(src.Type()) {
+		defined := src.FieldByName("Defined").Bool()
+		if e.definedOnly && (!defined || toOption(src).IsDefault()) {
+			return nil
+		}
+		if !e.sources {
+			if !defined {
+				return nil
+			}
+			dst.Set(reflect.New(dst.Type().Elem()))
+			return e.copy(src.FieldByName("Value"), dst.Elem())
+		}
+		if e.definedOnly {
+			dst.Set(reflect.New(dst.Type().Elem()))
+			dst = dst.Elem()
+		}
+		if err := e.copy(src.FieldByName("Value"), dst.Field(0)); err != nil {
+			return err
+		}
+		source := src.FieldByName("Source").Interface().(SourceLocation)
+		if source.Name != "" || source.Location != nil {
+			dst.Field(1).Set(reflect.ValueOf(newEncodedSource(source)))
+		}
+		dst.Field(2).SetBool(defined)
+		return nil
+	}
+	if e.definedOnly && src.Kind() == reflect.Struct && dst.Kind() == reflect.Pointer {
+		tmp := reflect.New(dst.Type().Elem())
+		if err := e.copy(src, tmp.Elem()); err != nil {
+			return err
+		}
+		if !tmp.Elem().IsZero() {
+			dst.Set(tmp)
+		}
+		return nil
+	}
+	switch src.Kind() {
+	case reflect.Interface:
+		if src.IsNil() {
+			return nil
+		}
+		elem, err := e.convert(src.Elem())
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(elem))
+	case reflect.Pointer:
+		if src.IsNil() {
+			return nil
+		}
+		dst.Set(reflect.New(dst.Type().Elem()))
+		return e.copy(src.Elem(), dst.Elem())
+	case reflect.Slice:
+		if src.IsNil() {
+			return nil
+		}
+		dst.Set(reflect.MakeSlice(dst.Type(), src.Len(), src.Len()))
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			if err := e.copy(src.Index(i), dst.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return nil
+		}
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := e.copy(iter.Value(), elem); err != nil {
+				return err
+			}
+			if e.definedOnly && isOptionType(src.Type().Elem()) && elem.IsZero() {
+				continue
+			}
+			dst.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.Struct:
+		if src.Type() == dst.Type() {
+			// copy the unexported fields, the exported fields are
+			// converted below.
+			dst.Set(src)
+		}
+		for i := 0; i < dst.NumField(); i++ {
+			field := dst.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if err := e.copy(src.FieldByName(field.Name), dst.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("cannot convert %s to %s", src.Type(), dst.Type())
+	}
+	return nil
+}
+
+// fromSourced copies src, a value of the encodedType (with sources) for dst,
+// into dst.
+func fromSourced(src, dst reflect.Value) error {
+	if src.Type() == dst.Type() {
+		dst.Set(src)
+		return nil
+	}
+	if isOptionType(dst.Type()) {
+		if err := fromSourced(src.Field(0), dst.FieldByName("Value")); err != nil {
+			return err
+		}
+		source := SourceLocation{}
+		if es, ok := src.Field(1).Interface().(*encodedSource); ok && es != nil {
+			source = es.sourceLocation()
+		}
+		dst.FieldByName("Source").Set(reflect.ValueOf(source))
+		dst.FieldByName("Defined").SetBool(src.Field(2).Bool())
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return nil
+		}
+		dst.Set(reflect.New(dst.Type().Elem()))
+		return fromSourced(src.Elem(), dst.Elem())
+	case reflect.Slice:
+		if src.IsNil() {
+			return nil
+		}
+		dst.Set(reflect.MakeSlice(dst.Type(), src.Len(), src.Len()))
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			if err := fromSourced(src.Index(i), dst.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return nil
+		}
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := fromSourced(iter.Value(), elem); err != nil {
+				return err
+			}
+			dst.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			name := src.Type().Field(i).Name
+			if err := fromSourced(src.Field(i), dst.FieldByName(name)); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("cannot convert %s to %s", src.Type(), dst.Type())
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package figtree
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalJSONWithSource(t *testing.T) {
+	type nested struct {
+		Port IntOption `json:"port"`
+	}
+	type options struct {
+		Name   StringOption             `json:"name"`
+		Labels MapStringOption          `json:"labels,omitempty"`
+		Hosts  ListStringOption         `json:"hosts,omitempty"`
+		Nested *nested                  `json:"nested,omitempty"`
+		Any    any                      `json:"any,omitempty"`
+		Plain  map[string]int           `json:"plain,omitempty"`
+		Unset  StringOption             `json:"unset"`
+		ByKey  map[string]ListIntOption `json:"by-key,omitempty"`
+		Raw    map[string]RawTypeOption `json:"raw,omitempty"`
+	}
+	opts := options{
+		Name:   StringOption{tSrc("figtree.yml", 1, 7), true, "name"},
+		Labels: MapStringOption{"key": NewStringOption("value")},
+		Hosts:  ListStringOption{StringOption{NewSource("override"), true, "host"}},
+		Nested: &nested{Port: IntOption{tSrc("../figtree.yml", 2, 9), true, 80}},
+		Any:    map[string]any{"opt": NewBoolOption(true)},
+		Plain:  map[string]int{"a": 1},
+		ByKey:  map[string]ListIntOption{"k": {NewIntOption(1)}},
+		Raw:    map[string]RawTypeOption{"r": {tSrc("figtree.yml", 3, 4), true, []any{"x"}}},
+	}
+
+	got, err := MarshalIndentWithSource(&opts, "", "  ")
+	require.NoError(t, err)
+	expected := `{
+  "name": {
+    "value": "name",
+    "source": {
+      "name": "figtree.yml",
+      "line": 1,
+      "column": 7
+    },
+    "defined": true
+  },
+  "labels": {
+    "key": {
+      "value": "value",
+      "source": {
+        "name": "default"
+      },
+      "defined": true
+    }
+  },
+  "hosts": [
+    {
+      "value": "host",
+      "source": {
+        "name": "override"
+      },
+      "defined": true
+    }
+  ],
+  "nested": {
+    "port": {
+      "value": 80,
+      "source": {
+        "name": "../figtree.yml",
+        "line": 2,
+        "column": 9
+      },
+      "defined": true
+    }
+  },
+  "any": {
+    "opt": {
+      "value": true,
+      "source": {
+        "name": "default"
+      },
+      "defined": true
+    }
+  },
+  "plain": {
+    "a": 1
+  },
+  "unset": {
+    "value": "",
+    "defined": false
+  },
+  "by-key": {
+    "k": [
+      {
+        "value": 1,
+        "source": {
+          "name": "default"
+        },
+        "defined": true
+      }
+    ]
+  },
+  "raw": {
+    "r": {
+      "value": [
+        "x"
+      ],
+      "source": {
+        "name": "figtree.yml",
+        "line": 3,
+        "column": 4
+      },
+      "defined": true
+    }
+  }
+}`
+	assert.Equal(t, expected, string(got))
+
+	got, err = MarshalJSONWithSource(opts.Name)
+	require.NoError(t, err)
+	assert.Equal(t, `{"value":"name","source":{"name":"figtree.yml","line":1,"column":7},"defined":true}`, string(got))
+}
+
+func TestUnmarshalJSONWithSource(t *testing.T) {
+	opts := TestOptions{}
+	require.NoError(t, os.Chdir("d1/d2/d3"))
+	t.Cleanup(func() {
+		_ = os.Chdir("../../..")
+	})
+	fig := newFigTreeFromEnv()
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+
+	data, err := MarshalJSONWithSource(&opts)
+	require.NoError(t, err)
+
+	got := TestOptions{}
+	err = UnmarshalJSONWithSource(data, &got)
+	require.NoError(t, err)
+	assert.Exactly(t, opts, got)
+
+	err = UnmarshalJSONWithSource(data, got)
+	assert.Error(t, err)
+}
+
+func TestMarshal(t *testing.T) {
+	opts := TestOptions{}
+	require.NoError(t, os.Chdir("d1/d2/d3"))
+	t.Cleanup(func() {
+		_ = os.Chdir("../../..")
+	})
+	fig := newFigTreeFromEnv()
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	opts.Map1 = MapStringOption{"key": opts.Map1["key3"]}
+	opts.Array1 = opts.Array1[:1]
+	opts.Bool1.Value = false
+
+	// the test init sets StringifyValue to false, Marshal must not be
+	// affected by it.
+	got, err := Marshal(&opts, WithIndent(2))
+	require.NoError(t, err)
+	expected := `str1: d3str1val1
+arr1:
+  - d3arr1val1
+map1:
+  key: d3map1val3
+int1: 333
+float1: 3.33
+bool1: false
+`
+	assert.Equal(t, expected, string(got))
+
+	got, err = MarshalJSON(&opts)
+	require.NoError(t, err)
+	assert.Equal(t, `{"str1":"d3str1val1","arr1":["d3arr1val1"],"map1":{"key":"d3map1val3"},"int1":333,"float1":3.33,"bool1":false}`, string(got))
+
+	got, err = Marshal(&opts, WithSources(), WithIndent(2))
+	require.NoError(t, err)
+	expected = `str1:
+  value: d3str1val1
+  source:
+    name: figtree.yml
+    line: 1
+    column: 7
+  defined: true
+arr1:
+  - value: d3arr1val1
+    source:
+      name: figtree.yml
+      line: 3
+      column: 5
+    defined: true
+map1:
+  key:
+    value: d3map1val3
+    source:
+      name: figtree.yml
+      line: 8
+      column: 9
+    defined: true
+int1:
+  value: 333
+  source:
+    name: figtree.yml
+    line: 10
+    column: 7
+  defined: true
+float1:
+  value: 3.33
+  source:
+    name: figtree.yml
+    line: 11
+    column: 9
+  defined: true
+bool1:
+  value: false
+  source:
+    name: figtree.yml
+    line: 12
+    column: 8
+  defined: true
+`
+	assert.Equal(t, expected, string(got))
+}
+
+func TestMarshalDefinedOnly(t *testing.T) {
+	type nested struct {
+		Port IntOption    `json:"port" yaml:"port"`
+		Host StringOption `json:"host" yaml:"host"`
+	}
+	type options struct {
+		Name    StringOption     `json:"name" yaml:"name"`
+		Default StringOption     `json:"default" yaml:"default"`
+		Unset   StringOption     `json:"unset" yaml:"unset"`
+		Off     BoolOption       `json:"disabled" yaml:"disabled"`
+		Labels  MapStringOption  `json:"labels" yaml:"labels"`
+		Hosts   ListStringOption `json:"hosts" yaml:"hosts"`
+		Nested  nested           `json:"nested" yaml:"nested"`
+		Empty   nested           `json:"empty" yaml:"empty"`
+		Count   int              `json:"count" yaml:"count"`
+		Skip    StringOption     `json:"-" yaml:"-"`
+	}
+	opts := options{
+		Name:    StringOption{tSrc("figtree.yml", 1, 7), true, "name"},
+		Default: NewStringOption("default"),
+		Off:     BoolOption{NewSource("override"), true, false},
+		Labels: MapStringOption{
+			"set":     StringOption{tSrc("figtree.yml", 3, 8), true, "value"},
+			"default": NewStringOption("value"),
+		},
+		Nested: nested{Port: IntOption{tSrc("figtree.yml", 5, 9), true, 80}},
+		Empty:  nested{Host: NewStringOption("localhost")},
+		Skip:   StringOption{tSrc("figtree.yml", 6, 7), true, "skip"},
+	}
+
+	got, err := Marshal(&opts, WithDefinedOnly())
+	require.NoError(t, err)
+	assert.Equal(t, "name: name\ndisabled: false\nlabels:\n    set: value\nnested:\n    port: 80\n", string(got))
+
+	got, err = MarshalJSON(&opts, WithDefinedOnly())
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"name","disabled":false,"labels":{"set":"value"},"nested":{"port":80}}`, string(got))
+
+	got, err = MarshalJSON(&opts, WithDefinedOnly(), WithSources())
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":{"value":"name","source":{"name":"figtree.yml","line":1,"column":7},"defined":true},`+
+		`"disabled":{"value":false,"source":{"name":"override"},"defined":true},`+
+		`"labels":{"set":{"value":"value","source":{"name":"figtree.yml","line":3,"column":8},"defined":true}},`+
+		`"nested":{"port":{"value":80,"source":{"name":"figtree.yml","line":5,"column":9},"defined":true}}}`, string(got))
+
+	// the full output is unchanged without WithDefinedOnly
+	got, err = MarshalJSON(&opts)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"name","default":"default","unset":null,"disabled":false,"labels":{"default":"value","set":"value"},"hosts":null,"nested":{"port":80,"host":null},"empty":{"port":null,"host":"localhost"},"count":0}`, string(got))
+}
@@ -0,0 +1,85 @@
+package figtree
+
+import (
+	"emperror.dev/errors"
+	"github.com/coryb/walky"
+	"gopkg.in/yaml.v3"
+)
+
+// WithCommand selects the command specific section of each config
+// document, for CLIs with subcommands, like:
+//
+//	color: auto
+//	commands:
+//	  build:
+//	    color: always
+//	  deploy:
+//	    confirm: true
+//
+// The section for the command inherits the rest of the document and takes
+// precedence over it, so with WithCommand("build") the color is "always".
+// Each document is still merged in precedence order, so a command section
+// only takes precedence over its own document and lower precedence
+// documents.  The `commands` key is not merged into the options when a
+// command is selected.
+func WithCommand(command string) CreateOption {
+	return func(f *FigTree) {
+		f.command = command
+	}
+}
+
+func (f *FigTree) WithCommand(command string) {
+	WithCommand(command)(f)
+}
+
+// commandSection returns the section of the document root for the
+// WithCommand command, if any, and root without the commands key.
+func (f *FigTree) commandSection(file string, root *yaml.Node) (*yaml.Node, *yaml.Node, error) {
+	return namedSection(file, root, "commands", f.command)
+}
+
+// namedSection returns the name section of the key map in the document
+// root, if any, and root without the key.  Nothing is stripped when name is
+// empty.
+func namedSection(file string, root *yaml.Node, key, name string) (*yaml.Node, *yaml.Node, error) {
+	if name == "" || root == nil || root.Kind != yaml.MappingNode {
+		return nil, root, nil
+	}
+	sections := walky.GetKey(root, key)
+	if sections == nil {
+		return nil, root, nil
+	}
+	if sections.Kind != yaml.MappingNode {
+		return nil, nil, errors.Errorf("%s: %s must be a map", sourceLine(file, sections), key)
+	}
+	stripped := withoutKey(root, key)
+	section := walky.GetKey(sections, name)
+	if section == nil {
+		return nil, stripped, nil
+	}
+	section = walky.Indirect(section)
+	if section.ShortTag() == "!!null" {
+		return nil, stripped, nil
+	}
+	if section.Kind != yaml.MappingNode {
+		return nil, nil, errors.Errorf("%s: %s %s must be a map", sourceLine(file, section), key, name)
+	}
+	return section, stripped, nil
+}
+
+// withoutKey returns a shallow copy of the mapping node without key, or node
+// if it does not have the key.
+func withoutKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode || walky.GetKey(node, key) == nil {
+		return node
+	}
+	stripped := *node
+	stripped.Content = make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			continue
+		}
+		stripped.Content = append(stripped.Content, node.Content[i], node.Content[i+1])
+	}
+	return &stripped
+}
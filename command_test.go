@@ -0,0 +1,82 @@
+package figtree
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllConfigsWithCommand(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml": `str1: root
+int1: 1
+commands:
+  build:
+    int1: 10
+    bool1: true
+  deploy:
+    str1: deploy
+`,
+		"app/figtree.yml": `int1: 2
+map1:
+  k: app
+commands:
+  build:
+    map1:
+      k: app-build
+`,
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "app")), WithCommand("build"))
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	expected := TestOptions{
+		String1: StringOption{tSrc("../figtree.yml", 1, 7), true, "root"},
+		// the root of a higher precedence document takes precedence over
+		// the command section of a lower precedence document
+		Int1:  IntOption{tSrc("figtree.yml", 1, 7), true, 2},
+		Bool1: BoolOption{tSrc("../figtree.yml", 6, 12), true, true},
+		Map1: MapStringOption{
+			"k": StringOption{tSrc("figtree.yml", 7, 10), true, "app-build"},
+		},
+	}
+	assert.Exactly(t, expected, opts)
+
+	opts = TestOptions{}
+	fig.WithCommand("deploy")
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "deploy", opts.String1.Value)
+	assert.False(t, opts.Bool1.Defined)
+	assert.Equal(t, "app", opts.Map1["k"].Value)
+
+	// without a command the sections are ignored
+	type withCommands struct {
+		TestOptions `yaml:",inline"`
+		Commands    map[string]map[string]any `yaml:"commands"`
+	}
+	all := withCommands{}
+	fig = newFigTreeFromEnv(WithHome(dir), WithCwd(dir))
+	err = fig.LoadAllConfigs("figtree.yml", &all)
+	require.NoError(t, err)
+	assert.Equal(t, "root", all.String1.Value)
+	assert.Equal(t, 1, all.Int1.Value)
+	assert.Equal(t, map[string]any{"str1": "deploy"}, all.Commands["deploy"])
+
+	// with a command the sections are not merged into the options
+	all = withCommands{}
+	fig.WithCommand("build")
+	err = fig.LoadAllConfigs("figtree.yml", &all)
+	require.NoError(t, err)
+	assert.Equal(t, 10, all.Int1.Value)
+	assert.Nil(t, all.Commands)
+
+	src, err := SourceFromString("figtree.yml", "commands:\n  build: [a]\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "figtree.yml:2:10: commands build must be a map")
+}
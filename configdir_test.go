@@ -0,0 +1,51 @@
+package figtree
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllConfigsConfigDir(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml":                  "str1: root\nint1: 1\nfloat1: 1.5\n",
+		"etc/app/figtree.yml":          "int1: 2\n",
+		"proj/figtree.yml":             "bool1: true\n",
+		"proj/etc/app/figtree.yml":     "str1: proj\n",
+		"proj/sub/etc/app/figtree.yml": "map1:\n  k: sub\n",
+	})
+	cwd := filepath.Join(dir, "proj", "sub")
+
+	// the config dir is probed at every level
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(cwd), WithConfigDir("etc/app"))
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	expected := TestOptions{
+		String1: StringOption{tSrc("../etc/app/figtree.yml", 1, 7), true, "proj"},
+		Int1:    IntOption{tSrc("../../etc/app/figtree.yml", 1, 7), true, 2},
+		Map1: MapStringOption{
+			"k": StringOption{tSrc("etc/app/figtree.yml", 2, 6), true, "sub"},
+		},
+	}
+	assert.Exactly(t, expected, opts)
+
+	// plain files are loaded from levels without the config dir
+	dir = writeConfigFiles(t, map[string]string{
+		"figtree.yml":              "float1: 1.5\n",
+		"proj/figtree.yml":         "str1: plain\nbool1: true\n",
+		"proj/etc/app/figtree.yml": "str1: proj\n",
+	})
+	opts = TestOptions{}
+	fig = newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "proj")), WithConfigDir("etc/app"), WithConfigDirMode(ConfigDirFirst))
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	expected = TestOptions{
+		String1: StringOption{tSrc("etc/app/figtree.yml", 1, 7), true, "proj"},
+		Float1:  Float32Option{tSrc("../figtree.yml", 1, 9), true, 1.5},
+	}
+	// proj/figtree.yml is not loaded, the config dir is preferred
+	assert.Exactly(t, expected, opts)
+}
@@ -0,0 +1,270 @@
+// Package configservice is a client for centrally managed configs served
+// over HTTP, so running figtree consumers can be updated by the server
+// rather than by watching config files.
+//
+// The protocol has two requests for a config name:
+//
+//	GET <base>/<name>
+//
+// returns the YAML config, with the config version in the ETag header.
+//
+//	GET <base>/<name>?watch=true&version=<version>
+//
+// returns a stream of newline delimited JSON updates, like:
+//
+//	{"version": "2", "config": "color: never\n"}
+//
+// The server sends an update whenever the config changes, the version
+// query parameter is the last version the client has seen, so the server
+// can send the current config immediately if it is newer.
+//
+// Client.Provider adapts a config for figtree.WithConfigProviders, so
+// FigTree.Watch loads the configs again when the service sends an update.
+package configservice
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/coryb/figtree"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotFound is returned by Fetch when the service does not have the
+// config.
+var ErrNotFound = errors.NewPlain("config not found")
+
+// Update is a version of a config sent by the service.
+type Update struct {
+	// Version identifies the config contents, it is opaque to the client.
+	Version string
+	// Source is the config, named with its URL, for use with
+	// FigTree.LoadAllConfigSources.
+	Source figtree.ConfigSource
+}
+
+// Client fetches and watches configs from a config service.
+type Client struct {
+	base          string
+	httpClient    *http.Client
+	retryInterval time.Duration
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the http.Client used for requests, otherwise
+// http.DefaultClient is used.
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithRetryInterval sets how long Watch waits before reconnecting after the
+// update stream fails or is closed by the server, the default is 5s.
+func WithRetryInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retryInterval = interval
+	}
+}
+
+// NewClient returns a Client for the config service at base, like
+// `https://config.example.com/v1/configs`.
+func NewClient(base string, options ...ClientOption) *Client {
+	c := &Client{
+		base:          strings.TrimSuffix(base, "/"),
+		httpClient:    http.DefaultClient,
+		retryInterval: 5 * time.Second,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// Fetch returns the current version of the config name.
+func (c *Client) Fetch(ctx context.Context, name string) (*Update, error) {
+	configURL := c.configURL(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/yaml")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch %s", configURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.Wrapf(ErrNotFound, "failed to fetch %s", configURL)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to fetch %s: %s", configURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", configURL)
+	}
+	return newUpdate(configURL, strings.Trim(resp.Header.Get("ETag"), `"`), data)
+}
+
+// Watch calls fn with every update to the config name until ctx is done
+// or fn returns an error.  The stream is reopened after the retry interval
+// when it fails or is closed by the server.  Watch returns the error from
+// fn, or the ctx error when ctx is done.
+func (c *Client) Watch(ctx context.Context, name string, fn func(*Update) error) error {
+	version := ""
+	for {
+		err := c.stream(ctx, name, &version, fn)
+		var fnErr callbackError
+		if errors.As(err, &fnErr) {
+			return fnErr.err
+		}
+		if ctx.Err() != nil {
+			return errors.WithStack(ctx.Err())
+		}
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(c.retryInterval):
+		}
+	}
+}
+
+// callbackError wraps errors returned by the Watch callback so they are not
+// retried.
+type callbackError struct {
+	err error
+}
+
+func (e callbackError) Error() string {
+	return e.err.Error()
+}
+
+// watchEvent is a single update in the watch stream.
+type watchEvent struct {
+	Version string `json:"version"`
+	Config  string `json:"config"`
+}
+
+// stream reads updates from a single watch request, version is updated as
+// updates are received so the next request resumes from it.
+func (c *Client) stream(ctx context.Context, name string, version *string, fn func(*Update) error) error {
+	configURL := c.configURL(name)
+	query := url.Values{"watch": {"true"}}
+	if *version != "" {
+		query.Set("version", *version)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to watch %s", configURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to watch %s: %s", configURL, resp.Status)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			// blank lines may be sent to keep the connection alive
+			continue
+		}
+		var event watchEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return errors.Wrapf(err, "invalid update from %s", configURL)
+		}
+		update, err := newUpdate(configURL, event.Version, []byte(event.Config))
+		if err != nil {
+			return err
+		}
+		*version = event.Version
+		if err := fn(update); err != nil {
+			return callbackError{err: err}
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+// Provider is a figtree.ConfigWatcher for the config name, so the config
+// can be loaded with figtree.WithConfigProviders and FigTree.Watch loads the
+// configs again when the service sends an update.
+type Provider struct {
+	client *Client
+	name   string
+
+	mu     sync.Mutex
+	latest *Update
+}
+
+var _ figtree.ConfigWatcher = (*Provider)(nil)
+
+// Provider returns a Provider for the config name.
+func (c *Client) Provider(name string) *Provider {
+	return &Provider{client: c, name: name}
+}
+
+// ReadConfigs implements figtree.ConfigProvider, it returns the latest
+// update received by WatchConfigs, or fetches the config when there is none
+// yet.  The source is ReadOnly.
+func (p *Provider) ReadConfigs(ctx context.Context) ([]figtree.ConfigSource, error) {
+	p.mu.Lock()
+	update := p.latest
+	p.mu.Unlock()
+	if update == nil {
+		var err error
+		update, err = p.client.Fetch(ctx, p.name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	source := update.Source
+	source.ReadOnly = true
+	return []figtree.ConfigSource{source}, nil
+}
+
+// WatchConfigs implements figtree.ConfigWatcher, notify is called for each
+// update with a new version until ctx is done, see Client.Watch.
+func (p *Provider) WatchConfigs(ctx context.Context, notify func()) error {
+	return p.client.Watch(ctx, p.name, func(update *Update) error {
+		p.mu.Lock()
+		changed := p.latest == nil || p.latest.Version != update.Version
+		p.latest = update
+		p.mu.Unlock()
+		if changed {
+			notify()
+		}
+		return nil
+	})
+}
+
+func (c *Client) configURL(name string) string {
+	return c.base + "/" + url.PathEscape(name)
+}
+
+func newUpdate(configURL, version string, data []byte) (*Update, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", configURL)
+	}
+	return &Update{
+		Version: version,
+		Source:  figtree.ConfigSource{Config: &node, Filename: configURL},
+	}, nil
+}
@@ -0,0 +1,137 @@
+package configservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/coryb/figtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOptions struct {
+	Color figtree.StringOption `yaml:"color"`
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	versions := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/configs/app" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("watch") != "true" {
+			w.Header().Set("ETag", `"1"`)
+			_, _ = w.Write([]byte("color: auto\n"))
+			return
+		}
+		mu.Lock()
+		versions = append(versions, r.URL.Query().Get("version"))
+		mu.Unlock()
+		// each stream sends the next version then closes, so the client
+		// must reconnect with the last version seen
+		next := "2"
+		if r.URL.Query().Get("version") == "2" {
+			next = "3"
+		}
+		enc := json.NewEncoder(w)
+		_, _ = w.Write([]byte("\n"))
+		_ = enc.Encode(watchEvent{Version: next, Config: "color: v" + next + "\n"})
+	}))
+	t.Cleanup(server.Close)
+	return server, &versions
+}
+
+func TestFetch(t *testing.T) {
+	server, _ := newTestServer(t)
+	client := NewClient(server.URL + "/configs/")
+
+	update, err := client.Fetch(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, "1", update.Version)
+	assert.Equal(t, server.URL+"/configs/app", update.Source.Filename)
+
+	opts := testOptions{}
+	fig := figtree.NewFigTree(figtree.WithEnvPrefix("CONFIGSERVICE_TEST"))
+	require.NoError(t, fig.LoadAllConfigSources([]figtree.ConfigSource{update.Source}, &opts))
+	assert.Equal(t, "auto", opts.Color.Value)
+	assert.Equal(t, server.URL+"/configs/app:1:8", opts.Color.Source.String())
+
+	_, err = client.Fetch(context.Background(), "missing")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestWatch(t *testing.T) {
+	server, requested := newTestServer(t)
+	client := NewClient(server.URL+"/configs", WithRetryInterval(time.Millisecond))
+
+	stop := errors.New("stop")
+	colors := []string{}
+	err := client.Watch(context.Background(), "app", func(update *Update) error {
+		opts := testOptions{}
+		fig := figtree.NewFigTree(figtree.WithEnvPrefix("CONFIGSERVICE_TEST"))
+		if err := fig.LoadAllConfigSources([]figtree.ConfigSource{update.Source}, &opts); err != nil {
+			return err
+		}
+		colors = append(colors, update.Version+"="+opts.Color.Value)
+		if update.Version == "3" {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []string{"2=v2", "3=v3"}, colors)
+	assert.Equal(t, []string{"", "2"}, *requested)
+}
+
+func TestWatchContextDone(t *testing.T) {
+	server, _ := newTestServer(t)
+	client := NewClient(server.URL+"/configs", WithRetryInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := client.Watch(ctx, "app", func(update *Update) error {
+		cancel()
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestProvider(t *testing.T) {
+	server, _ := newTestServer(t)
+	client := NewClient(server.URL+"/configs", WithRetryInterval(time.Millisecond))
+	dir := t.TempDir()
+	fig := figtree.NewFigTree(
+		figtree.WithEnvPrefix("CONFIGSERVICE_TEST"),
+		figtree.WithHome(dir),
+		figtree.WithCwd(dir),
+		figtree.WithConfigProviders(client.Provider("app")),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := testOptions{}
+	var reloaded *testOptions
+	var changed []string
+	err := fig.Watch(ctx, "app.yml", &opts, time.Hour, func(options any, keys []string) {
+		reloaded, changed = options.(*testOptions), keys
+		cancel()
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, "auto", opts.Color.Value)
+	assert.Equal(t, server.URL+"/configs/app:1:8", opts.Color.Source.String())
+	require.NotNil(t, reloaded)
+	assert.Contains(t, []string{"v2", "v3"}, reloaded.Color.Value)
+	assert.Equal(t, []string{"color"}, changed)
+
+	_, err = client.Provider("missing").ReadConfigs(context.Background())
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
@@ -0,0 +1,279 @@
+package figtree
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"emperror.dev/errors"
+)
+
+// constraintField is a field found while checking constraints.
+type constraintField struct {
+	path  string
+	field reflect.StructField
+	value reflect.Value
+}
+
+// describe returns the field path along with the source for Options.
+func (c constraintField) describe() string {
+	if option := toOption(c.value); option != nil && option.IsDefined() {
+		return fmt.Sprintf("%s (%s)", c.path, option.GetSource())
+	}
+	return c.path
+}
+
+// isSet returns true if the field has been set to a non-default value.
+func (c constraintField) isSet() bool {
+	if option := toOption(c.value); option != nil {
+		return option.IsDefined() && !option.IsDefault()
+	}
+	return !isZero(c.value)
+}
+
+// isDefined returns true if the field has any value, including defaults.
+func (c constraintField) isDefined() bool {
+	if option := toOption(c.value); option != nil {
+		return option.IsDefined()
+	}
+	return !isZero(c.value)
+}
+
+type constraintError struct {
+	field      constraintField
+	constraint string
+	other      constraintField
+	otherPath  string
+}
+
+func (e constraintError) Error() string {
+	switch e.constraint {
+	case "requires":
+		return fmt.Sprintf("%s requires %s to be set", e.field.describe(), e.otherPath)
+	case "required":
+		return fmt.Sprintf("%s is required", e.field.describe())
+	}
+	return fmt.Sprintf("%s conflicts with %s", e.field.describe(), e.other.describe())
+}
+
+type validationError struct {
+	field   constraintField
+	message string
+}
+
+func (e validationError) Error() string {
+	return fmt.Sprintf("%s %s", e.field.describe(), e.message)
+}
+
+// CheckConstraints will return an error if any fields in options violate the
+// constraints set with struct tags.  Fields tagged with
+// `figtree:",requires=tls.cert"` require the other field to be set when the
+// field is set, and fields tagged with `figtree:",conflicts=insecure"` cannot
+// be set when the other field is set.  Multiple fields can be separated with
+// `|`, like `requires=tls.cert|tls.key`.  Fields are the dot separated yaml
+// names from the top level options.  Default values do not trigger
+// constraints, but do satisfy requirements.
+//
+// Fields tagged with `figtree:",required"` must have a value, which may be
+// a default value.  Values can be validated with `validate` tags, like
+// `validate:"min=1,max=10"`, with the rules:
+//   - min=N and max=N limit numbers, or the length of strings, lists and maps
+//   - oneof=a|b limits the value to one of the values
+//
+// Other rules are ignored, so structs can share `validate` tags with
+// validation libraries like github.com/go-playground/validator, where the
+// oneof values are separated by spaces, like `oneof=a b`, which is also
+// accepted.
+// Validation rules are only checked for fields with a value.  The errors for
+// all failing fields are returned together, each including the source of
+// the value.
+//
+// Constraints are checked automatically at the end of LoadAllConfigSources,
+// CheckConstraints can be used again after options have been modified, for
+// example after parsing command line flags.
+func CheckConstraints(options any) error {
+	fields := map[string]constraintField{}
+	tagged := []constraintField{}
+	collectConstraintFields(reflect.ValueOf(options), nil, fields, &tagged)
+
+	var errs []error
+	for _, field := range tagged {
+		if figtreeTagFlag(field.field, "required") && !field.isDefined() {
+			errs = append(errs, constraintError{field: field, constraint: "required"})
+		}
+		if rules, ok := field.field.Tag.Lookup("validate"); ok && field.isDefined() {
+			errs = append(errs, validateField(field, rules)...)
+		}
+		if !field.isSet() {
+			continue
+		}
+		if tag, ok := figtreeTagValue(field.field, "requires"); ok {
+			for _, path := range strings.Split(tag, "|") {
+				other, found := fields[path]
+				if !found || !other.isDefined() {
+					errs = append(errs, constraintError{field: field, constraint: "requires", other: other, otherPath: path})
+				}
+			}
+		}
+		if tag, ok := figtreeTagValue(field.field, "conflicts"); ok {
+			for _, path := range strings.Split(tag, "|") {
+				if other, found := fields[path]; found && other.isSet() {
+					errs = append(errs, constraintError{field: field, constraint: "conflicts", other: other, otherPath: path})
+				}
+			}
+		}
+	}
+	return errors.Combine(errs...)
+}
+
+// collectConstraintFields records all the struct fields and map entries in v
+// by path, fields with constraint tags are also added to tagged.
+func collectConstraintFields(v reflect.Value, path []string, fields map[string]constraintField, tagged *[]constraintField) {
+	v = uninterface(indirect(v))
+	if !v.IsValid() || toOption(v) != nil {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		byYAML := populateYAMLMaps(v)
+		names := make([]string, 0, len(byYAML))
+		for name := range byYAML {
+			names = append(names, name)
+		}
+		// sorted so errors are reported in a consistent order
+		sort.Strings(names)
+		for _, name := range names {
+			field := byYAML[name]
+			if field.StructField.PkgPath != "" || field.StructField.Anonymous {
+				continue
+			}
+			fieldPath := append(append([]string{}, path...), name)
+			cf := constraintField{
+				path:  strings.Join(fieldPath, "."),
+				field: field.StructField,
+				value: field.Value,
+			}
+			fields[cf.path] = cf
+			if hasConstraintTag(cf.field) {
+				*tagged = append(*tagged, cf)
+			}
+			collectConstraintFields(field.Value, fieldPath, fields, tagged)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			entryPath := append(append([]string{}, path...), fmt.Sprint(key.Interface()))
+			cf := constraintField{
+				path:  strings.Join(entryPath, "."),
+				value: v.MapIndex(key),
+			}
+			fields[cf.path] = cf
+			collectConstraintFields(cf.value, entryPath, fields, tagged)
+		}
+	}
+}
+
+// hasConstraintTag returns true if the field has any tag checked by
+// CheckConstraints.
+func hasConstraintTag(sf reflect.StructField) bool {
+	if _, ok := figtreeTagValue(sf, "requires"); ok {
+		return true
+	}
+	if _, ok := figtreeTagValue(sf, "conflicts"); ok {
+		return true
+	}
+	if _, ok := sf.Tag.Lookup("validate"); ok {
+		return true
+	}
+	return figtreeTagFlag(sf, "required")
+}
+
+// figtreeTagFlag returns true if the figtree tag has the option flag, like
+// `figtree:",required"`.
+func figtreeTagFlag(sf reflect.StructField, flag string) bool {
+	if tag, ok := sf.Tag.Lookup("figtree"); ok {
+		parts := strings.Split(tag, ",")
+		for _, part := range parts[1:] {
+			if part == flag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateField returns an error for each of the comma separated validation
+// rules that the field value does not satisfy.
+func validateField(field constraintField, rules string) []error {
+	value := uninterface(indirect(field.value))
+	if option := toOption(value); option != nil {
+		value = reflect.ValueOf(option.GetValue())
+	}
+	if !value.IsValid() {
+		return nil
+	}
+	var errs []error
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "":
+			continue
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				errs = append(errs, validationError{field, fmt.Sprintf("has invalid validation rule %q", rule)})
+				continue
+			}
+			n, what, ok := validationSize(value)
+			if !ok {
+				errs = append(errs, validationError{field, fmt.Sprintf("cannot be validated with %q", rule)})
+				continue
+			}
+			if name == "min" && n < limit {
+				errs = append(errs, validationError{field, fmt.Sprintf("%s must be at least %s", what, arg)})
+			} else if name == "max" && n > limit {
+				errs = append(errs, validationError{field, fmt.Sprintf("%s must be at most %s", what, arg)})
+			}
+		case "oneof":
+			// choices are separated with spaces in go-playground/validator
+			// tags, which are also accepted
+			choices := strings.Fields(arg)
+			if strings.Contains(arg, "|") {
+				choices = strings.Split(arg, "|")
+			}
+			actual := formatScalar(value.Interface())
+			found := false
+			for _, choice := range choices {
+				if choice == actual {
+					found = true
+					break
+				}
+			}
+			if !found {
+				errs = append(errs, validationError{field, fmt.Sprintf("value %q must be one of %s", actual, strings.Join(choices, ", "))})
+			}
+		}
+	}
+	return errs
+}
+
+// validationSize returns the number compared by the min and max rules, the
+// value for numbers or the length for strings, lists and maps, along with a
+// description of it.
+func validationSize(v reflect.Value) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "value", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), "value", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "value", true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "length", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), "length", true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,110 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraints(t *testing.T) {
+	type config struct {
+		TLS struct {
+			Enabled BoolOption   `yaml:"enabled" figtree:",requires=tls.cert|tls.key"`
+			Cert    StringOption `yaml:"cert"`
+			Key     StringOption `yaml:"key"`
+		} `yaml:"tls"`
+		Insecure BoolOption `yaml:"insecure" figtree:",conflicts=tls.enabled"`
+		Port     int        `yaml:"port" figtree:",requires=host"`
+		Host     string     `yaml:"host"`
+	}
+
+	load := func(t *testing.T, content string, opts *config) error {
+		src, err := SourceFromString("config.yml", content)
+		require.NoError(t, err)
+		return newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, opts)
+	}
+
+	opts := config{}
+	err := load(t, "tls: {enabled: true, cert: a.pem, key: a.key}\nport: 80\nhost: localhost\n", &opts)
+	require.NoError(t, err)
+
+	// defaults satisfy requirements but do not trigger constraints
+	opts = config{}
+	opts.TLS.Key = NewStringOption("default.key")
+	opts.Insecure = NewBoolOption(true)
+	err = load(t, "tls: {enabled: true, cert: a.pem}\n", &opts)
+	require.NoError(t, err)
+
+	opts = config{}
+	err = load(t, "tls:\n  enabled: true\n  cert: a.pem\ninsecure: true\nport: 80\n", &opts)
+	require.Error(t, err)
+	assert.Equal(t, "insecure (config.yml:4:11) conflicts with tls.enabled (config.yml:2:12); "+
+		"port requires host to be set; "+
+		"tls.enabled (config.yml:2:12) requires tls.key to be set", err.Error())
+
+	// constraints can be checked again after options are modified
+	opts.Insecure = BoolOption{}
+	opts.Port = 0
+	opts.TLS.Key = NewStringOption("a.key")
+	require.NoError(t, CheckConstraints(&opts))
+	require.NoError(t, opts.Insecure.Set("true"))
+	err = CheckConstraints(&opts)
+	require.Error(t, err)
+	assert.Equal(t, "insecure (override) conflicts with tls.enabled (config.yml:2:12)", err.Error())
+}
+
+func TestValidation(t *testing.T) {
+	type config struct {
+		Name     StringOption     `yaml:"name" figtree:",required" validate:"min=3"`
+		Replicas IntOption        `yaml:"replicas" validate:"min=1,max=10"`
+		Level    StringOption     `yaml:"level" validate:"oneof=debug|info|warn"`
+		Hosts    ListStringOption `yaml:"hosts" validate:"max=2"`
+		Region   string           `yaml:"region" figtree:",required"`
+		Ratio    Float64Option    `yaml:"ratio" validate:"max=1"`
+	}
+
+	load := func(t *testing.T, content string, opts *config) error {
+		src, err := SourceFromString("config.yml", content)
+		require.NoError(t, err)
+		return newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, opts)
+	}
+
+	opts := config{}
+	err := load(t, "name: web\nreplicas: 3\nlevel: info\nhosts: [a, b]\nregion: us\n", &opts)
+	require.NoError(t, err)
+
+	// defaults satisfy required fields and are validated
+	opts = config{Name: NewStringOption("default"), Replicas: NewIntOption(0)}
+	err = load(t, "region: us\n", &opts)
+	require.Error(t, err)
+	assert.Equal(t, "replicas (default) value must be at least 1", err.Error())
+
+	opts = config{}
+	err = load(t, "name: ab\nreplicas: 11\nlevel: trace\nhosts: [a, b, c]\nratio: 1.5\n", &opts)
+	require.Error(t, err)
+	assert.Equal(t, "hosts length must be at most 2; "+
+		"level (config.yml:3:8) value \"trace\" must be one of debug, info, warn; "+
+		"name (config.yml:1:7) length must be at least 3; "+
+		"ratio (config.yml:5:8) value must be at most 1; "+
+		"region is required; "+
+		"replicas (config.yml:2:11) value must be at most 10", err.Error())
+}
+
+func TestValidationOtherRules(t *testing.T) {
+	// rules for other validation libraries are ignored
+	type config struct {
+		Email string       `yaml:"email" validate:"required,email"`
+		Color StringOption `yaml:"color" validate:"required,oneof=red green"`
+	}
+	src, err := SourceFromString("config.yml", "email: user@example.com\ncolor: green\n")
+	require.NoError(t, err)
+	opts := config{}
+	require.NoError(t, newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, &opts))
+	assert.Equal(t, "green", opts.Color.Value)
+
+	require.NoError(t, opts.Color.Set("blue"))
+	err = CheckConstraints(&opts)
+	require.Error(t, err)
+	assert.Equal(t, `color (override) value "blue" must be one of red, green`, err.Error())
+}
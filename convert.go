@@ -2,7 +2,15 @@ package figtree
 
 import (
 	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"gopkg.in/yaml.v3"
 )
 
 // dst must be a pointer type
@@ -90,6 +98,30 @@ func convertString(src string, dst interface{}) (err error) {
 		var tmp float64
 		tmp, err = strconv.ParseFloat(src, 64)
 		*v = tmp
+	case *uintptr:
+		var tmp uint64
+		tmp, err = strconv.ParseUint(src, 10, 64)
+		*v = uintptr(tmp)
+	case *complex64:
+		var tmp complex128
+		tmp, err = strconv.ParseComplex(src, 64)
+		*v = complex64(tmp)
+	case *complex128:
+		var tmp complex128
+		tmp, err = strconv.ParseComplex(src, 128)
+		*v = tmp
+	case *error:
+		*v = errors.NewPlain(src)
+	case **time.Location:
+		*v, err = time.LoadLocation(src)
+	case *time.Duration:
+		*v, err = ParseDuration(src)
+	case *time.Time:
+		*v, err = ParseTime(src)
+	case **url.URL:
+		*v, err = ParseURL(src)
+	case **regexp.Regexp:
+		*v, err = ParseRegexp(src)
 	case *any:
 		*v = src
 	case setter:
@@ -105,3 +137,137 @@ func convertString(src string, dst interface{}) (err error) {
 
 	return nil
 }
+
+// needsStringConversion returns true for types that the yaml and json
+// libraries are unable to decode or encode directly, these types are
+// converted to and from strings instead.
+func needsStringConversion(dst any) bool {
+	switch dst.(type) {
+	case *complex64, *complex128, *error, **time.Location, *Locale, *time.Duration, *time.Time, **url.URL, **regexp.Regexp:
+		return true
+	}
+	return false
+}
+
+// decodeYAML will decode node into dst, which must be a pointer, using
+// convertString for scalar values that yaml cannot decode directly.
+func decodeYAML(node *yaml.Node, dst any) error {
+	if needsStringConversion(dst) && node.Kind == yaml.ScalarNode {
+		return convertString(node.Value, dst)
+	}
+	return node.Decode(dst)
+}
+
+// stringValue returns the string form of v for the types where
+// needsStringConversion is true.
+func stringValue(v any) (string, bool) {
+	switch t := v.(type) {
+	case complex64:
+		return strconv.FormatComplex(complex128(t), 'g', -1, 64), true
+	case complex128:
+		return strconv.FormatComplex(t, 'g', -1, 128), true
+	case error:
+		return t.Error(), true
+	case *time.Location:
+		if t == nil {
+			return "", false
+		}
+		return t.String(), true
+	case time.Duration:
+		return t.String(), true
+	case time.Time:
+		return t.Format(time.RFC3339Nano), true
+	case *url.URL:
+		if t == nil {
+			return "", false
+		}
+		return t.String(), true
+	case *regexp.Regexp:
+		if t == nil {
+			return "", false
+		}
+		return t.String(), true
+	}
+	return "", false
+}
+
+// formatScalar returns the string form of v.  Floats are formatted with
+// strconv using the shortest representation that round trips at their own
+// precision, so float32(3.33) is "3.33", independent of any locale.
+func formatScalar(v any) string {
+	switch t := v.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(t), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// parseFloat parses a float from s for a float of bitSize.  When
+// decimalComma is true a single comma is also accepted as the decimal
+// separator, like "1,5".
+func parseFloat(s string, bitSize int, decimalComma bool) (float64, error) {
+	f, err := strconv.ParseFloat(s, bitSize)
+	if err != nil && decimalComma && strings.Count(s, ",") == 1 && !strings.Contains(s, ".") {
+		if f2, err2 := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), bitSize); err2 == nil {
+			return f2, nil
+		}
+	}
+	return f, err
+}
+
+// parseNumber parses s as a decimal number of type typ, it returns false if
+// typ is not an integer or float type.
+func parseNumber(s string, typ reflect.Type, decimalComma bool) (reflect.Value, bool, error) {
+	v := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return v, true, err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return v, true, err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := parseFloat(s, typ.Bits(), decimalComma)
+		if err != nil {
+			return v, true, err
+		}
+		v.SetFloat(f)
+	default:
+		return v, false, nil
+	}
+	return v, true, nil
+}
+
+// untypedScalars returns a copy of node where the scalars without an
+// explicit tag are strings, see WithoutImplicitTyping.  Null scalars are
+// unchanged.  copies tracks the nodes already copied so aliases refer to the
+// copied anchors.
+func untypedScalars(node *yaml.Node, copies map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if cp, ok := copies[node]; ok {
+		return cp
+	}
+	cp := *node
+	copies[node] = &cp
+	if cp.Kind == yaml.ScalarNode && cp.Style&yaml.TaggedStyle == 0 && cp.ShortTag() != "!!null" {
+		cp.Tag = "!!str"
+	}
+	cp.Alias = untypedScalars(node.Alias, copies)
+	if node.Content != nil {
+		cp.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			cp.Content[i] = untypedScalars(child, copies)
+		}
+	}
+	return &cp
+}
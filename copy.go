@@ -0,0 +1,91 @@
+package figtree
+
+import (
+	"reflect"
+)
+
+// DeepCopy returns a copy of src where all maps, slices and pointers have been
+// duplicated, so that modifications to the copy are never visible in src.
+// Options are copied along with their Source and Defined properties.
+// Unexported struct fields are not deep copied.
+func DeepCopy[T any](src T) T {
+	var dst T
+	reflect.ValueOf(&dst).Elem().Set(deepCopyValue(reflect.ValueOf(&src).Elem()))
+	return dst
+}
+
+// deepCopier is used to recursively copy values so that no maps, slices or
+// pointers are shared between the source and the copy.  Pointers are tracked
+// so that shared or cyclic references are copied only once.
+type deepCopier struct {
+	seen map[reflect.Value]reflect.Value
+}
+
+func deepCopyValue(src reflect.Value) reflect.Value {
+	c := deepCopier{seen: map[reflect.Value]reflect.Value{}}
+	return c.copy(src)
+}
+
+func (c *deepCopier) copy(src reflect.Value) reflect.Value {
+	if !src.IsValid() {
+		return src
+	}
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return src
+		}
+		if dst, ok := c.seen[src]; ok {
+			return dst
+		}
+		dst := reflect.New(src.Type().Elem())
+		c.seen[src] = dst
+		dst.Elem().Set(c.copy(src.Elem()))
+		return dst
+	case reflect.Interface:
+		if src.IsNil() {
+			return src
+		}
+		dst := reflect.New(src.Type()).Elem()
+		dst.Set(c.copy(src.Elem()))
+		return dst
+	case reflect.Map:
+		if src.IsNil() {
+			return src
+		}
+		dst := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(iter.Key(), c.copy(iter.Value()))
+		}
+		return dst
+	case reflect.Slice:
+		if src.IsNil() {
+			return src
+		}
+		dst := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			dst.Index(i).Set(c.copy(src.Index(i)))
+		}
+		return dst
+	case reflect.Array:
+		dst := reflect.New(src.Type()).Elem()
+		for i := 0; i < src.Len(); i++ {
+			dst.Index(i).Set(c.copy(src.Index(i)))
+		}
+		return dst
+	case reflect.Struct:
+		dst := reflect.New(src.Type()).Elem()
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).PkgPath != "" {
+				// unexported fields cannot be set, they are left as
+				// a shallow copy
+				continue
+			}
+			dst.Field(i).Set(c.copy(src.Field(i)))
+		}
+		return dst
+	}
+	return src
+}
@@ -0,0 +1,141 @@
+package figtree
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDeepCopyValues(t *testing.T) {
+	type nested struct {
+		Map  map[string]string
+		List []string
+	}
+	src := map[string]any{
+		"list": []any{
+			map[string]any{"a": 1},
+		},
+		"struct": nested{
+			Map:  map[string]string{"b": "2"},
+			List: []string{"c"},
+		},
+	}
+	dst := map[string]any{}
+	err := Merge(&dst, src, DeepCopyValues())
+	require.NoError(t, err)
+	assert.Equal(t, src, dst)
+
+	// mutating the source must not be visible in the merged result
+	src["list"].([]any)[0].(map[string]any)["a"] = 100
+	src["struct"].(nested).Map["b"] = "200"
+	src["struct"].(nested).List[0] = "300"
+
+	expected := map[string]any{
+		"list": []any{
+			map[string]any{"a": 1},
+		},
+		"struct": nested{
+			Map:  map[string]string{"b": "2"},
+			List: []string{"c"},
+		},
+	}
+	assert.Equal(t, expected, dst)
+}
+
+func TestLoadConfigDeepCopyValues(t *testing.T) {
+	type options struct {
+		Data map[string]any `yaml:"data"`
+	}
+	shared := map[string]any{
+		"nested": map[string]any{"key": "value"},
+	}
+	fig := newFigTreeFromEnv(WithDeepCopyValues(), WithOverrides(map[string]any{"data": shared}))
+
+	opts1 := options{}
+	require.NoError(t, fig.LoadAllConfigSources(nil, &opts1))
+	opts2 := options{}
+	require.NoError(t, fig.LoadAllConfigSources(nil, &opts2))
+
+	opts1.Data["nested"].(map[string]any)["key"] = "changed"
+	assert.Equal(t, "value", opts2.Data["nested"].(map[string]any)["key"])
+	assert.Equal(t, "value", shared["nested"].(map[string]any)["key"])
+}
+
+func TestDeepCopy(t *testing.T) {
+	type config struct {
+		Name    StringOption
+		Labels  MapStringOption
+		Hosts   ListStringOption
+		Extra   map[string]any
+		Pointer *IntOption
+	}
+	orig := config{
+		Name: NewStringOption("name"),
+		Labels: MapStringOption{
+			"key": StringOption{tSrc("figtree.yml", 1, 2), true, "value"},
+		},
+		Hosts: ListStringOption{}.Append("a", "b"),
+		Extra: map[string]any{
+			"list": []any{"x", map[string]any{"y": 1}},
+		},
+		Pointer: &IntOption{tSrc("figtree.yml", 3, 4), true, 42},
+	}
+	cp := DeepCopy(orig)
+	assert.Equal(t, orig, cp)
+
+	cp.Labels["key"] = NewStringOption("changed")
+	cp.Hosts[0].Value = "changed"
+	cp.Extra["list"].([]any)[1].(map[string]any)["y"] = 2
+	cp.Pointer.Value = 0
+
+	assert.Equal(t, StringOption{tSrc("figtree.yml", 1, 2), true, "value"}, orig.Labels["key"])
+	assert.Equal(t, "a", orig.Hosts[0].Value)
+	assert.Equal(t, 1, orig.Extra["list"].([]any)[1].(map[string]any)["y"])
+	assert.Equal(t, 42, orig.Pointer.Value)
+
+	var nilAny any
+	assert.Nil(t, DeepCopy(nilAny))
+}
+
+func TestFigTreeWith(t *testing.T) {
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next LoadFunc) LoadFunc {
+			return func(source ConfigSource, options any) error {
+				calls = append(calls, name)
+				return next(source, options)
+			}
+		}
+	}
+	dir := t.TempDir()
+	fig := newFigTreeFromEnv(
+		WithCwd(dir),
+		WithUsageTracking(),
+		WithMiddleware(mw("a")),
+		WithMiddleware(mw("b")),
+		WithMiddleware(mw("c")),
+	)
+
+	derived1 := fig.With(WithCwd("sub"), WithEnvPrefix("OTHER"), WithMiddleware(mw("d1")))
+	derived2 := fig.With(WithMiddleware(mw("d2")))
+
+	assert.Equal(t, dir, fig.workDir)
+	assert.Equal(t, "FIGTREE", fig.envPrefix)
+	assert.Equal(t, filepath.Join(dir, "sub"), derived1.workDir)
+	assert.Equal(t, "OTHER", derived1.envPrefix)
+	assert.Equal(t, dir, derived2.workDir)
+	assert.NotSame(t, fig.usage, derived1.usage)
+
+	src, err := SourceFromString("config.yml", "str1: value\n")
+	require.NoError(t, err)
+	load := func(f *FigTree) []string {
+		calls = nil
+		require.NoError(t, f.LoadAllConfigSources([]ConfigSource{src}, &TestOptions{}))
+		return calls
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, load(fig))
+	assert.Equal(t, []string{"a", "b", "c", "d1"}, load(derived1))
+	assert.Equal(t, []string{"a", "b", "c", "d2"}, load(derived2))
+}
@@ -0,0 +1,66 @@
+package figtree
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigDecoder decodes the contents of a config file into a yaml.Node so
+// that config formats other than YAML can be merged like YAML documents.
+// Decoders should set the Line and Column of the nodes so Options have
+// useful source locations.  Empty data should leave node unchanged.  The
+// file name is only provided for error messages.
+type ConfigDecoder interface {
+	Decode(file string, data []byte, node *yaml.Node) error
+}
+
+// ConfigDecoderFunc adapts a function to a ConfigDecoder.
+type ConfigDecoderFunc func(file string, data []byte, node *yaml.Node) error
+
+func (fn ConfigDecoderFunc) Decode(file string, data []byte, node *yaml.Node) error {
+	return fn(file, data, node)
+}
+
+// builtinDecoders are the decoders used for files that do not have a
+// decoder registered with WithDecoder, files with other extensions are
+// decoded as YAML.
+var builtinDecoders = map[string]ConfigDecoder{
+	"json": ConfigDecoderFunc(decodeJSONNode),
+}
+
+// WithDecoder registers the decoder used to read config files with the
+// extension ext, like "toml".  The extension is also added to the extensions
+// probed by LoadAllConfigs (see WithExtensions) if it is not already present.
+func WithDecoder(ext string, decoder ConfigDecoder) CreateOption {
+	return func(f *FigTree) {
+		ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+		decoders := map[string]ConfigDecoder{}
+		for k, v := range f.decoders {
+			decoders[k] = v
+		}
+		decoders[ext] = decoder
+		f.decoders = decoders
+		for _, e := range f.extensions {
+			if strings.EqualFold(e, ext) {
+				return
+			}
+		}
+		f.extensions = append(append([]string{}, f.extensions...), ext)
+	}
+}
+
+func (f *FigTree) WithDecoder(ext string, decoder ConfigDecoder) {
+	WithDecoder(ext, decoder)(f)
+}
+
+// decoder returns the ConfigDecoder for file, or nil if the file should be
+// decoded as YAML.
+func (f *FigTree) decoder(file string) ConfigDecoder {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file), "."))
+	if decoder, ok := f.decoders[ext]; ok {
+		return decoder
+	}
+	return builtinDecoders[ext]
+}
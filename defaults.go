@@ -0,0 +1,223 @@
+package figtree
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/coryb/walky"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	defaultsProfilesMu sync.RWMutex
+	defaultsProfiles   = map[string]any{}
+)
+
+// RegisterDefaults registers a named set of default option values that can
+// be merged into options via WithDefaultsProfile.  This allows packages to
+// contribute shared baseline values, typically from an init function:
+//
+//	func init() {
+//		figtree.RegisterDefaults("company", &Options{
+//			Region: figtree.NewStringOption("us-west-2"),
+//		})
+//	}
+//
+// The defaults are usually a struct, or pointer to a struct, with the same
+// fields as the options being loaded, but a map with matching keys also
+// works.  RegisterDefaults panics if defaults is nil or if a profile is
+// registered twice with the same name.
+func RegisterDefaults(name string, defaults any) {
+	defaultsProfilesMu.Lock()
+	defer defaultsProfilesMu.Unlock()
+	if defaults == nil {
+		panic("figtree: RegisterDefaults defaults is nil")
+	}
+	if _, ok := defaultsProfiles[name]; ok {
+		panic(fmt.Sprintf("figtree: RegisterDefaults called twice for profile %q", name))
+	}
+	defaultsProfiles[name] = defaults
+}
+
+// lookupDefaults returns a copy of the defaults registered for the profile
+// name, so that merging never shares maps or slices with the registry.  All
+// the defined options in the copy have their source set to the profile.
+func lookupDefaults(name string) (any, bool) {
+	defaultsProfilesMu.RLock()
+	defer defaultsProfilesMu.RUnlock()
+	defaults, ok := defaultsProfiles[name]
+	if !ok {
+		return nil, false
+	}
+	v := reflect.New(reflect.TypeOf(defaults)).Elem()
+	v.Set(reflect.ValueOf(DeepCopy(defaults)))
+	setDefaultSources(v, NewSource(defaultSourcePrefix+name))
+	return v.Interface(), true
+}
+
+// setDefaultSources will set source on all the defined options within v that
+// have no source, the generic default source or were decoded from yaml.  v
+// must be settable.
+func setDefaultSources(v reflect.Value, source SourceLocation) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			setDefaultSources(v.Elem(), source)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		setDefaultSources(elem, source)
+		v.Set(elem)
+	case reflect.Struct:
+		if option, ok := v.Addr().Interface().(option); ok {
+			name := option.GetSource().Name
+			if option.IsDefined() && (name == "" || name == defaultSource || name == yamlSource) {
+				option.SetSource(source)
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				setDefaultSources(v.Field(i), source)
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			setDefaultSources(elem, source)
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			setDefaultSources(v.Index(i), source)
+		}
+	}
+}
+
+// WithDefaultsProfile will merge the defaults registered with RegisterDefaults
+// for each of the named profiles after all other sources, so the profile
+// values have the lowest precedence.  When multiple profiles are used the
+// earlier profiles take precedence over the later ones.  Options set from a
+// profile have a source of `default:<name>` and are considered defaults, see
+// Option.IsDefault.
+func WithDefaultsProfile(names ...string) CreateOption {
+	return func(f *FigTree) {
+		f.defaultsProfiles = append(f.defaultsProfiles, names...)
+	}
+}
+
+func (f *FigTree) WithDefaultsProfile(names ...string) {
+	WithDefaultsProfile(names...)(f)
+}
+
+// loadDefaultsProfiles will merge the registered defaults for each of the
+// defaults profiles into options.
+func (f *FigTree) loadDefaultsProfiles(m *Merger, options interface{}) error {
+	if len(f.defaultsProfiles) == 0 {
+		return nil
+	}
+	for _, name := range f.defaultsProfiles {
+		defaults, ok := lookupDefaults(name)
+		if !ok {
+			return errors.Errorf("unknown defaults profile %q", name)
+		}
+		m.sourceFile = defaultSourcePrefix + name
+		m.includeChain = nil
+		_, err := m.mergeStructs(
+			reflect.ValueOf(options),
+			newMergeSource(reflect.ValueOf(defaults)),
+			false,
+		)
+		if err != nil {
+			return err
+		}
+		m.Advance()
+	}
+	changeSet := f.PopulateEnv(options)
+	return f.exportEnv(changeSet)
+}
+
+// tagDefault returns the default value for fields tagged with
+// `figtree:",default=<value>"`.  The default is everything after `default=`,
+// so it must be the last tag option, which allows defaults like
+// `default=[a, b]`.
+func tagDefault(sf reflect.StructField) (string, bool) {
+	tag, ok := sf.Tag.Lookup("figtree")
+	if !ok {
+		return "", false
+	}
+	idx := strings.Index(tag, ",default=")
+	if idx < 0 {
+		return "", false
+	}
+	return tag[idx+len(",default="):], true
+}
+
+// loadTagDefaults sets the default values from `figtree:",default=<value>"`
+// tags on the fields in options after all the sources have been merged, so
+// the tag defaults have the lowest precedence.  Fields that were set by any
+// source, even to a zero value, or that have a value in options before
+// loading keep their value.  The values are parsed as YAML, so lists and
+// maps can also have defaults, like `default=[a, b]`.  Options set from a
+// tag have the default source, the same as options created with NewOption.
+func (f *FigTree) loadTagDefaults(m *Merger, options interface{}) error {
+	applied, err := m.applyTagDefaults(reflect.ValueOf(options), nil)
+	if err != nil || !applied {
+		return err
+	}
+	changeSet := f.PopulateEnv(options)
+	return f.exportEnv(changeSet)
+}
+
+func (m *Merger) applyTagDefaults(v reflect.Value, path []string) (applied bool, err error) {
+	v = indirect(v)
+	if v.Kind() != reflect.Struct || isOptionType(v.Type()) {
+		return false, nil
+	}
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		fieldPath := path
+		if !sf.Anonymous {
+			fieldPath = append(path[:len(path):len(path)], yamlFieldName(sf))
+		}
+		field := v.Field(i)
+		dflt, ok := tagDefault(sf)
+		if !ok || !field.CanSet() || !isZero(field) || m.isPresent(fieldPath) {
+			// exported fields of embedded unexported structs can still be
+			// set, so recurse even when field itself cannot be set
+			ok, err := m.applyTagDefaults(field, fieldPath)
+			if err != nil {
+				return false, err
+			}
+			applied = applied || ok
+			continue
+		}
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+		if dflt != "" {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(dflt), &doc); err != nil {
+				return false, errors.Wrapf(err, "invalid default for %s", strings.Join(fieldPath, "."))
+			}
+			node = walky.UnwrapDocument(&doc)
+		}
+		value := reflect.New(field.Type())
+		if err := decodeYAML(node, value.Interface()); err != nil {
+			return false, errors.Wrapf(err, "invalid default for %s", strings.Join(fieldPath, "."))
+		}
+		setDefaultSources(value.Elem(), DefaultSource)
+		field.Set(value.Elem())
+		applied = true
+	}
+	return applied, nil
+}
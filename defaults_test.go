@@ -0,0 +1,131 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultsProfile(t *testing.T) {
+	RegisterDefaults("test-company", &TestOptions{
+		String1: StringOption{Value: "company", Defined: true},
+		Int1:    NewIntOption(42),
+		Map1: MapStringOption{
+			"key1": StringOption{Value: "company-key1", Defined: true},
+		},
+	})
+	RegisterDefaults("test-team", map[string]any{
+		"str1":  "team",
+		"bool1": true,
+	})
+	assert.Panics(t, func() {
+		RegisterDefaults("test-company", TestOptions{})
+	})
+	assert.Panics(t, func() {
+		RegisterDefaults("test-nil", nil)
+	})
+
+	config, err := SourceFromString("figtree.yml", "int1: 1\nmap1:\n  key2: file-key2\n")
+	require.NoError(t, err)
+
+	opts := TestOptions{
+		Float1: NewFloat32Option(1.5),
+	}
+	fig := newFigTreeFromEnv(WithDefaultsProfile("test-team", "test-company"))
+	err = fig.LoadAllConfigSources([]ConfigSource{config}, &opts)
+	require.NoError(t, err)
+
+	expected := TestOptions{
+		String1: StringOption{NewSource("default:test-team"), true, "team"},
+		Map1: MapStringOption{
+			"key1": StringOption{NewSource("default:test-company"), true, "company-key1"},
+			"key2": StringOption{tSrc("figtree.yml", 3, 9), true, "file-key2"},
+		},
+		Int1:   IntOption{tSrc("figtree.yml", 1, 7), true, 1},
+		Float1: NewFloat32Option(1.5),
+		Bool1:  BoolOption{NewSource("default:test-team"), true, true},
+	}
+	assert.Exactly(t, expected, opts)
+	assert.True(t, opts.String1.IsDefault())
+
+	// the registered defaults must not be modified by loading
+	opts.Map1["key1"] = StringOption{Value: "changed"}
+	opts = TestOptions{}
+	err = fig.LoadAllConfigSources(nil, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "company-key1", opts.Map1["key1"].Value)
+	assert.Equal(t, IntOption{NewSource("default:test-company"), true, 42}, opts.Int1)
+
+	fig = newFigTreeFromEnv(WithDefaultsProfile("test-missing"))
+	err = fig.LoadAllConfigSources(nil, &opts)
+	assert.EqualError(t, err, `unknown defaults profile "test-missing"`)
+}
+
+func TestTagDefaults(t *testing.T) {
+	type server struct {
+		Port    IntOption        `yaml:"port" figtree:",default=8080"`
+		Host    string           `yaml:"host" figtree:",default=localhost"`
+		Tags    ListStringOption `yaml:"tags" figtree:",default=[a, b]"`
+		Verbose BoolOption       `yaml:"verbose"`
+	}
+	type config struct {
+		Server  server        `yaml:"server"`
+		Name    StringOption  `yaml:"name" figtree:",default="`
+		Timeout Float64Option `yaml:"timeout" figtree:",default=1.5"`
+		Retries int           `yaml:"retries" figtree:",default=3"`
+	}
+
+	src, err := SourceFromString("config.yml", "server:\n  port: 9090\nretries: 0\n")
+	require.NoError(t, err)
+	opts := config{Timeout: NewFloat64Option(2.5)}
+	fig := newFigTreeFromEnv()
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &opts))
+
+	assert.Equal(t, config{
+		Server: server{
+			Port: IntOption{tSrc("config.yml", 2, 9), true, 9090},
+			Host: "localhost",
+			Tags: ListStringOption{NewStringOption("a"), NewStringOption("b")},
+		},
+		Name:    NewStringOption(""),
+		Timeout: NewFloat64Option(2.5),
+		// explicit zero values from a source are kept
+		Retries: 0,
+	}, opts)
+	assert.True(t, opts.Server.Tags[0].IsDefault())
+
+	opts = config{}
+	require.NoError(t, fig.LoadAllConfigSources(nil, &opts))
+	assert.Equal(t, 3, opts.Retries)
+	assert.Equal(t, IntOption{DefaultSource, true, 8080}, opts.Server.Port)
+
+	type invalid struct {
+		Port IntOption `yaml:"port" figtree:",default=http"`
+	}
+	err = fig.LoadAllConfigSources(nil, &invalid{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid default for port")
+}
+
+type tagDefaultsEmbedded struct {
+	Name StringOption `yaml:"name" figtree:",default=embedded"`
+	Size int          `yaml:"size" figtree:",default=10"`
+}
+
+func TestTagDefaultsEmbeddedUnexported(t *testing.T) {
+	type config struct {
+		tagDefaultsEmbedded `yaml:",inline"`
+		internal            tagDefaultsEmbedded
+		Port                IntOption `yaml:"port" figtree:",default=8080"`
+	}
+	src, err := SourceFromString("config.yml", "size: 5\n")
+	require.NoError(t, err)
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &opts))
+	assert.Equal(t, NewStringOption("embedded"), opts.Name)
+	assert.Equal(t, 5, opts.Size)
+	assert.Equal(t, NewIntOption(8080), opts.Port)
+	assert.Equal(t, tagDefaultsEmbedded{}, opts.internal)
+}
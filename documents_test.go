@@ -0,0 +1,53 @@
+package figtree
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllConfigsMultiDocument(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml":     "str1: root\nbool1: true\n",
+		"app/figtree.yml": "str1: override\n---\nstr1: base\nint1: 1\nconfig:\n  include: shared.yml\n",
+		"app/shared.yml":  "int1: 2\n---\nfloat1: 1.5\n",
+	})
+
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "app")))
+	sources, err := fig.ReadFileDocuments(context.Background(), filepath.Join(dir, "app", "figtree.yml"))
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	assert.Equal(t, sources[0].Filename, sources[1].Filename)
+
+	opts := TestOptions{}
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	includedSrc := func(l, c int) SourceLocation {
+		return NewSource("shared.yml", WithLocation(&FileCoordinate{Line: l, Column: c}), WithIncludeChain([]string{"figtree.yml"}))
+	}
+	expected := TestOptions{
+		String1: StringOption{tSrc("figtree.yml", 1, 7), true, "override"},
+		Int1:    IntOption{tSrc("figtree.yml", 4, 7), true, 1},
+		Float1:  Float32Option{includedSrc(3, 9), true, 1.5},
+		Bool1:   BoolOption{tSrc("../figtree.yml", 2, 8), true, true},
+	}
+	assert.Exactly(t, expected, opts)
+
+	// LoadConfig also merges every document
+	opts = TestOptions{}
+	err = fig.LoadConfig("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "override", opts.String1.Value)
+	assert.Equal(t, 1, opts.Int1.Value)
+
+	// ReadFile only returns the first document
+	cs, err := fig.ReadFile("figtree.yml")
+	require.NoError(t, err)
+	opts = TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{*cs}, &opts))
+	assert.Equal(t, "override", opts.String1.Value)
+	assert.False(t, opts.Int1.Defined)
+}
@@ -0,0 +1,39 @@
+package figtree
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// DurationOption is an option for a time.Duration, in config files, env
+// vars and flags the duration is either a time.ParseDuration string, like
+// "30s" or "1h30m", or an integer number of seconds.
+type DurationOption = Option[time.Duration]
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ParseDuration returns the duration for s, which is either a
+// time.ParseDuration string or an integer number of seconds.
+func ParseDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	secs, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid duration %q, expected a duration like \"30s\" or integer seconds", s)
+	}
+	if secs > int64(time.Duration(1<<63-1)/time.Second) || secs < int64(-1<<63/time.Second) {
+		return 0, errors.Errorf("invalid duration %q, seconds out of range", s)
+	}
+	return time.Duration(secs) * time.Second, nil
+}
+
+// isDurationConversion returns true when src is assigned to a
+// time.Duration from another type, these are converted from their string
+// form so integers are seconds rather than nanoseconds.
+func isDurationConversion(src reflect.Value, dstType reflect.Type) bool {
+	return dstType == durationType && src.Type() != durationType
+}
@@ -0,0 +1,99 @@
+package figtree
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseDuration(t *testing.T) {
+	for input, expected := range map[string]time.Duration{
+		"30s":    30 * time.Second,
+		"5m":     5 * time.Minute,
+		"1h30m":  90 * time.Minute,
+		"250ms":  250 * time.Millisecond,
+		"45":     45 * time.Second,
+		"-10":    -10 * time.Second,
+		"0":      0,
+		"1.5h":   90 * time.Minute,
+		"100000": 100000 * time.Second,
+	} {
+		got, err := ParseDuration(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+	for _, input := range []string{"", "abc", "1.5", "30 s", "99999999999999999"} {
+		_, err := ParseDuration(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestLoadDuration(t *testing.T) {
+	type config struct {
+		Timeout  DurationOption   `yaml:"timeout"`
+		Interval DurationOption   `yaml:"interval"`
+		Retry    time.Duration    `yaml:"retry"`
+		Backoff  []DurationOption `yaml:"backoff"`
+	}
+
+	src, err := SourceFromString("config.yml", "timeout: 30s\ninterval: 45\nretry: 5m\nbackoff: [1s, 2]\n")
+	require.NoError(t, err)
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, config{
+		Timeout:  DurationOption{tSrc("config.yml", 1, 10), true, 30 * time.Second},
+		Interval: DurationOption{tSrc("config.yml", 2, 11), true, 45 * time.Second},
+		Retry:    5 * time.Minute,
+		Backoff: []DurationOption{
+			{tSrc("config.yml", 4, 11), true, time.Second},
+			{tSrc("config.yml", 4, 15), true, 2 * time.Second},
+		},
+	}, opts)
+
+	// durations are merged as is, not converted from seconds
+	dst := config{}
+	require.NoError(t, Merge(&dst, config{Retry: time.Minute}))
+	assert.Equal(t, time.Minute, dst.Retry)
+	require.NoError(t, Merge(&dst, map[string]any{"timeout": 10}))
+	assert.Equal(t, 10*time.Second, dst.Timeout.Value)
+
+	StringifyValue = true
+	defer func() {
+		StringifyValue = false
+	}()
+	got, err := yaml.Marshal(opts.Timeout)
+	require.NoError(t, err)
+	assert.Equal(t, "30s\n", string(got))
+	data, err := json.Marshal(opts.Interval)
+	require.NoError(t, err)
+	assert.Equal(t, `"45s"`, string(data))
+
+	var fromJSON DurationOption
+	require.NoError(t, json.Unmarshal([]byte(`"1m"`), &fromJSON))
+	assert.Equal(t, time.Minute, fromJSON.Value)
+	require.NoError(t, json.Unmarshal([]byte(`15`), &fromJSON))
+	assert.Equal(t, 15*time.Second, fromJSON.Value)
+
+	// invalid values are reported with their location
+	src, err = SourceFromString("config.yml", "timeout: soon\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:10")
+	assert.Contains(t, err.Error(), `invalid duration "soon"`)
+
+	// and from the command line
+	opts = config{}
+	require.NoError(t, opts.Timeout.Set("2m"))
+	assert.Equal(t, 2*time.Minute, opts.Timeout.Value)
+	require.NoError(t, opts.Interval.Set("90"))
+	assert.Equal(t, 90*time.Second, opts.Interval.Value)
+	require.Error(t, opts.Timeout.Set("later"))
+}
@@ -1,7 +1,9 @@
 package figtree
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
@@ -133,6 +135,7 @@ func TestTag(t *testing.T) {
 		OverrideEnv string `yaml:"override-env" figtree:"OVERRIDE_ENV"`
 		NoEnv       string `yaml:"no-env" figtree:"-"`
 		MultiEnv    string `yaml:"multi-env" figtree:"MULTIA;MULTIB"`
+		SourcesEnv  string `yaml:"sources-env" figtree:",sources=file"`
 	}{}
 
 	var node yaml.Node
@@ -141,6 +144,7 @@ default-env: abc
 override-env: def
 no-env: ghi
 multi-env: jkl
+sources-env: mno
 `), &node)
 	assert.NoError(t, err)
 
@@ -156,7 +160,315 @@ multi-env: jkl
 		"FIGTREE_MULTIA=jkl",
 		"FIGTREE_MULTIB=jkl",
 		"FIGTREE_OVERRIDE_ENV=def",
+		"FIGTREE_SOURCES_ENV=mno",
 	}
 
 	assert.Equal(t, expected, got)
 }
+
+func TestWithEnviron(t *testing.T) {
+	t.Parallel()
+	dir := writeConfigFiles(t, map[string]string{
+		"home/figtree.yml": "str1: home\n",
+		"work/figtree.yml": "int1: 1\n",
+	})
+	environ := map[string]string{
+		"HOME": filepath.Join(dir, "home"),
+	}
+	fig := NewFigTree(
+		WithEnviron(func(key string) string { return environ[key] }),
+		WithCwd(filepath.Join(dir, "work")),
+		WithApplyChangeSet(func(map[string]*string) error { return nil }),
+	)
+	opts := TestOptions{}
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{tSrc("../home/figtree.yml", 1, 7), true, "home"}, opts.String1)
+	assert.Equal(t, IntOption{tSrc("figtree.yml", 1, 7), true, 1}, opts.Int1)
+
+	// explicit home takes precedence over the environment
+	fig = NewFigTree(
+		WithEnviron(func(key string) string { return environ[key] }),
+		WithHome(filepath.Join(dir, "other")),
+		WithCwd(filepath.Join(dir, "work")),
+		WithApplyChangeSet(func(map[string]*string) error { return nil }),
+	)
+	opts = TestOptions{}
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.False(t, opts.String1.IsDefined())
+
+	// an empty home is not replaced by the environment
+	fig = NewFigTree(
+		WithEnviron(func(key string) string { return environ[key] }),
+		WithHome(""),
+		WithCwd(filepath.Join(dir, "work")),
+		WithApplyChangeSet(func(map[string]*string) error { return nil }),
+	)
+	opts = TestOptions{}
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.False(t, opts.String1.IsDefined())
+
+	// the method resolves home through the new environment
+	fig = NewFigTree(
+		WithCwd(filepath.Join(dir, "work")),
+		WithApplyChangeSet(func(map[string]*string) error { return nil }),
+	)
+	fig.WithEnviron(func(key string) string { return environ[key] })
+	opts = TestOptions{}
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{tSrc("../home/figtree.yml", 1, 7), true, "home"}, opts.String1)
+
+	// executable configs are run with the environment
+	script := "#!/bin/sh\necho str1: $HOME\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "work", "exec.yml"), []byte(script), 0o755))
+	opts = TestOptions{}
+	err = fig.LoadAllConfigs("exec.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "home"), opts.String1.Value)
+}
+
+func TestWithEnvKeyFilter(t *testing.T) {
+	t.Parallel()
+	source, err := SourceFromString("test", "str1: a\nint1: 1\nmap1: {key: b}\n")
+	require.NoError(t, err)
+	var changeSet map[string]*string
+	fig := newFigTreeFromEnv(
+		WithEnvKeyFilter(func(key string) bool {
+			return strings.HasPrefix(key, "str") || key == "int1"
+		}),
+		WithApplyChangeSet(func(cs map[string]*string) error {
+			changeSet = cs
+			return nil
+		}),
+	)
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{source}, &opts))
+	got := []string{}
+	for k := range changeSet {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	assert.Equal(t, []string{"FIGTREE_INT_1", "FIGTREE_STRING_1"}, got)
+
+	// maps are filtered by key
+	changeSet = fig.PopulateEnv(map[string]any{"str1": "a", "map1": "b"})
+	assert.Len(t, changeSet, 1)
+	assert.Contains(t, changeSet, "FIGTREE_STR_1")
+}
+
+func TestDeterministicMapOutput(t *testing.T) {
+	t.Parallel()
+	config := "map1:\n"
+	for i := 20; i > 0; i-- {
+		config += fmt.Sprintf("  key%02d: val%d\n", i, i)
+	}
+	source, err := SourceFromString("test", config)
+	require.NoError(t, err)
+
+	var env, yml, jsn string
+	for i := 0; i < 10; i++ {
+		var changeSet map[string]*string
+		fig := newFigTreeFromEnv(WithApplyChangeSet(func(cs map[string]*string) error {
+			changeSet = cs
+			return nil
+		}))
+		opts := TestOptions{}
+		require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{source}, &opts))
+		y, err := Marshal(&opts, WithDefinedOnly())
+		require.NoError(t, err)
+		j, err := MarshalJSON(&opts, WithSources())
+		require.NoError(t, err)
+		if i == 0 {
+			env, yml, jsn = *changeSet["FIGTREE_MAP_1"], string(y), string(j)
+			continue
+		}
+		// map keys are always sorted, so the output is identical
+		assert.Equal(t, env, *changeSet["FIGTREE_MAP_1"])
+		assert.Equal(t, yml, string(y))
+		assert.Equal(t, jsn, string(j))
+	}
+	assert.True(t, strings.HasPrefix(env, `{"key01":{"Value":"val1",`), env)
+	assert.True(t, strings.HasPrefix(yml, "map1:\n    key01: val1\n    key02: val2\n"), yml)
+}
+
+func TestWithEnvOverrides(t *testing.T) {
+	t.Parallel()
+	source, err := SourceFromString("test", "str1: file\nint1: 1\nbool1: true\n")
+	require.NoError(t, err)
+	environ := map[string]string{
+		"FIGTREE_STRING_1": "env",
+		"FIGTREE_INT_1":    "5",
+		"FIGTREE_FLOAT_1":  "1.5",
+		"FIGTREE_MAP_1":    `{"key": "value"}`,
+		"FIGTREE_ARRAY_1":  `["a", "b"]`,
+	}
+	fig := newFigTreeFromEnv(
+		WithEnviron(func(key string) string { return environ[key] }),
+		WithApplyChangeSet(func(map[string]*string) error { return nil }),
+		WithOverrides(map[string]any{"int1": 10}),
+		WithEnvOverrides(),
+	)
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{source}, &opts))
+
+	assert.Equal(t, StringOption{NewSource("env:FIGTREE_STRING_1"), true, "env"}, opts.String1)
+	assert.Equal(t, Float32Option{NewSource("env:FIGTREE_FLOAT_1"), true, 1.5}, opts.Float1)
+	assert.Equal(t, MapStringOption{"key": {NewSource("env:FIGTREE_MAP_1"), true, "value"}}, opts.Map1)
+	assert.Equal(t, ListStringOption{
+		{NewSource("env:FIGTREE_ARRAY_1"), true, "a"},
+		{NewSource("env:FIGTREE_ARRAY_1"), true, "b"},
+	}, opts.Array1)
+	// overrides take precedence over the environment
+	assert.Equal(t, IntOption{NewSource("override"), true, 10}, opts.Int1)
+	// options not in the environment are loaded from the config
+	assert.Equal(t, BoolOption{tSrc("test", 3, 8), true, true}, opts.Bool1)
+}
+
+func TestWithEnvOverridesElements(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		Ports  []IntOption        `yaml:"ports"`
+		Tags   ListStringOption   `yaml:"tags"`
+		Limits MapOption[float64] `yaml:"limits"`
+		Flags  map[string]bool    `yaml:"flags"`
+		Hosts  ListStringOption   `yaml:"hosts"`
+		Extra  MapOption[any]     `yaml:"extra"`
+	}
+	environ := map[string]string{
+		"FIGTREE_PORTS":  "80,443",
+		"FIGTREE_TAGS":   `a,b\,c`,
+		"FIGTREE_LIMITS": "cpu=1.5,mem=512",
+		"FIGTREE_FLAGS":  "debug=true,trace=false",
+		"FIGTREE_HOSTS":  `["x", "y"]`,
+		"FIGTREE_EXTRA":  "k=v",
+	}
+	fig := newFigTreeFromEnv(
+		WithEnviron(func(key string) string { return environ[key] }),
+		WithApplyChangeSet(func(map[string]*string) error { return nil }),
+		WithEnvOverrides(),
+	)
+	opts := config{}
+	require.NoError(t, fig.LoadAllConfigSources(nil, &opts))
+
+	assert.Equal(t, []IntOption{
+		{NewSource("env:FIGTREE_PORTS[0]"), true, 80},
+		{NewSource("env:FIGTREE_PORTS[1]"), true, 443},
+	}, opts.Ports)
+	assert.Equal(t, ListStringOption{
+		{NewSource("env:FIGTREE_TAGS[0]"), true, "a"},
+		{NewSource("env:FIGTREE_TAGS[1]"), true, "b,c"},
+	}, opts.Tags)
+	assert.Equal(t, MapOption[float64]{
+		"cpu": {NewSource("env:FIGTREE_LIMITS[cpu]"), true, 1.5},
+		"mem": {NewSource("env:FIGTREE_LIMITS[mem]"), true, 512},
+	}, opts.Limits)
+	assert.Equal(t, map[string]bool{"debug": true, "trace": false}, opts.Flags)
+	// YAML and JSON values are loaded as a single source
+	assert.Equal(t, ListStringOption{
+		{NewSource("env:FIGTREE_HOSTS"), true, "x"},
+		{NewSource("env:FIGTREE_HOSTS"), true, "y"},
+	}, opts.Hosts)
+	assert.Equal(t, MapOption[any]{"k": {NewSource("env:FIGTREE_EXTRA[k]"), true, "v"}}, opts.Extra)
+
+	environ["FIGTREE_PORTS"] = "80,http"
+	err := fig.LoadAllConfigSources(nil, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse environment variable FIGTREE_PORTS: invalid element 1")
+
+	environ["FIGTREE_PORTS"] = ""
+	environ["FIGTREE_LIMITS"] = "cpu"
+	err = fig.LoadAllConfigSources(nil, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `expected KEY=VALUE got "cpu"`)
+}
+
+func TestWithEnvOverridesPopulateEnv(t *testing.T) {
+	t.Parallel()
+	environ := map[string]string{}
+	getenv := func(key string) string { return environ[key] }
+	apply := func(changeSet map[string]*string) error {
+		for k, v := range changeSet {
+			if v != nil {
+				environ[k] = *v
+			} else {
+				delete(environ, k)
+			}
+		}
+		return nil
+	}
+	source, err := SourceFromString("test", "str1: file\nint1: 1\narr1: [a, b]\nmap1: {key: value}\n")
+	require.NoError(t, err)
+	parent := newFigTreeFromEnv(WithEnviron(getenv), WithApplyChangeSet(apply), WithEnvOverrides())
+	require.NoError(t, parent.LoadAllConfigSources([]ConfigSource{source}, &TestOptions{}))
+	assert.Contains(t, environ["FIGTREE_ARRAY_1"], `"Source"`)
+
+	// a new FigTree, like in a child process, loads the exported options
+	childEnviron := map[string]string{}
+	for k, v := range environ {
+		childEnviron[k] = v
+	}
+	child := newFigTreeFromEnv(
+		WithEnviron(func(key string) string { return childEnviron[key] }),
+		WithApplyChangeSet(func(map[string]*string) error { return nil }),
+		WithEnvOverrides(),
+	)
+	opts := TestOptions{}
+	require.NoError(t, child.LoadAllConfigSources(nil, &opts))
+	assert.Equal(t, StringOption{NewSource("env:FIGTREE_STRING_1"), true, "file"}, opts.String1)
+	assert.Equal(t, IntOption{NewSource("env:FIGTREE_INT_1"), true, 1}, opts.Int1)
+	assert.Equal(t, ListStringOption{
+		{NewSource("env:FIGTREE_ARRAY_1"), true, "a"},
+		{NewSource("env:FIGTREE_ARRAY_1"), true, "b"},
+	}, opts.Array1)
+	assert.Equal(t, MapStringOption{"key": {NewSource("env:FIGTREE_MAP_1"), true, "value"}}, opts.Map1)
+
+	// values exported by the parent in the earlier load do not override
+	// its config files
+	source, err = SourceFromString("test", "str1: updated\narr1: [c]\n")
+	require.NoError(t, err)
+	opts = TestOptions{}
+	require.NoError(t, parent.LoadAllConfigSources([]ConfigSource{source}, &opts))
+	assert.Equal(t, StringOption{tSrc("test", 1, 7), true, "updated"}, opts.String1)
+	assert.Equal(t, []string{"c"}, opts.Array1.Slice())
+	assert.False(t, opts.Int1.IsDefined())
+}
+
+func TestWithEnvOverridesYAMLBlocks(t *testing.T) {
+	t.Parallel()
+	environ := map[string]string{
+		"FIGTREE_ARRAY_1": "- a\n- b",
+		"FIGTREE_MAP_1":   "key1: x\nkey2: y",
+	}
+	fig := newFigTreeFromEnv(
+		WithEnviron(func(key string) string { return environ[key] }),
+		WithApplyChangeSet(func(map[string]*string) error { return nil }),
+		WithEnvOverrides(),
+	)
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSources(nil, &opts))
+	assert.Equal(t, ListStringOption{
+		{NewSource("env:FIGTREE_ARRAY_1"), true, "a"},
+		{NewSource("env:FIGTREE_ARRAY_1"), true, "b"},
+	}, opts.Array1)
+	assert.Equal(t, MapStringOption{
+		"key1": {NewSource("env:FIGTREE_MAP_1"), true, "x"},
+		"key2": {NewSource("env:FIGTREE_MAP_1"), true, "y"},
+	}, opts.Map1)
+
+	// comma separated values are still split
+	environ["FIGTREE_ARRAY_1"] = "a,b"
+	environ["FIGTREE_MAP_1"] = "key1=x,key2=y"
+	opts = TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSources(nil, &opts))
+	assert.Equal(t, ListStringOption{
+		{NewSource("env:FIGTREE_ARRAY_1[0]"), true, "a"},
+		{NewSource("env:FIGTREE_ARRAY_1[1]"), true, "b"},
+	}, opts.Array1)
+	assert.Equal(t, MapStringOption{
+		"key1": {NewSource("env:FIGTREE_MAP_1[key1]"), true, "x"},
+		"key2": {NewSource("env:FIGTREE_MAP_1[key2]"), true, "y"},
+	}, opts.Map1)
+}
@@ -1,8 +1,13 @@
 package figtree
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -207,3 +212,64 @@ func TestBuiltinExecConfigD1(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Exactly(t, expected, opts)
 }
+
+func TestExecAllowedDirs(t *testing.T) {
+	logger := &recordingLogger{}
+	fig := newFigTreeFromEnv(WithExecAllowedDirs("d1/d2"), WithLogger(logger))
+	opts := TestOptions{}
+	err := fig.LoadAllConfigsFrom("d1/d2/d3", "exec.yml", &opts)
+	require.NoError(t, err)
+
+	// only d1/d2/exec.yml was executed
+	assert.Equal(t, StringOption{tSrc("../exec.yml[stdout]", 1, 7), true, "d2str1val1"}, opts.String1)
+	assert.Equal(t, MapStringOption{
+		"key1": {tSrc("../exec.yml[stdout]", 6, 9), true, "d2map1val1"},
+		"key2": {tSrc("../exec.yml[stdout]", 7, 9), true, "d2map1val2"},
+	}, opts.Map1)
+	for _, dir := range []string{"d1", "d1/d2/d3"} {
+		assert.Contains(t, logger.messages, fmt.Sprintf(
+			"Skipping Executable Config file: %[1]s/exec.yml, exec is not allowed in %[1]s",
+			filepath.Join(fig.workDir, dir),
+		))
+	}
+}
+
+func TestLoadFirstConfigExec(t *testing.T) {
+	fig := newFigTreeFromEnv(WithCwd("d1/d2/d3"), WithExecAllowedDirs(".."))
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadFirstConfig("exec.yml", &opts))
+
+	// d1/d2/d3/exec.yml is not allowed to execute, so only the d2 exec
+	// config is loaded
+	assert.Equal(t, StringOption{tSrc("../exec.yml[stdout]", 1, 7), true, "d2str1val1"}, opts.String1)
+	assert.Equal(t, ListStringOption{
+		{tSrc("../exec.yml[stdout]", 3, 5), true, "d2arr1val1"},
+		{tSrc("../exec.yml[stdout]", 4, 5), true, "d2arr1val2"},
+	}, opts.Array1)
+	assert.Equal(t, MapStringOption{
+		"key1": {tSrc("../exec.yml[stdout]", 6, 9), true, "d2map1val1"},
+		"key2": {tSrc("../exec.yml[stdout]", 7, 9), true, "d2map1val2"},
+	}, opts.Map1)
+}
+
+func TestLoadAllConfigsContextTimeout(t *testing.T) {
+	dir := t.TempDir()
+	// sleep is a child of the script that holds stdout open
+	script := "#!/bin/sh\nsleep 10\necho str1: late\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "slow.yml"), []byte(script), 0o755))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(dir))
+	opts := TestOptions{}
+	start := time.Now()
+	err := fig.LoadAllConfigsContext(ctx, "slow.yml", &opts)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// a done context stops loading before any file is read
+	err = fig.LoadConfigContext(ctx, filepath.Join(dir, "slow.yml"), &opts)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,166 @@
+package figtree
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// timeNow is used to timestamp ExpiringOptions, it can be replaced in tests.
+var timeNow = time.Now
+
+// ExpiringOption is an Option whose value is only valid for a limited time,
+// like a token fetched by an executable config.  FetchedAt is set each time
+// the value is loaded, and the TTL is set from the `figtree:",ttl=..."` tag
+// on the field, using time.ParseDuration syntax, like:
+//
+//	Token ExpiringOption[string] `yaml:"token" figtree:",ttl=15m"`
+//
+// Stale options can be reloaded with RefreshStale.
+type ExpiringOption[T any] struct {
+	Option[T]
+	FetchedAt time.Time
+	// TTL is how long the value is valid after FetchedAt, a zero TTL
+	// never expires.
+	TTL time.Duration
+}
+
+// SetValue implements part of the option interface, the FetchedAt time is
+// updated with the value.
+func (o *ExpiringOption[T]) SetValue(v any) error {
+	if err := o.Option.SetValue(v); err != nil {
+		return err
+	}
+	o.FetchedAt = timeNow()
+	return nil
+}
+
+// IsStale returns true if the option is defined and the TTL has passed
+// since the value was fetched.
+func (o ExpiringOption[T]) IsStale() bool {
+	return o.isStaleAt(timeNow())
+}
+
+func (o ExpiringOption[T]) isStaleAt(now time.Time) bool {
+	return o.Defined && o.TTL > 0 && !now.Before(o.FetchedAt.Add(o.TTL))
+}
+
+func (o *ExpiringOption[T]) setTTL(ttl time.Duration) {
+	o.TTL = ttl
+}
+
+func (o *ExpiringOption[T]) reset() {
+	o.Option = Option[T]{}
+	o.FetchedAt = time.Time{}
+}
+
+// expiring is implemented by ExpiringOption.
+type expiring interface {
+	isStaleAt(time.Time) bool
+	setTTL(time.Duration)
+	reset()
+}
+
+// setTTL sets the TTL for ExpiringOption fields tagged with
+// `figtree:",ttl=..."`.
+func (m *Merger) setTTL(sf reflect.StructField, v reflect.Value) error {
+	tag, ok := figtreeTagValue(sf, "ttl")
+	if !ok || !v.CanAddr() {
+		return nil
+	}
+	e, ok := v.Addr().Interface().(expiring)
+	if !ok {
+		return nil
+	}
+	ttl, err := time.ParseDuration(tag)
+	if err != nil {
+		return errors.Wrapf(err, "invalid ttl for field %s", sf.Name)
+	}
+	e.setTTL(ttl)
+	return nil
+}
+
+// StaleKeys returns the dot separated key paths of the stale ExpiringOptions
+// in options.
+func StaleKeys(options any) []string {
+	keys := []string{}
+	for _, path := range collectStale(reflect.ValueOf(options), nil, timeNow(), nil) {
+		keys = append(keys, strings.Join(path, "."))
+	}
+	return keys
+}
+
+func collectStale(v reflect.Value, path []string, now time.Time, stale [][]string) [][]string {
+	v = uninterface(indirect(v))
+	if !v.IsValid() {
+		return stale
+	}
+	if v.CanAddr() {
+		if e, ok := v.Addr().Interface().(expiring); ok {
+			if e.isStaleAt(now) {
+				stale = append(stale, append([]string{}, path...))
+			}
+			return stale
+		}
+	}
+	if toOption(v) != nil {
+		return stale
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for name, field := range populateYAMLMaps(v) {
+			if field.StructField.PkgPath != "" || field.StructField.Anonymous {
+				continue
+			}
+			stale = collectStale(field.Value, append(path, name), now, stale)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			stale = collectStale(v.MapIndex(key), append(path, fmt.Sprint(key.Interface())), now, stale)
+		}
+	}
+	return stale
+}
+
+// RefreshStale reloads the stale ExpiringOptions in options from sources,
+// all other options are left unchanged.  The sources should be read again,
+// for example with ReadAllConfigs, so executable configs are run again to
+// fetch new values.
+func (f *FigTree) RefreshStale(sources []ConfigSource, options any) error {
+	now := timeNow()
+	stale := collectStale(reflect.ValueOf(options), nil, now, nil)
+	if len(stale) == 0 {
+		return nil
+	}
+	resetStale(reflect.ValueOf(options), now)
+	m := f.NewMerger()
+	m.refreshKeys = stale
+	return f.LoadAllConfigSourcesWithMerger(m, sources, options)
+}
+
+// resetStale resets the stale ExpiringOptions in v so they can be loaded
+// again.
+func resetStale(v reflect.Value, now time.Time) {
+	v = uninterface(indirect(v))
+	if !v.IsValid() {
+		return
+	}
+	if v.CanAddr() {
+		if e, ok := v.Addr().Interface().(expiring); ok {
+			if e.isStaleAt(now) {
+				e.reset()
+			}
+			return
+		}
+	}
+	if v.Kind() == reflect.Struct && toOption(v) == nil {
+		for _, field := range populateYAMLMaps(v) {
+			if field.StructField.PkgPath == "" && !field.StructField.Anonymous {
+				resetStale(field.Value, now)
+			}
+		}
+	}
+}
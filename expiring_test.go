@@ -0,0 +1,59 @@
+package figtree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiringOption(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() {
+		timeNow = time.Now
+	})
+
+	type Auth struct {
+		Token ExpiringOption[string] `yaml:"token" figtree:",ttl=15m"`
+	}
+	type Options struct {
+		Auth    Auth                   `yaml:"auth"`
+		Session ExpiringOption[string] `yaml:"session"`
+		Name    StringOption           `yaml:"name"`
+	}
+	first, err := SourceFromString("first", "auth: {token: abc}\nsession: s1\nname: first\n")
+	require.NoError(t, err)
+
+	fig := newFigTreeFromEnv()
+	opts := Options{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{first}, &opts))
+	assert.Equal(t, "abc", opts.Auth.Token.Value)
+	assert.Equal(t, tSrc("first", 1, 15), opts.Auth.Token.Source)
+	assert.Equal(t, now, opts.Auth.Token.FetchedAt)
+	assert.Equal(t, 15*time.Minute, opts.Auth.Token.TTL)
+	// no ttl tag, never expires
+	assert.Equal(t, time.Duration(0), opts.Session.TTL)
+	assert.False(t, opts.Auth.Token.IsStale())
+	assert.Empty(t, StaleKeys(&opts))
+
+	// nothing to refresh yet
+	second, err := SourceFromString("second", "auth: {token: def}\nsession: s2\nname: second\n")
+	require.NoError(t, err)
+	require.NoError(t, fig.RefreshStale([]ConfigSource{second}, &opts))
+	assert.Equal(t, "abc", opts.Auth.Token.Value)
+
+	now = now.Add(15 * time.Minute)
+	assert.True(t, opts.Auth.Token.IsStale())
+	assert.Equal(t, []string{"auth.token"}, StaleKeys(&opts))
+
+	// only the stale token is reloaded
+	require.NoError(t, fig.RefreshStale([]ConfigSource{second}, &opts))
+	assert.Equal(t, "def", opts.Auth.Token.Value)
+	assert.Equal(t, tSrc("second", 1, 15), opts.Auth.Token.Source)
+	assert.Equal(t, now, opts.Auth.Token.FetchedAt)
+	assert.False(t, opts.Auth.Token.IsStale())
+	assert.Equal(t, "s1", opts.Session.Value)
+	assert.Equal(t, "first", opts.Name.Value)
+}
@@ -0,0 +1,166 @@
+package figtree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoadAllConfigsProbeExtensions(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yaml":       "str1: top\nint1: 1\n",
+		"sub/figtree.yml":    "str1: sub-yml\n",
+		"sub/figtree.yaml":   "str1: sub-yaml\nbool1: true\n",
+		"sub/d/figtree.json": `{"float1": 1.5}`,
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "sub", "d")))
+	err := fig.LoadAllConfigs("figtree", &opts)
+	require.NoError(t, err)
+
+	expected := TestOptions{
+		String1: StringOption{tSrc("../figtree.yml", 1, 7), true, "sub-yml"},
+		Int1:    IntOption{tSrc("../../figtree.yaml", 2, 7), true, 1},
+		Float1:  Float32Option{tSrc("figtree.json", 1, 12), true, 1.5},
+	}
+	assert.Exactly(t, expected, opts)
+}
+
+func TestLoadAllConfigsWithExtensions(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml":  "str1: yml\n",
+		"figtree.yaml": "str1: yaml\n",
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(dir), WithExtensions(".yaml", "yml"))
+	err := fig.LoadAllConfigs("figtree", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{tSrc("figtree.yaml", 1, 7), true, "yaml"}, opts.String1)
+
+	// an explicit extension disables probing
+	opts = TestOptions{}
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{tSrc("figtree.yml", 1, 7), true, "yml"}, opts.String1)
+}
+
+func TestLoadAllConfigsWithSourceNames(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"home/figtree.yml":          "str1: home\n",
+		"home/proj/sub/figtree.yml": "int1: 1\n",
+	})
+	home := filepath.Join(dir, "home")
+	cwd := filepath.Join(home, "proj", "sub")
+
+	tests := []struct {
+		style SourceNameStyle
+		str1  string
+		int1  string
+	}{
+		{SourceNameRelativeToCwd, "../../figtree.yml", "figtree.yml"},
+		{SourceNameAbsolute, filepath.Join(home, "figtree.yml"), filepath.Join(cwd, "figtree.yml")},
+		{SourceNameRelativeToHome, "~/figtree.yml", "~/proj/sub/figtree.yml"},
+		{SourceNameBasename, "figtree.yml", "figtree.yml"},
+	}
+	for _, tt := range tests {
+		opts := TestOptions{}
+		fig := newFigTreeFromEnv(WithHome(home), WithCwd(cwd), WithSourceNames(tt.style))
+		err := fig.LoadAllConfigs("figtree.yml", &opts)
+		require.NoError(t, err)
+		assert.Equal(t, tt.str1, opts.String1.Source.Name)
+		assert.Equal(t, tt.int1, opts.Int1.Source.Name)
+	}
+
+	// files outside of home are absolute with SourceNameRelativeToHome
+	fig := newFigTreeFromEnv(WithHome(filepath.Join(dir, "other")), WithCwd(cwd), WithSourceNames(SourceNameRelativeToHome))
+	opts := TestOptions{}
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cwd, "figtree.yml"), opts.Int1.Source.Name)
+}
+
+func TestLoadAllConfigsJSON(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.json": "{\n\t\"str1\": \"json\",\n\t\"int1\": 2,\n\t\"arr1\": [\"a\", \"b\"],\n\t\"map1\": {\"k\": \"v\\u00e9\"},\n\t\"bool1\": true\n}\n",
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(dir))
+	err := fig.LoadAllConfigs("figtree", &opts)
+	require.NoError(t, err)
+
+	expected := TestOptions{
+		String1: StringOption{tSrc("figtree.json", 2, 10), true, "json"},
+		Int1:    IntOption{tSrc("figtree.json", 3, 10), true, 2},
+		Array1: []StringOption{
+			{tSrc("figtree.json", 4, 11), true, "a"},
+			{tSrc("figtree.json", 4, 16), true, "b"},
+		},
+		Map1: map[string]StringOption{
+			"k": {tSrc("figtree.json", 5, 16), true, "vé"},
+		},
+		Bool1: BoolOption{tSrc("figtree.json", 6, 11), true, true},
+	}
+	assert.Exactly(t, expected, opts)
+}
+
+func TestLoadAllConfigsJSONSyntaxError(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.json": "{\n  \"str1\": \"json\",\n  \"int1\": 2,\n}\n",
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(dir))
+	err := fig.LoadAllConfigs("figtree", &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "figtree.json:3:13:")
+}
+
+func TestLoadAllConfigsWithDecoder(t *testing.T) {
+	// a toy decoder for flat `key = "value"` TOML files
+	toml := ConfigDecoderFunc(func(file string, data []byte, node *yaml.Node) error {
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+		for i, line := range strings.Split(string(data), "\n") {
+			key, value, ok := strings.Cut(line, " = ")
+			if !ok {
+				continue
+			}
+			mapping.Content = append(mapping.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key, Line: i + 1, Column: 1},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.Trim(value, `"`), Line: i + 1, Column: len(key) + 4},
+			)
+		}
+		*node = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}
+		return nil
+	})
+
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.toml":     "str1 = \"toml\"\nleave-empty = \"\"\n",
+		"sub/figtree.json": `{"str1": "json"}`,
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "sub")), WithDecoder(".toml", toml))
+	err := fig.LoadAllConfigs("figtree", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{tSrc("figtree.json", 1, 10), true, "json"}, opts.String1)
+	assert.Equal(t, StringOption{tSrc("../figtree.toml", 2, 15), true, ""}, opts.LeaveEmpty)
+}
@@ -0,0 +1,149 @@
+package figtree
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// FeatureSetOption is a set of named feature toggles.  In config files the
+// set is written as a list of feature names, where a name prefixed with `!`
+// disables the feature:
+//
+//	features:
+//	  - a
+//	  - b
+//	  - !c
+//
+// Each feature is expanded to a BoolOption, so the features from all sources
+// are merged per feature name, and a source with higher precedence can
+// enable or disable features set in sources with lower precedence.  Note that
+// in flow style lists the `!` names must be quoted or followed by a space
+// before the closing bracket, like `[a, b, "!c"]`, since yaml otherwise parses
+// them as tags.
+type FeatureSetOption map[string]BoolOption
+
+var featureSetType = reflect.TypeOf(FeatureSetOption{})
+
+// parseFeature returns the feature name and if the feature is enabled from
+// the list form `name` or `!name`.
+func parseFeature(value string) (string, bool, error) {
+	name := strings.TrimPrefix(value, "!")
+	if name == "" {
+		return "", false, errors.Errorf("invalid feature %q, name is empty", value)
+	}
+	return name, name == value, nil
+}
+
+// negatableNodeValue returns the value of the scalar node, where yaml has
+// parsed a `!name` value as a local tag with an empty value the tag is
+// returned instead.
+func negatableNodeValue(node *yaml.Node) string {
+	if node.Value == "" && strings.HasPrefix(node.Tag, "!") && !strings.HasPrefix(node.Tag, "!!") {
+		return node.Tag
+	}
+	return node.Value
+}
+
+// Set implements part of the Value interface as defined by the kingpin command
+// line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+// The value is either `name` to enable the feature or `!name` to disable it.
+func (o *FeatureSetOption) Set(value string) error {
+	name, enabled, err := parseFeature(value)
+	if err != nil {
+		return err
+	}
+	if *o == nil {
+		*o = FeatureSetOption{}
+	}
+	(*o)[name] = BoolOption{OverrideSource, true, enabled}
+	return nil
+}
+
+// IsCumulative implements part of the remainderArg interface as defined by the
+// kingpin command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L49-L52
+func (o FeatureSetOption) IsCumulative() bool {
+	return true
+}
+
+// String implements part of the Value interface as defined by the kingpin
+// command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+func (o FeatureSetOption) String() string {
+	return fmt.Sprint(map[string]BoolOption(o))
+}
+
+// Enabled returns true if the feature name has been enabled.
+func (o FeatureSetOption) Enabled(name string) bool {
+	return o[name].Value
+}
+
+// Features returns the sorted names of all the enabled features.
+func (o FeatureSetOption) Features() []string {
+	features := []string{}
+	for name, opt := range o {
+		if opt.Value {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+func (o FeatureSetOption) IsDefined() bool {
+	// true if the set has any features
+	return len(o) > 0
+}
+
+// featureSetToMap converts the list form of a FeatureSetOption to a map of
+// feature name to bool so it can be merged like other maps.
+func featureSetToMap(src mergeSource) (mergeSource, error) {
+	if src.node != nil {
+		mapNode := &yaml.Node{
+			Kind:   yaml.MappingNode,
+			Tag:    "!!map",
+			Line:   src.node.Line,
+			Column: src.node.Column,
+		}
+		for _, item := range src.node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return mergeSource{}, errors.Errorf("invalid feature at line %d, column %d, expected a name", item.Line, item.Column)
+			}
+			name, enabled, err := parseFeature(negatableNodeValue(item))
+			if err != nil {
+				return mergeSource{}, errors.Wrapf(err, "invalid feature at line %d, column %d", item.Line, item.Column)
+			}
+			mapNode.Content = append(mapNode.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name, Line: item.Line, Column: item.Column},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(enabled), Line: item.Line, Column: item.Column},
+			)
+		}
+		return newMergeSource(mapNode), nil
+	}
+	features := map[string]bool{}
+	err := src.foreach(func(_ int, item mergeSource) error {
+		value, _, err := item.reflect()
+		if err != nil {
+			return err
+		}
+		if value.Kind() != reflect.String {
+			return errors.Errorf("invalid feature %v, expected a name", value)
+		}
+		name, enabled, err := parseFeature(value.String())
+		if err != nil {
+			return err
+		}
+		features[name] = enabled
+		return nil
+	})
+	if err != nil {
+		return mergeSource{}, err
+	}
+	return newMergeSource(reflect.ValueOf(features)), nil
+}
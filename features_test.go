@@ -0,0 +1,63 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureSetOption(t *testing.T) {
+	type config struct {
+		Features FeatureSetOption `yaml:"features"`
+	}
+	top, err := SourceFromString("top.yml", "features: [a, b, c]\n")
+	require.NoError(t, err)
+	mid, err := SourceFromString("mid.yml", "features:\n  - !b\n  - d\n")
+	require.NoError(t, err)
+	bottom, err := SourceFromString("bottom.yml", `features: ["!c", e]`)
+	require.NoError(t, err)
+
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{bottom, mid, top}, &opts)
+	require.NoError(t, err)
+
+	expected := FeatureSetOption{
+		"a": {tSrc("top.yml", 1, 12), true, true},
+		"b": {tSrc("mid.yml", 2, 5), true, false},
+		"c": {tSrc("bottom.yml", 1, 12), true, false},
+		"d": {tSrc("mid.yml", 3, 5), true, true},
+		"e": {tSrc("bottom.yml", 1, 18), true, true},
+	}
+	assert.Equal(t, expected, opts.Features)
+	assert.Equal(t, []string{"a", "d", "e"}, opts.Features.Features())
+	assert.True(t, opts.Features.Enabled("a"))
+	assert.False(t, opts.Features.Enabled("b"))
+	assert.False(t, opts.Features.Enabled("missing"))
+
+	// command line flags take precedence over config files
+	opts = config{}
+	require.NoError(t, opts.Features.Set("!a"))
+	require.NoError(t, opts.Features.Set("b"))
+	err = fig.LoadAllConfigSources([]ConfigSource{bottom, mid, top}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "d", "e"}, opts.Features.Features())
+	assert.Error(t, opts.Features.Set("!"))
+
+	// list values from overrides are expanded as well
+	opts = config{}
+	fig = newFigTreeFromEnv(WithOverrides(map[string]any{
+		"features": []string{"!d", "f"},
+	}))
+	err = fig.LoadAllConfigSources([]ConfigSource{mid}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f"}, opts.Features.Features())
+	assert.Equal(t, BoolOption{NewSource("override"), true, false}, opts.Features["d"])
+
+	bad, err := SourceFromString("bad.yml", "features: [[a]]\n")
+	require.NoError(t, err)
+	opts = config{}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{bad}, &opts)
+	assert.Error(t, err)
+}
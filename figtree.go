@@ -2,9 +2,12 @@ package figtree
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
+	"math"
 	"os"
 	"os/exec"
 	"path"
@@ -14,6 +17,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 
 	"emperror.dev/errors"
@@ -30,6 +35,8 @@ type nullLogger struct{}
 
 func (*nullLogger) Debugf(string, ...interface{}) {}
 
+// Log is the default Logger used when a FigTree or Merger has not been
+// configured with a specific Logger.
 var Log Logger = &nullLogger{}
 
 func defaultApplyChangeSet(changeSet map[string]*string) error {
@@ -43,11 +50,64 @@ func defaultApplyChangeSet(changeSet map[string]*string) error {
 	return nil
 }
 
+// exportedEnv is the environment variables exported by a FigTree, so
+// WithEnvOverrides does not read values back from an earlier load as
+// overrides.
+type exportedEnv struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// record records the exported values in changeSet, values that were already
+// in the environment were not exported by the FigTree.
+func (e *exportedEnv) record(changeSet map[string]*string, getenv func(string) string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for name, value := range changeSet {
+		switch {
+		case value == nil:
+			delete(e.values, name)
+		case getenv(name) != *value:
+			if e.values == nil {
+				e.values = map[string]string{}
+			}
+			e.values[name] = *value
+		}
+	}
+}
+
+// isExported returns true if value was exported to name by the FigTree.
+func (e *exportedEnv) isExported(name, value string) bool {
+	if e == nil {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	exported, ok := e.values[name]
+	return ok && exported == value
+}
+
 type CreateOption func(*FigTree)
 
 func WithHome(home string) CreateOption {
 	return func(f *FigTree) {
 		f.home = home
+		f.homeSet = true
+	}
+}
+
+// WithEnviron sets the function used to read environment variables, like
+// HOME and the WithEnvPrefix variables, instead of os.Getenv.  Executable
+// configs are run with the process environment read through getenv, with
+// HOME set to the FigTree home directory.  This allows a FigTree to be
+// fully isolated from the process environment, for example in parallel
+// tests.
+func WithEnviron(getenv func(key string) string) CreateOption {
+	return func(f *FigTree) {
+		f.environ = getenv
 	}
 }
 
@@ -63,12 +123,66 @@ func WithEnvPrefix(env string) CreateOption {
 	}
 }
 
+// WithEnvKeyFilter restricts the options exported to the environment by
+// PopulateEnv to the keys where filter returns true.  The key is the name of
+// the top level option in config files, like `str1`.  This is useful to
+// avoid exceeding environment size limits with large configs.
+func WithEnvKeyFilter(filter func(key string) bool) CreateOption {
+	return func(f *FigTree) {
+		f.envKeyFilter = filter
+	}
+}
+
+// WithEnvOverrides reads the top level options back from the environment,
+// using the same variable names as PopulateEnv, like FIGTREE_STR_1.  Values
+// found in the environment take precedence over config files but not over
+// flags or WithOverrides, and have the source `env:<NAME>`.  Scalar options
+// are converted from the string value, lists, maps and structs are parsed
+// as YAML or JSON.  Lists may also be comma separated, like `a,b,c`, and
+// maps comma separated `key=value` pairs, then each element has its own
+// source, like `env:<NAME>[2]` or `env:<NAME>[key]`.  Empty variables are
+// ignored.  The JSON values exported by PopulateEnv are read back, so a
+// child process can load the options exported by its parent, but values the
+// FigTree exported itself in an earlier load are not treated as overrides.
+func WithEnvOverrides() CreateOption {
+	return func(f *FigTree) {
+		f.envOverrides = true
+	}
+}
+
+// WithConfigDir sets a directory, like `etc/app`, that contains the config
+// files.  A relative dir is probed in every directory searched, so
+// `<dir>/etc/app/<file>` is loaded from the working directory and from each
+// of its parents, see also WithConfigDirMode.
 func WithConfigDir(dir string) CreateOption {
 	return func(f *FigTree) {
 		f.configDir = dir
 	}
 }
 
+// ConfigDirMode controls the files probed when WithConfigDir is used.
+type ConfigDirMode int
+
+const (
+	// ConfigDirOnly probes only `<dir>/<config dir>/<file>` in each
+	// directory searched.  This is the default.
+	ConfigDirOnly ConfigDirMode = iota
+	// ConfigDirFirst probes `<dir>/<config dir>/<file>` and then
+	// `<dir>/<file>` in each directory searched, the first file found in
+	// each directory is loaded.  This allows projects in a monorepo to keep
+	// their configs in a nested directory while shared configs remain at
+	// the top of the repo.
+	ConfigDirFirst
+)
+
+// WithConfigDirMode sets the files probed for a relative WithConfigDir
+// directory.  An absolute config dir is always the only location searched.
+func WithConfigDirMode(mode ConfigDirMode) CreateOption {
+	return func(f *FigTree) {
+		f.configDirMode = mode
+	}
+}
+
 type ChangeSetFunc func(map[string]*string) error
 
 func WithApplyChangeSet(apply ChangeSetFunc) CreateOption {
@@ -122,36 +236,267 @@ func WithoutExec() CreateOption {
 	}
 }
 
+// WithFS makes FigTree read config files from fsys rather than the
+// operating system, for example to test config cascades with an
+// fstest.MapFS.  Absolute paths like `/home/bob/figtree.yml` are read from
+// fsys as `home/bob/figtree.yml`.  Files in fsys are never executed.
+func WithFS(fsys fs.FS) CreateOption {
+	return func(f *FigTree) {
+		f.fsys = fsys
+	}
+}
+
+// WithExecAllowedDirs restricts executable configs to config files directly
+// in one of dirs, executable configs found in other directories are skipped.
+// Relative dirs are relative to the working directory.  This is finer
+// grained than WithoutExec, for example to allow executable configs in a
+// repository root but never in $HOME.
+func WithExecAllowedDirs(dirs ...string) CreateOption {
+	return func(f *FigTree) {
+		f.execAllowedDirs = append([]string{}, dirs...)
+	}
+}
+
+// WithoutParentTraversal stops LoadAllConfigs from searching every parent
+// directory of the working directory.  Only the config files in /etc, the
+// home directory and the working directory are loaded.
+func WithoutParentTraversal() CreateOption {
+	return func(f *FigTree) {
+		f.noParentTraversal = true
+	}
+}
+
+// WithRequireConfig makes LoadConfig, LoadAllConfigs and LoadFirstConfig
+// return a ConfigNotFoundError when no config file is found, rather than
+// loading just the overrides and defaults.
+func WithRequireConfig() CreateOption {
+	return func(f *FigTree) {
+		f.requireConfig = true
+	}
+}
+
+// defaultExtensions are the file extensions probed, in order of preference,
+// when LoadAllConfigs is given a config file name without an extension.
+var defaultExtensions = []string{"yml", "yaml", "json"}
+
+// WithExtensions sets the file extensions that are probed, in order of
+// preference, when LoadAllConfigs is given a config file name without an
+// extension.  At each directory level only the first matching file is loaded.
+func WithExtensions(exts ...string) CreateOption {
+	return func(f *FigTree) {
+		f.extensions = nil
+		for _, ext := range exts {
+			f.extensions = append(f.extensions, strings.TrimPrefix(ext, "."))
+		}
+	}
+}
+
+// WithOverrides sets values that are merged into the options as the highest
+// priority source when loading configs.  The values will have their source
+// set to "override", the same as values populated from command line flags.
+func WithOverrides(overrides map[string]any) CreateOption {
+	return func(f *FigTree) {
+		f.overrides = overrides
+	}
+}
+
+// WithDeepCopyValues will ensure the loaded options do not share any maps,
+// slices or pointers with the config sources, see DeepCopyValues.
+func WithDeepCopyValues() CreateOption {
+	return func(f *FigTree) {
+		f.deepCopy = true
+	}
+}
+
+// WithPreserveAnchors will retain yaml aliases in yaml.Node options, see
+// PreserveAnchors.
+func WithPreserveAnchors() CreateOption {
+	return WithMergeOptions(PreserveAnchors())
+}
+
+// WithLogger sets the Logger used when loading configs, otherwise the global
+// Log is used.
+func WithLogger(logger Logger) CreateOption {
+	return func(f *FigTree) {
+		f.logger = logger
+	}
+}
+
+// FlagProvider is used to look up values for feature flags at load time, see
+// WithFlagProvider.
+type FlagProvider interface {
+	// Flag returns the value for key, ok will be false if the provider has
+	// no value for the key.
+	Flag(key string) (value any, ok bool, err error)
+}
+
+// FlagProviderFunc is an adapter to allow the use of ordinary functions as a
+// FlagProvider.
+type FlagProviderFunc func(key string) (any, bool, error)
+
+func (fn FlagProviderFunc) Flag(key string) (any, bool, error) {
+	return fn(key)
+}
+
+// WithFlagProvider will consult provider for each of the config keys when
+// loading configs.  Keys are the YAML names of the config options, nested
+// options use dotted paths like `server.port`.  Flag values take precedence
+// over config files but not over values from WithOverrides.  The source for
+// the values will be `flag:<key>`.
+func WithFlagProvider(provider FlagProvider, keys ...string) CreateOption {
+	return func(f *FigTree) {
+		f.flagProvider = provider
+		f.flagKeys = keys
+	}
+}
+
+// SourceNameStyle controls how config file names are rendered in
+// ConfigSource Filename and option SourceLocation names.
+type SourceNameStyle int
+
+const (
+	// SourceNameRelativeToCwd renders file names relative to the working
+	// directory, like `../../figtree.yml`.  This is the default.
+	SourceNameRelativeToCwd SourceNameStyle = iota
+	// SourceNameAbsolute renders absolute file names.
+	SourceNameAbsolute
+	// SourceNameRelativeToHome renders file names relative to the home
+	// directory, like `~/projects/figtree.yml`.  Files outside the home
+	// directory are rendered as absolute file names.
+	SourceNameRelativeToHome
+	// SourceNameBasename renders just the base file name, like `figtree.yml`.
+	SourceNameBasename
+)
+
+// WithSourceNames sets how config file names are rendered in ConfigSource
+// Filename and option SourceLocation names.
+func WithSourceNames(style SourceNameStyle) CreateOption {
+	return func(f *FigTree) {
+		f.sourceNames = style
+	}
+}
+
+// WithMergeOptions adds MergeOptions used for every Merger created while
+// loading configs, for example WithIgnoreKeys.
+func WithMergeOptions(options ...MergeOption) CreateOption {
+	return func(f *FigTree) {
+		f.mergeOptions = append(f.mergeOptions, options...)
+	}
+}
+
 type FigTree struct {
-	home           string
-	workDir        string
-	configDir      string
-	envPrefix      string
-	preProcessor   PreProcessor
-	applyChangeSet ChangeSetFunc
-	exec           bool
-	filterOut      FilterOut
+	home              string
+	homeSet           bool
+	workDir           string
+	configDir         string
+	configDirMode     ConfigDirMode
+	envPrefix         string
+	preProcessor      PreProcessor
+	applyChangeSet    ChangeSetFunc
+	exec              bool
+	filterOut         FilterOut
+	extensions        []string
+	overrides         map[string]any
+	deepCopy          bool
+	logger            Logger
+	flagProvider      FlagProvider
+	flagKeys          []string
+	sourceNames       SourceNameStyle
+	environ           func(string) string
+	defaultsProfiles  []string
+	mergeOptions      []MergeOption
+	sourceMetadata    SourceMetadataFunc
+	ageDecrypter      Decrypter
+	ageEncrypter      Encrypter
+	encryptKeys       [][]string
+	execAllowedDirs   []string
+	noParentTraversal bool
+	homeFirst         bool
+	homeFirstGroups   []string
+	envKeyFilter      func(string) bool
+	envOverrides      bool
+	requireConfig     bool
+	fsys              fs.FS
+	middleware        []Middleware
+	decoders          map[string]ConfigDecoder
+	clock             func() time.Time
+	matchContext      map[string]string
+	fingerprintSalt   []byte
+	usage             *usageTracker
+	command           string
+	profile           string
+	extends           bool
+	keyAliases        map[string]string
+	keyAliasHooks     []KeyAliasHook
+	providers         []ConfigProvider
+	exported          *exportedEnv
 }
 
 func NewFigTree(opts ...CreateOption) *FigTree {
 	wd, _ := os.Getwd()
 	fig := &FigTree{
-		home:           os.Getenv("HOME"),
 		workDir:        wd,
 		envPrefix:      "FIGTREE",
 		applyChangeSet: defaultApplyChangeSet,
 		exec:           true,
+		extensions:     defaultExtensions,
+		exported:       &exportedEnv{},
 	}
 	for _, opt := range opts {
 		opt(fig)
 	}
+	if !fig.homeSet {
+		fig.home = fig.getenv("HOME")
+	}
+	if !filepath.IsAbs(fig.workDir) {
+		fig.workDir = filepath.Join(wd, fig.workDir)
+	}
+	fig.resolveExecAllowedDirs()
 	return fig
 }
 
+// getenv returns the value of the environment variable key read via the
+// WithEnviron function.
+func (f *FigTree) getenv(key string) string {
+	if f.environ == nil {
+		return os.Getenv(key)
+	}
+	return f.environ(key)
+}
+
 func (f *FigTree) WithHome(home string) {
 	WithHome(home)(f)
 }
 
+func (f *FigTree) WithEnviron(getenv func(key string) string) {
+	WithEnviron(getenv)(f)
+	if !f.homeSet {
+		f.home = f.getenv("HOME")
+	}
+}
+
+// execEnv returns the environment for executable configs, nil for the
+// process environment unless WithEnviron is used.
+func (f *FigTree) execEnv() []string {
+	if f.environ == nil {
+		return nil
+	}
+	env := []string{}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if name == "HOME" {
+			continue
+		}
+		if value := f.getenv(name); value != "" {
+			env = append(env, name+"="+value)
+		}
+	}
+	if f.home != "" {
+		env = append(env, "HOME="+f.home)
+	}
+	return env
+}
+
 func (f *FigTree) WithCwd(cwd string) {
 	WithCwd(cwd)(f)
 }
@@ -160,10 +505,18 @@ func (f *FigTree) WithEnvPrefix(env string) {
 	WithEnvPrefix(env)(f)
 }
 
+func (f *FigTree) WithEnvKeyFilter(filter func(key string) bool) {
+	WithEnvKeyFilter(filter)(f)
+}
+
 func (f *FigTree) WithConfigDir(dir string) {
 	WithConfigDir(dir)(f)
 }
 
+func (f *FigTree) WithConfigDirMode(mode ConfigDirMode) {
+	WithConfigDirMode(mode)(f)
+}
+
 func (f *FigTree) WithPreProcessor(pp PreProcessor) {
 	WithPreProcessor(pp)(f)
 }
@@ -186,187 +539,1259 @@ func (f *FigTree) WithoutExec() {
 	WithoutExec()(f)
 }
 
+func (f *FigTree) WithExtensions(exts ...string) {
+	WithExtensions(exts...)(f)
+}
+
+func (f *FigTree) WithOverrides(overrides map[string]any) {
+	WithOverrides(overrides)(f)
+}
+
+func (f *FigTree) WithDeepCopyValues() {
+	WithDeepCopyValues()(f)
+}
+
+func (f *FigTree) WithLogger(logger Logger) {
+	WithLogger(logger)(f)
+}
+
+func (f *FigTree) WithFlagProvider(provider FlagProvider, keys ...string) {
+	WithFlagProvider(provider, keys...)(f)
+}
+
+func (f *FigTree) WithSourceNames(style SourceNameStyle) {
+	WithSourceNames(style)(f)
+}
+
+func (f *FigTree) WithMergeOptions(options ...MergeOption) {
+	WithMergeOptions(options...)(f)
+}
+
+func (f *FigTree) WithPreserveAnchors() {
+	WithPreserveAnchors()(f)
+}
+
+func (f *FigTree) WithAgeDecrypter(decrypt Decrypter) {
+	WithAgeDecrypter(decrypt)(f)
+}
+
+func (f *FigTree) WithExecAllowedDirs(dirs ...string) {
+	WithExecAllowedDirs(dirs...)(f)
+	f.resolveExecAllowedDirs()
+}
+
+func (f *FigTree) WithoutParentTraversal() {
+	WithoutParentTraversal()(f)
+}
+
+func (f *FigTree) WithRequireConfig() {
+	WithRequireConfig()(f)
+}
+
+func (f *FigTree) WithMiddleware(mw Middleware) {
+	WithMiddleware(mw)(f)
+}
+
+func (f *FigTree) WithFS(fsys fs.FS) {
+	WithFS(fsys)(f)
+}
+
+func (f *FigTree) WithSourceMetadata(fn SourceMetadataFunc) {
+	WithSourceMetadata(fn)(f)
+}
+
+func (f *FigTree) log() Logger {
+	if f.logger != nil {
+		return f.logger
+	}
+	return Log
+}
+
 func (f *FigTree) Copy() *FigTree {
 	cp := *f
 	return &cp
 }
 
+// With returns a FigTree derived from f with the options applied, for
+// example to load the configs for another directory with a different env
+// prefix:
+//
+//	staging := fig.With(figtree.WithCwd("deploy/staging"), figtree.WithEnvPrefix("STAGING"))
+//
+// f is not modified, and a relative WithCwd is relative to the working
+// directory of f.  The derived FigTree shares the config providers, and so
+// their caches, with f, along with the decoders, logger and file system.
+// Settings that options add to, like WithMergeOptions and WithMiddleware,
+// are extended independently of f, and usage tracking is tracked
+// separately.  Derived FigTrees can be used concurrently with f.
+func (f *FigTree) With(opts ...CreateOption) *FigTree {
+	fig := f.Copy()
+	// clip the slices so options that append to them do not write to the
+	// backing arrays shared with f
+	fig.extensions = fig.extensions[:len(fig.extensions):len(fig.extensions)]
+	fig.flagKeys = fig.flagKeys[:len(fig.flagKeys):len(fig.flagKeys)]
+	fig.defaultsProfiles = fig.defaultsProfiles[:len(fig.defaultsProfiles):len(fig.defaultsProfiles)]
+	fig.mergeOptions = fig.mergeOptions[:len(fig.mergeOptions):len(fig.mergeOptions)]
+	fig.encryptKeys = fig.encryptKeys[:len(fig.encryptKeys):len(fig.encryptKeys)]
+	fig.homeFirstGroups = fig.homeFirstGroups[:len(fig.homeFirstGroups):len(fig.homeFirstGroups)]
+	fig.middleware = fig.middleware[:len(fig.middleware):len(fig.middleware)]
+	fig.keyAliasHooks = fig.keyAliasHooks[:len(fig.keyAliasHooks):len(fig.keyAliasHooks)]
+	fig.providers = fig.providers[:len(fig.providers):len(fig.providers)]
+	if f.execAllowedDirs != nil {
+		// resolved in place against the working directory
+		fig.execAllowedDirs = append([]string{}, f.execAllowedDirs...)
+	}
+	if f.usage != nil {
+		fig.usage = &usageTracker{}
+	}
+	for _, opt := range opts {
+		opt(fig)
+	}
+	if !filepath.IsAbs(fig.workDir) {
+		fig.workDir = filepath.Join(f.workDir, fig.workDir)
+	}
+	fig.resolveExecAllowedDirs()
+	return fig
+}
+
+// LoadAllConfigsFrom is like LoadAllConfigs but uses dir as the working
+// directory rather than the FigTree working directory.  A relative dir is
+// relative to the FigTree working directory.  This allows configs to be
+// loaded for multiple directories concurrently without changing the process
+// working directory.
+func (f *FigTree) LoadAllConfigsFrom(dir, configFile string, options interface{}) error {
+	fig := f.Copy()
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(f.workDir, dir)
+	}
+	fig.workDir = filepath.Clean(dir)
+	return fig.LoadAllConfigs(configFile, options)
+}
+
+// LoadAllConfigs will load and merge all the config files named configFile
+// found in /etc, the home directory and each parent directory of the working
+// directory, with the nearest file taking precedence, see also
+// WithoutParentTraversal.  If configFile has no
+// extension then each of the configured extensions (see WithExtensions) is
+// probed at every level.
 func (f *FigTree) LoadAllConfigs(configFile string, options interface{}) error {
-	if f.configDir != "" {
-		configFile = path.Join(f.configDir, configFile)
+	return f.LoadAllConfigsContext(context.Background(), configFile, options)
+}
+
+// LoadAllConfigsContext is like LoadAllConfigs but stops loading when ctx is
+// done, killing any executable config that is still running.
+func (f *FigTree) LoadAllConfigsContext(ctx context.Context, configFile string, options interface{}) error {
+	_, err := f.loadAllConfigs(ctx, configFile, options)
+	return err
+}
+
+// loadAllConfigs is LoadAllConfigsContext, it returns the sources loaded.
+func (f *FigTree) loadAllConfigs(ctx context.Context, configFile string, options interface{}) ([]ConfigSource, error) {
+	configSources, err := f.ReadAllConfigsContext(ctx, configFile)
+	if err != nil {
+		return nil, err
 	}
+	if len(configSources) == 0 && f.requireConfig {
+		return nil, f.configNotFound(configFile)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return configSources, f.LoadAllConfigSources(configSources, options)
+}
 
-	paths := FindParentPaths(f.home, f.workDir, configFile)
-	paths = append([]string{fmt.Sprintf("/etc/%s", configFile)}, paths...)
+// ReadAllConfigs returns the config sources that LoadAllConfigs would load
+// for configFile, in precedence order.  This can be used to load the sources
+// with LoadAllConfigSources and then build a LoadReport for them.
+func (f *FigTree) ReadAllConfigs(configFile string) ([]ConfigSource, error) {
+	return f.ReadAllConfigsContext(context.Background(), configFile)
+}
 
+// ReadAllConfigsContext is like ReadAllConfigs but stops reading when ctx is
+// done.
+func (f *FigTree) ReadAllConfigsContext(ctx context.Context, configFile string) ([]ConfigSource, error) {
+	paths := f.configPaths(configFile)
 	configSources := []ConfigSource{}
+	// loaded tracks the file info for each of the configSources so that
+	// paths resolving to the same file, via symlinks or bind mounts, are
+	// only merged once
+	loaded := []os.FileInfo{}
 	// iterate paths in reverse
+PATHS:
 	for i := len(paths) - 1; i >= 0; i-- {
 		file := paths[i]
-		cs, err := f.ReadFile(file)
+		stat, statErr := f.stat(file)
+		if statErr == nil {
+			for j, prev := range loaded {
+				if os.SameFile(prev, stat) {
+					alias := f.sourceName(file, file)
+					f.log().Debugf("Skipping config %s, it is the same file as %s", file, configSources[j].Filename)
+					configSources[j].Aliases = append(configSources[j].Aliases, alias)
+					continue PATHS
+				}
+			}
+		}
+		sources, err := f.readFileSources(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		for _, cs := range sources {
+			var csStat os.FileInfo
+			if cs.Version != nil {
+				csStat, _ = f.stat(cs.Version.Path)
+			}
+			loaded = append(loaded, csStat)
+			configSources = append(configSources, cs)
+		}
+	}
+	providerSources, err := f.readProviderConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(configSources, providerSources...), nil
+}
+
+// LoadFirstConfig is like LoadAllConfigs but only the nearest config file
+// found is loaded, rather than merging all of them.  Overrides, flags and
+// defaults profiles are still merged with the config.
+func (f *FigTree) LoadFirstConfig(configFile string, options interface{}) error {
+	paths := f.configPaths(configFile)
+	for i := len(paths) - 1; i >= 0; i-- {
+		sources, err := f.readFileSources(context.Background(), paths[i])
 		if err != nil {
 			return err
 		}
-		if cs == nil {
-			// no file contents to parse, file likely does not exist
+		if sources == nil {
+			// file does not exist, or it is a disallowed executable
 			continue
 		}
-		configSources = append(configSources, *cs)
+		return f.LoadAllConfigSources(sources, options)
+	}
+	if f.requireConfig {
+		return f.configNotFound(configFile)
 	}
-	return f.LoadAllConfigSources(configSources, options)
+	return f.LoadAllConfigSources(nil, options)
 }
 
-type ConfigSource struct {
-	Config   *yaml.Node
-	Filename string
+// ErrConfigNotFound is matched by ConfigNotFoundError with errors.Is.
+var ErrConfigNotFound = errors.NewPlain("config not found")
+
+// ConfigNotFoundError is returned when no config file is found and
+// WithRequireConfig is used.
+type ConfigNotFoundError struct {
+	File string
+	// SearchPath are the file paths that were searched, in precedence
+	// order.
+	SearchPath []string
 }
 
-func (f *FigTree) LoadAllConfigSources(sources []ConfigSource, options interface{}) error {
-	m := NewMerger()
-	filterOut := f.filterOut
-	if filterOut == nil {
-		filterOut = defaultFilterOut(f)
-	}
+func (e ConfigNotFoundError) Error() string {
+	return fmt.Sprintf("config %s not found, searched: %s", e.File, strings.Join(e.SearchPath, ", "))
+}
 
-	for _, source := range sources {
-		// automatically skip empty configs
-		if source.Config == nil || source.Config.IsZero() {
-			continue
+func (e ConfigNotFoundError) Unwrap() error {
+	return ErrConfigNotFound
+}
+
+// configNotFound returns a ConfigNotFoundError for configFile with the paths
+// searched by LoadAllConfigs.
+func (f *FigTree) configNotFound(configFile string) error {
+	fileNames := f.searchFileNames(configFile)
+	if f.configDir != "" {
+		configFile = path.Join(f.configDir, configFile)
+	}
+	if filepath.IsAbs(configFile) {
+		return errors.WithStack(ConfigNotFoundError{File: configFile, SearchPath: fileNames})
+	}
+	dirs := []string{}
+	if f.noParentTraversal {
+		dirs = append(dirs, f.workDir)
+	} else {
+		for dir := f.workDir; ; dir = filepath.Dir(dir) {
+			dirs = append(dirs, dir)
+			if dir == filepath.Dir(dir) {
+				break
+			}
 		}
-		skip := filterOut(source.Config)
-		if skip {
-			continue
+	}
+	if f.home != "" && (!isSubPath(f.home, f.workDir) || f.noParentTraversal && f.home != f.workDir) {
+		dirs = append(dirs, f.home)
+	}
+	dirs = append(dirs, "/etc")
+	searchPath := []string{}
+	for _, dir := range dirs {
+		for _, fileName := range fileNames {
+			searchPath = append(searchPath, filepath.Join(dir, fileName))
 		}
+	}
+	return errors.WithStack(ConfigNotFoundError{File: configFile, SearchPath: searchPath})
+}
 
-		m.sourceFile = source.Filename
-		err := f.loadConfigSource(m, source.Config, options)
-		if err != nil {
-			return err
-		}
-		m.advance()
+// configPaths returns the paths of the existing config files for
+// configFile, lowest precedence first.
+func (f *FigTree) configPaths(configFile string) []string {
+	fileNames := f.searchFileNames(configFile)
+	var paths []string
+	if f.noParentTraversal {
+		paths = findHomeAndCwdPaths(f.stat, f.home, f.workDir, fileNames)
+	} else {
+		paths = findParentPaths(f.stat, f.home, f.workDir, fileNames)
 	}
-	return nil
+	if etc := firstExisting(f.stat, "/etc", fileNames); etc != "" {
+		paths = append([]string{etc}, paths...)
+	}
+	return paths
 }
 
-func (f *FigTree) LoadConfigSource(config *yaml.Node, source string, options interface{}) error {
-	m := NewMerger(WithSourceFile(source))
-	return f.loadConfigSource(m, config, options)
+type ConfigSource struct {
+	Config   *yaml.Node
+	Filename string
+	// IncludeChain is the list of sources that included this source,
+	// outermost first, if any.
+	IncludeChain []string
+	// Labels are optional descriptive metadata for the source, like
+	// `managed-by: admin`, for use when reporting where options came from.
+	Labels map[string]string
+	// Priority changes the precedence of the source.  Sources with a higher
+	// priority take precedence over sources with a lower priority, sources
+	// with the same priority retain their order.  The default is 0.
+	Priority int
+	// ReadOnly marks sources that must not be modified, APIs that write
+	// options back to their sources should refuse to modify them.
+	ReadOnly bool
+	// Aliases are other paths found while searching for config files that
+	// resolve to the same file as this source, for example via symlinks.
+	// The file is only loaded once, from its highest precedence path.
+	Aliases []string
+	// Version identifies the contents of the config file when the source
+	// was read with ReadFile, it is nil for other sources.  For executable
+	// configs it identifies the executable, not the output.
+	Version *FileVersion
+	// home is true for config files in the home directory, see
+	// WithHomeFirst.
+	home bool
 }
 
-func sourceLine(file string, node *yaml.Node) string {
-	if node.Line > 0 {
-		return fmt.Sprintf("%s:%d:%d", file, node.Line, node.Column)
+// SourceMetadataFunc can set the metadata (Labels, Priority and ReadOnly) for
+// a ConfigSource read from the config file at the absolute path file.
+type SourceMetadataFunc func(file string, source *ConfigSource)
+
+// LoadFunc merges a single config source into options.
+type LoadFunc func(source ConfigSource, options any) error
+
+// Middleware wraps the LoadFunc used for each config source, see
+// WithMiddleware.
+type Middleware func(next LoadFunc) LoadFunc
+
+// WithMiddleware adds middleware around the loading of each config source by
+// LoadAllConfigs and LoadAllConfigSources.  This can be used for timing,
+// tracing, validation or to modify the source before it is merged.  The
+// first middleware added is the outermost.  Overrides, flags and defaults
+// are not loaded through the middleware.
+func WithMiddleware(mw Middleware) CreateOption {
+	return func(f *FigTree) {
+		f.middleware = append(f.middleware, mw)
 	}
-	return file
 }
 
-func (f *FigTree) loadConfigSource(m *Merger, config *yaml.Node, options interface{}) error {
-	if !reflect.ValueOf(options).IsValid() {
-		return errors.Errorf("options argument [%#v] is not valid", options)
+// loadSource merges source into options with m, through the middleware.
+func (f *FigTree) loadSource(m *Merger, source ConfigSource, options any) error {
+	var load LoadFunc = func(source ConfigSource, options any) error {
+		m.sourceFile = source.Filename
+		m.includeChain = source.IncludeChain
+		return f.loadConfigSource(m, source.Config, options)
 	}
+	for i := len(f.middleware) - 1; i >= 0; i-- {
+		load = f.middleware[i](load)
+	}
+	return load(source, options)
+}
 
-	var err error
-	if f.preProcessor != nil {
-		err = f.preProcessor(config)
-		if err != nil {
-			return errors.Wrapf(err, "failed to process config file %s", sourceLine(m.sourceFile, config))
-		}
+// WithSourceMetadata sets a function to populate the metadata for each
+// config file read, for example to mark the files in /etc as read only.
+func WithSourceMetadata(fn SourceMetadataFunc) CreateOption {
+	return func(f *FigTree) {
+		f.sourceMetadata = fn
 	}
+}
 
-	err = config.Decode(m)
-	if err != nil {
-		return errors.WithStack(walky.ErrFilename(err, m.sourceFile))
+// SourceFromString returns a ConfigSource named name from the yaml
+// document in data.  Option locations are the line and column within data.
+// This is useful for constructing sources in tests without config files.
+func SourceFromString(name, data string) (ConfigSource, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &node); err != nil {
+		return ConfigSource{}, errors.Wrapf(err, "failed to parse %s", name)
 	}
+	return ConfigSource{Config: &node, Filename: name}, nil
+}
 
-	_, err = m.mergeStructs(
-		reflect.ValueOf(options),
-		newMergeSource(walky.UnwrapDocument(config)),
-		false,
-	)
+// SourceFromMap returns a ConfigSource named name from the values in data.
+// The values are rendered as yaml, with map keys sorted, so option locations
+// are stable line and column numbers within that rendered document.
+func SourceFromMap(name string, data map[string]any) (ConfigSource, error) {
+	content, err := yaml.Marshal(data)
 	if err != nil {
-		return err
+		return ConfigSource{}, errors.Wrapf(err, "failed to encode %s", name)
 	}
-	changeSet := f.PopulateEnv(options)
-	return f.applyChangeSet(changeSet)
+	return SourceFromString(name, string(content))
 }
 
-func (f *FigTree) LoadConfig(file string, options interface{}) error {
-	cs, err := f.ReadFile(file)
-	if err != nil {
-		return err
+// NewValueSource returns a ConfigSource for the Go value v, like an API
+// response or database row, so it can be loaded with LoadAllConfigSources.
+// v may be a map or struct, Options in v are loaded by value and undefined
+// Options are omitted, so they do not hide values from other sources.  The
+// Options loaded from the source have the Name, IncludeChain and Location
+// of loc, rather than locations within a rendered document.
+func NewValueSource(v any, loc SourceLocation) (ConfigSource, error) {
+	var node yaml.Node
+	if err := node.Encode(stringifyValues(normalizeDefined(v))); err != nil {
+		return ConfigSource{}, errors.Wrapf(err, "failed to encode %s", loc.Name)
 	}
-	if cs == nil {
+	setNodeLocation(&node, loc.Location)
+	return ConfigSource{Config: &node, Filename: loc.Name, IncludeChain: loc.Includes()}, nil
+}
+
+// stringifyValues replaces the values in the Normalized value v that yaml
+// cannot encode directly with their string form, see stringValue.
+func stringifyValues(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, elem := range t {
+			t[k] = stringifyValues(elem)
+		}
+	case []any:
+		for i, elem := range t {
+			t[i] = stringifyValues(elem)
+		}
+	default:
+		if s, ok := stringValue(v); ok {
+			return s
+		}
+	}
+	return v
+}
+
+// setNodeLocation sets the line and column of node and all of its content
+// to loc, or clears them when loc is nil.
+func setNodeLocation(node *yaml.Node, loc *FileCoordinate) {
+	node.Line, node.Column = 0, 0
+	if loc != nil {
+		node.Line, node.Column = loc.Line, loc.Column
+	}
+	for _, child := range node.Content {
+		setNodeLocation(child, loc)
+	}
+}
+
+func (f *FigTree) LoadAllConfigSources(sources []ConfigSource, options interface{}) error {
+	return f.LoadAllConfigSourcesWithMerger(f.NewMerger(), sources, options)
+}
+
+// LoadAllConfigSourcesWithMerger is like LoadAllConfigSources but uses m to
+// merge the sources.  The Merger retains the fields overwritten by each
+// source, so reusing one Merger for multiple calls gives the sources in
+// later calls the same overwrite semantics as if they were lower precedence
+// sources in the first call.  Use Merger.Reset to start over.  The overrides
+// and flags are merged in each call.
+func (f *FigTree) LoadAllConfigSourcesWithMerger(m *Merger, sources []ConfigSource, options interface{}) error {
+	filterOut := f.filterOut
+	if filterOut == nil {
+		filterOut = defaultFilterOut(f)
+	}
+
+	// overrides are always merged first so they take precedence over
+	// all other sources, and they are never filtered out.
+	if err := f.loadValues(m, overrideSource, f.overrides, options); err != nil {
+		return err
+	}
+	if err := f.loadFlags(m, options); err != nil {
+		return err
+	}
+	if err := f.loadEnvOverrides(m, options); err != nil {
+		return err
+	}
+
+	sources = sortSourcesByPriority(sources)
+	loading := []ConfigSource{}
+	skipped := map[string]bool{}
+	home := -1
+	for _, source := range sources {
+		// automatically skip empty configs
+		if source.Config == nil || source.Config.IsZero() {
+			continue
+		}
+		if len(source.IncludeChain) > 0 {
+			// included sources are loaded along with the file that
+			// included them, regardless of the stop pragma
+			if skipped[source.IncludeChain[0]] {
+				continue
+			}
+		} else if filterOut(source.Config) {
+			skipped[source.Filename] = true
+			continue
+		}
+		if err := CheckIncludeCycle(source.IncludeChain, source.Filename); err != nil {
+			return err
+		}
+		if f.homeFirst && source.home && home < 0 {
+			home = len(loading)
+		}
+		loading = append(loading, source)
+	}
+
+	var homeKeys [][]string
+	if home >= 0 {
+		homeKeys = f.homeFirstKeys(options)
+	}
+	homeLoaded := false
+	for i, source := range loading {
+		if home >= 0 && !homeLoaded && source.Priority <= loading[home].Priority {
+			// merge the home config for the home-first keys before any
+			// other source with the same priority
+			if err := f.loadHomeFirst(m, loading[home], homeKeys, options); err != nil {
+				return err
+			}
+			homeLoaded = true
+		}
+		if i == home {
+			if homeKeys == nil {
+				// all keys were merged with the home-first precedence
+				continue
+			}
+			// the rest of the keys are merged with the default precedence
+			m.skipKeys = homeKeys
+		}
+		err := f.loadSource(m, source, options)
+		m.skipKeys = nil
+		if err != nil {
+			return err
+		}
+		m.Advance()
+	}
+	if err := f.loadDefaultsProfiles(m, options); err != nil {
+		return err
+	}
+	if err := f.loadTagDefaults(m, options); err != nil {
+		return err
+	}
+	if err := CheckConstraints(options); err != nil {
+		return err
+	}
+	f.usage.track(options)
+	return nil
+}
+
+// loadValues will merge values into options with the given source name.
+func (f *FigTree) loadValues(m *Merger, source string, values map[string]any, options interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+	var node yaml.Node
+	if err := node.Encode(values); err != nil {
+		return errors.Wrapf(err, "failed to encode %s values", source)
+	}
+	m.sourceFile = source
+	if err := f.loadConfigSource(m, &node, options); err != nil {
+		return err
+	}
+	m.Advance()
+	return nil
+}
+
+// loadFlags will merge the values from the FlagProvider for each of the
+// flag keys, each key is merged as a separate `flag:<key>` source.
+func (f *FigTree) loadFlags(m *Merger, options interface{}) error {
+	if f.flagProvider == nil {
+		return nil
+	}
+	for _, key := range f.flagKeys {
+		value, ok, err := f.flagProvider.Flag(key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get value for flag %q", key)
+		}
+		if !ok {
+			continue
+		}
+		path := []setPathElem{}
+		for _, part := range strings.Split(key, ".") {
+			path = append(path, setPathElem{key: part})
+		}
+		values, err := setPathValue(nil, path, value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to set value for flag %q", key)
+		}
+		if err := f.loadValues(m, flagSourcePrefix+key, values.(map[string]any), options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadEnvOverrides will merge the options found in the environment when
+// WithEnvOverrides is used, each variable is merged as a separate
+// `env:<NAME>` source.
+func (f *FigTree) loadEnvOverrides(m *Merger, options interface{}) error {
+	if !f.envOverrides {
+		return nil
+	}
+	type envValue struct {
+		name, key, value string
+		option           reflect.Value
+	}
+	found := []envValue{}
+	f.envFields(options, func(envName, key string, value reflect.Value) {
+		if v := f.getenv(envName); v != "" && !f.exported.isExported(envName, v) {
+			found = append(found, envValue{envName, key, v, value})
+		}
+	})
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].name < found[j].name
+	})
+	for _, env := range found {
+		elements, ok, err := parseEnvElements(env.option, env.value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse environment variable %s", env.name)
+		}
+		if ok {
+			// each element is merged as a separate source so the
+			// element sources are like `env:NAME[2]`
+			for _, elem := range elements {
+				source := fmt.Sprintf("%s%s[%s]", envSourcePrefix, env.name, elem.index)
+				if err := f.loadValues(m, source, map[string]any{env.key: elem.value}, options); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		value, err := parseEnvValue(env.option, env.value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse environment variable %s", env.name)
+		}
+		if err := f.loadValues(m, envSourcePrefix+env.name, map[string]any{env.key: value}, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEnvValue returns the value of the environment variable s for the
+// option v.  Lists, maps and structs, which PopulateEnv serializes as JSON,
+// are parsed as YAML, scalars are converted from the string to the option
+// type.
+func parseEnvValue(v reflect.Value, s string) (any, error) {
+	v = uninterface(indirect(v))
+	if option := toOption(v); option != nil {
+		v = reflect.ValueOf(option.GetValue())
+	}
+	if !v.IsValid() {
+		return s, nil
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		var value any
+		if err := yaml.Unmarshal([]byte(s), &value); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		value, _ = unwrapEnvOptions(value)
+		return value, nil
+	}
+	value := reflect.New(v.Type())
+	if err := convertString(s, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+// unwrapEnvOptions replaces the Options within value, which PopulateEnv
+// serializes as JSON objects like `{"Value":1,"Source":"...","Defined":true}`,
+// with the Option values.  It returns false for undefined Options, which are
+// dropped from lists and maps.
+func unwrapEnvOptions(value any) (any, bool) {
+	switch t := value.(type) {
+	case map[string]any:
+		if _, ok := t["Value"]; ok {
+			defined, ok := t["Defined"].(bool)
+			_, hasSource := t["Source"]
+			if ok && (len(t) == 2 || hasSource && len(t) == 3) {
+				if !defined {
+					return nil, false
+				}
+				return unwrapEnvOptions(t["Value"])
+			}
+		}
+		for k, v := range t {
+			if v, ok := unwrapEnvOptions(v); ok {
+				t[k] = v
+			} else {
+				delete(t, k)
+			}
+		}
+	case []any:
+		items := t[:0]
+		for _, v := range t {
+			if v, ok := unwrapEnvOptions(v); ok {
+				items = append(items, v)
+			}
+		}
+		return items, true
+	}
+	return value, true
+}
+
+// envElement is an element of a list or map parsed from an environment
+// variable, value is a single element list or map.
+type envElement struct {
+	index string
+	value any
+}
+
+// parseEnvElements parses comma separated lists, like `a,b,c`, and maps,
+// like `k1=v1,k2=v2`, from the environment variable s for list and map
+// options.  Commas in values can be escaped as `\,`.  Each value is
+// converted to the element type of the option.  It returns false for other
+// options, and for values that are YAML or JSON lists or maps, including the
+// YAML block forms like "- a\n- b".
+func parseEnvElements(v reflect.Value, s string) ([]envElement, bool, error) {
+	v = uninterface(indirect(v))
+	if option := toOption(v); option != nil {
+		v = reflect.ValueOf(option.GetValue())
+	}
+	if !v.IsValid() {
+		return nil, false, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &doc); err == nil {
+		if node := walky.UnwrapDocument(&doc); node != nil && (node.Kind == yaml.SequenceNode || node.Kind == yaml.MappingNode) {
+			return nil, false, nil
+		}
+	}
+	elements := []envElement{}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i, item := range splitEscaped(s, ',') {
+			value, err := parseEnvElement(v.Type().Elem(), item)
+			if err != nil {
+				return nil, false, errors.Wrapf(err, "invalid element %d", i)
+			}
+			elements = append(elements, envElement{strconv.Itoa(i), []any{value}})
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false, nil
+		}
+		for _, item := range splitEscaped(s, ',') {
+			key, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return nil, false, errors.Errorf("expected KEY=VALUE got %q", item)
+			}
+			value, err := parseEnvElement(v.Type().Elem(), val)
+			if err != nil {
+				return nil, false, errors.Wrapf(err, "invalid value for %s", key)
+			}
+			elements = append(elements, envElement{key, map[string]any{key: value}})
+		}
+	default:
+		return nil, false, nil
+	}
+	return elements, true, nil
+}
+
+// parseEnvElement converts s to the element type typ, for Option elements
+// s is converted to the type of the Option value.
+func parseEnvElement(typ reflect.Type, s string) (any, error) {
+	if isOptionType(typ) {
+		if field, ok := typ.FieldByName("Value"); ok {
+			typ = field.Type
+		}
+	}
+	if typ.Kind() == reflect.Interface {
+		return s, nil
+	}
+	value := reflect.New(typ)
+	if err := convertString(s, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+func (f *FigTree) LoadConfigSource(config *yaml.Node, source string, options interface{}) error {
+	m := f.newMerger(WithSourceFile(source))
+	return f.loadConfigSource(m, config, options)
+}
+
+// LoadConfigSourceWithMerger merges a single config document named source
+// into options using m.  Unlike LoadConfigSource the overwrite state in m is
+// retained, so loaders that feed documents incrementally, like watchers or
+// remote streams, get the same overwrite semantics as LoadAllConfigSources
+// as long as they call m.Advance after each document.
+func (f *FigTree) LoadConfigSourceWithMerger(m *Merger, config *yaml.Node, source string, options interface{}) error {
+	m.sourceFile = source
+	m.includeChain = nil
+	return f.loadConfigSource(m, config, options)
+}
+
+// NewMerger returns a Merger using the merge options from the FigTree, see
+// LoadAllConfigSourcesWithMerger.
+func (f *FigTree) NewMerger(options ...MergeOption) *Merger {
+	return f.newMerger(options...)
+}
+
+func (f *FigTree) newMerger(options ...MergeOption) *Merger {
+	options = append(append([]MergeOption{}, f.mergeOptions...), options...)
+	if f.deepCopy {
+		options = append(options, DeepCopyValues())
+	}
+	if f.logger != nil {
+		options = append(options, WithMergeLogger(f.logger))
+	}
+	return NewMerger(options...)
+}
+
+func sourceLine(file string, node *yaml.Node) string {
+	if node.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d", file, node.Line, node.Column)
+	}
+	return file
+}
+
+func (f *FigTree) loadConfigSource(m *Merger, config *yaml.Node, options interface{}) error {
+	if !reflect.ValueOf(options).IsValid() {
+		return errors.Errorf("options argument [%#v] is not valid", options)
+	}
+
+	var err error
+	if f.preProcessor != nil {
+		err = f.preProcessor(config)
+		if err != nil {
+			return errors.Wrapf(err, "failed to process config file %s", sourceLine(m.sourceFile, config))
+		}
+	}
+	config = f.applyKeyAliases(m, config)
+	config, err = f.filterValidity(m.sourceFile, config)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		f.log().Debugf("Skipping config %s, it is not valid at this time", m.sourceFile)
+		return nil
+	}
+	if ok, err := f.matches(m.sourceFile, config); err != nil {
+		return err
+	} else if !ok {
+		f.log().Debugf("Skipping config %s, it does not match the match context", m.sourceFile)
+		return nil
+	}
+	config, err = f.decryptValues(m, config)
+	if err != nil {
+		return err
+	}
+
+	err = config.Decode(m)
+	if err != nil {
+		return errors.WithStack(walky.ErrFilename(err, m.sourceFile))
+	}
+
+	if m.untyped {
+		config = untypedScalars(config, map[*yaml.Node]*yaml.Node{})
+	}
+
+	profile, root, err := f.profileSection(m.sourceFile, walky.UnwrapDocument(config))
+	if err != nil {
+		return err
+	}
+	command, root, err := f.commandSection(m.sourceFile, root)
+	if err != nil {
+		return err
+	}
+	if f.extends {
+		root = withoutKey(root, "extends")
+	}
+	// the profile and command sections take precedence over the rest of
+	// the document
+	for _, section := range []*yaml.Node{profile, command} {
+		if section == nil {
+			continue
+		}
+		_, err = m.mergeStructs(reflect.ValueOf(options), newMergeSource(section), false)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = m.mergeStructs(
+		reflect.ValueOf(options),
+		newMergeSource(root),
+		false,
+	)
+	if err != nil {
+		return err
+	}
+	changeSet := f.PopulateEnv(options)
+	return f.exportEnv(changeSet)
+}
+
+func (f *FigTree) LoadConfig(file string, options interface{}) error {
+	return f.LoadConfigContext(context.Background(), file, options)
+}
+
+// LoadConfigContext is like LoadConfig but stops loading when ctx is done,
+// killing the config file if it is executable and still running.
+func (f *FigTree) LoadConfigContext(ctx context.Context, file string, options interface{}) error {
+	sources, err := f.ReadFileDocuments(ctx, file)
+	if err != nil {
+		return err
+	}
+	if sources == nil {
 		// no file contents to parse, file likely does not exist
+		if f.requireConfig {
+			return errors.WithStack(ConfigNotFoundError{File: file, SearchPath: []string{file}})
+		}
 		return nil
 	}
-	return f.LoadConfigSource(cs.Config, cs.Filename, options)
+	m := f.newMerger()
+	for _, cs := range sources {
+		if err := f.LoadConfigSourceWithMerger(m, cs.Config, cs.Filename, options); err != nil {
+			return err
+		}
+		m.Advance()
+	}
+	return nil
 }
 
 // ReadFile will return a ConfigSource for given file path.  If the
 // file is executable (and WithoutExec was not used), it will execute
 // the file and return the stdout otherwise it will return the file
-// contents directly.
+// contents directly.  Files are decoded with the ConfigDecoder for
+// their extension (see WithDecoder), .json files as JSON, all others as YAML.
+// Only the first document of a multi-document YAML file is returned, see
+// ReadFileDocuments.
 func (f *FigTree) ReadFile(file string) (*ConfigSource, error) {
+	return f.ReadFileContext(context.Background(), file)
+}
+
+// ReadFileContext is like ReadFile but an executable config file is killed
+// if ctx is done before it exits.
+func (f *FigTree) ReadFileContext(ctx context.Context, file string) (*ConfigSource, error) {
+	sources, err := f.ReadFileDocuments(ctx, file)
+	if err != nil || len(sources) == 0 {
+		return nil, err
+	}
+	return &sources[0], nil
+}
+
+// ReadFileDocuments is like ReadFileContext but returns a ConfigSource for
+// each document of a multi-document YAML file, separated by `---`, in the
+// order of the documents.  The sources all have the file name, so option
+// locations are the line and column within the file.  When the sources are
+// loaded each document takes precedence over the documents that follow it,
+// like config files found in a nearer directory.  A nil slice is returned if
+// the file does not exist.
+func (f *FigTree) ReadFileDocuments(ctx context.Context, file string) ([]ConfigSource, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
 	absFile := file
 	if !filepath.IsAbs(file) {
 		absFile = filepath.Clean(filepath.Join(f.workDir, file))
 	}
-	rel, err := filepath.Rel(f.workDir, absFile)
+	rel := f.sourceName(file, absFile)
+	stat, err := f.stat(absFile)
 	if err != nil {
-		rel = file
+		return nil, nil
 	}
-	var node yaml.Node
-	if stat, err := os.Stat(absFile); err == nil {
-		if stat.Mode()&0o111 == 0 || !f.exec {
-			Log.Debugf("Reading config %s", absFile)
-			fh, err := os.Open(absFile)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to open %s", rel)
+	var version *FileVersion
+	var nodes []*yaml.Node
+	if stat.Mode()&0o111 == 0 || !f.exec || f.fsys != nil {
+		f.log().Debugf("Reading config %s", absFile)
+		data, err := f.readFile(absFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", rel)
+		}
+		version = newFileVersion(absFile, stat, data, f.stat)
+		if decoder := f.decoder(absFile); decoder != nil {
+			var node yaml.Node
+			if err := decoder.Decode(file, data, &node); err != nil {
+				return nil, err
 			}
-			defer fh.Close()
-			decoder := yaml.NewDecoder(fh)
-			if err := decoder.Decode(&node); err != nil && !errors.Is(err, io.EOF) {
+			nodes = []*yaml.Node{&node}
+		} else {
+			nodes, err = decodeDocuments(data)
+			if err != nil {
 				return nil, errors.WithStack(walky.ErrFilename(err, file))
 			}
-		} else {
-			Log.Debugf("Found Executable Config file: %s", absFile)
-			// it is executable, so run it and try to parse the output
-			cmd := exec.Command(absFile)
-			stdout := bytes.NewBufferString("")
-			cmd.Stdout = stdout
-			cmd.Stderr = bytes.NewBufferString("")
-			if err := cmd.Run(); err != nil {
-				return nil, errors.Wrapf(err, "%s is executable, but it failed to execute:\n%s", file, cmd.Stderr)
-			}
-			rel += "[stdout]"
-			if err := yaml.Unmarshal(stdout.Bytes(), &node); err != nil {
-				return nil, err
+		}
+	} else if !f.execAllowed(absFile) {
+		f.log().Debugf("Skipping Executable Config file: %s, exec is not allowed in %s", absFile, filepath.Dir(absFile))
+		return nil, nil
+	} else {
+		f.log().Debugf("Found Executable Config file: %s", absFile)
+		data, err := os.ReadFile(absFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", rel)
+		}
+		version = newFileVersion(absFile, stat, data, f.stat)
+		// it is executable, so run it and try to parse the output
+		cmd := exec.Command(absFile)
+		cmd.Dir = f.workDir
+		cmd.Env = f.execEnv()
+		stdout := bytes.NewBufferString("")
+		cmd.Stdout = stdout
+		cmd.Stderr = bytes.NewBufferString("")
+		if err := runContext(ctx, cmd); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, errors.Wrapf(ctxErr, "%s is executable, but it did not complete", file)
 			}
+			return nil, errors.Wrapf(err, "%s is executable, but it failed to execute:\n%s", file, cmd.Stderr)
+		}
+		rel += "[stdout]"
+		nodes, err = decodeDocuments(stdout.Bytes())
+		if err != nil {
+			return nil, err
 		}
-		return &ConfigSource{
-			Config:   &node,
+	}
+	sources := make([]ConfigSource, 0, len(nodes))
+	for _, node := range nodes {
+		cs := ConfigSource{
+			Config:   node,
 			Filename: rel,
-		}, nil
+			Version:  version,
+			home:     f.isHomeConfig(absFile),
+		}
+		if f.sourceMetadata != nil {
+			f.sourceMetadata(absFile, &cs)
+		}
+		sources = append(sources, cs)
+	}
+	return sources, nil
+}
+
+// runContext runs cmd in its own process group, the whole group is killed
+// when ctx is done, so children of cmd that hold its output open do not
+// delay the load.
+func runContext(ctx context.Context, cmd *exec.Cmd) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
 	}
-	return nil, nil
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+	return cmd.Wait()
+}
+
+// readFileSources returns the sources for each document of file, each
+// followed by the sources it includes, see readIncludes.  The documents of
+// the WithProfile profile file come first.  A nil slice is
+// returned if the file does not exist.
+func (f *FigTree) readFileSources(ctx context.Context, file string) ([]ConfigSource, error) {
+	docs, err := f.ReadFileDocuments(ctx, file)
+	if err != nil || docs == nil {
+		return nil, err
+	}
+	if f.profile != "" {
+		// the profile file takes precedence over the file
+		profileDocs, err := f.ReadFileDocuments(ctx, profileFileName(file, f.profile))
+		if err != nil {
+			return nil, err
+		}
+		docs = append(profileDocs, docs...)
+	}
+	sources := []ConfigSource{}
+	for _, doc := range docs {
+		included, err := f.readIncludes(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, doc)
+		sources = append(sources, included...)
+	}
+	return sources, nil
+}
+
+// decodeDocuments decodes each yaml document in data.  An empty node is
+// returned for empty data.
+func decodeDocuments(data []byte) ([]*yaml.Node, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	nodes := []*yaml.Node{}
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		nodes = append(nodes, &node)
+	}
+	if len(nodes) == 0 {
+		nodes = append(nodes, &yaml.Node{})
+	}
+	return nodes, nil
+}
+
+// sortSourcesByPriority returns the sources ordered by descending Priority,
+// sources with the same priority retain their order.
+func sortSourcesByPriority(sources []ConfigSource) []ConfigSource {
+	sorted := append([]ConfigSource{}, sources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return sorted
+}
+
+// resolveExecAllowedDirs makes the WithExecAllowedDirs dirs absolute,
+// relative to the working directory.
+func (f *FigTree) resolveExecAllowedDirs() {
+	for i, dir := range f.execAllowedDirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(f.workDir, dir)
+		}
+		f.execAllowedDirs[i] = filepath.Clean(dir)
+	}
+}
+
+// execAllowed returns true if the executable config absFile may be
+// executed, see WithExecAllowedDirs.
+func (f *FigTree) execAllowed(absFile string) bool {
+	if f.execAllowedDirs == nil {
+		return true
+	}
+	dir := filepath.Dir(absFile)
+	for _, allowed := range f.execAllowedDirs {
+		if allowed == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// searchFileNames returns the file names probed for configFile in each
+// directory searched, in precedence order, see WithConfigDir and
+// WithExtensions.
+func (f *FigTree) searchFileNames(configFile string) []string {
+	if f.configDir == "" {
+		return f.configFileNames(configFile)
+	}
+	fileNames := f.configFileNames(path.Join(f.configDir, configFile))
+	if f.configDirMode == ConfigDirFirst && !filepath.IsAbs(f.configDir) {
+		fileNames = append(fileNames, f.configFileNames(configFile)...)
+	}
+	return fileNames
+}
+
+// configFileNames returns the candidate file names for configFile in order
+// of preference.  If configFile already has an extension it is used as-is,
+// otherwise one name is returned for each of the configured extensions.
+func (f *FigTree) configFileNames(configFile string) []string {
+	if filepath.Ext(configFile) != "" || len(f.extensions) == 0 {
+		return []string{configFile}
+	}
+	names := make([]string, 0, len(f.extensions))
+	for _, ext := range f.extensions {
+		names = append(names, configFile+"."+ext)
+	}
+	return names
+}
+
+// statFunc returns the file info for the file name, like os.Stat.
+type statFunc func(name string) (os.FileInfo, error)
+
+// fsPath returns the path in the WithFS file system for the absolute file
+// path name.
+func fsPath(name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// stat returns the file info for the file name from the WithFS file system,
+// or the operating system.
+func (f *FigTree) stat(name string) (os.FileInfo, error) {
+	if f.fsys != nil {
+		return fs.Stat(f.fsys, fsPath(name))
+	}
+	return os.Stat(name)
+}
+
+// readFile returns the contents of the file name from the WithFS file
+// system, or the operating system.
+func (f *FigTree) readFile(name string) ([]byte, error) {
+	if f.fsys != nil {
+		return fs.ReadFile(f.fsys, fsPath(name))
+	}
+	return os.ReadFile(name)
+}
+
+// firstExisting returns the path of the first of fileNames that exists in
+// dir, or an empty string if none exist.
+func firstExisting(stat statFunc, dir string, fileNames []string) string {
+	for _, fileName := range fileNames {
+		file := path.Join(dir, fileName)
+		if _, err := stat(file); err == nil {
+			return filepath.FromSlash(file)
+		}
+	}
+	return ""
+}
+
+// sourceName returns the name used for the config file in the
+// ConfigSource Filename and the option SourceLocation according to
+// the SourceNameStyle.
+func (f *FigTree) sourceName(file, absFile string) string {
+	switch f.sourceNames {
+	case SourceNameAbsolute:
+		return absFile
+	case SourceNameBasename:
+		return filepath.Base(absFile)
+	case SourceNameRelativeToHome:
+		if f.home != "" && isSubPath(f.home, absFile) {
+			rel, _ := filepath.Rel(f.home, absFile)
+			return filepath.Join("~", rel)
+		}
+		return absFile
+	}
+	rel, err := filepath.Rel(f.workDir, absFile)
+	if err != nil {
+		return file
+	}
+	return rel
 }
 
 func FindParentPaths(homedir, cwd, fileName string) []string {
+	return findParentPaths(os.Stat, homedir, cwd, []string{fileName})
+}
+
+// findParentPaths is like FindParentPaths but at each directory level it
+// will look for the first existing file from fileNames.
+func findParentPaths(stat statFunc, homedir, cwd string, fileNames []string) []string {
 	paths := make([]string, 0)
-	if filepath.IsAbs(fileName) {
+	if len(fileNames) > 0 && filepath.IsAbs(fileNames[0]) {
 		// dont recursively look for files when fileName is an abspath
-		_, err := os.Stat(fileName)
-		if err == nil {
-			paths = append(paths, fileName)
+		for _, fileName := range fileNames {
+			if _, err := stat(fileName); err == nil {
+				paths = append(paths, fileName)
+				break
+			}
 		}
 		return paths
 	}
 
 	// special case if homedir is not in current path then check there anyway
-	if homedir != "" && !strings.HasPrefix(cwd, homedir) {
-		file := path.Join(homedir, fileName)
-		if _, err := os.Stat(file); err == nil {
-			paths = append(paths, filepath.FromSlash(file))
+	if homedir != "" && !isSubPath(homedir, cwd) {
+		if file := firstExisting(stat, homedir, fileNames); file != "" {
+			paths = append(paths, file)
 		}
 	}
 
@@ -377,16 +1802,42 @@ func FindParentPaths(homedir, cwd, fileName string) []string {
 		} else {
 			dir = path.Join(dir, part)
 		}
-		file := path.Join(dir, fileName)
-		if _, err := os.Stat(file); err == nil {
-			paths = append(paths, filepath.FromSlash(file))
+		if file := firstExisting(stat, dir, fileNames); file != "" {
+			paths = append(paths, file)
+		}
+	}
+	return paths
+}
+
+// findHomeAndCwdPaths is like findParentPaths but only looks for the config
+// files in homedir and cwd, the parent directories of cwd are not searched.
+func findHomeAndCwdPaths(stat statFunc, homedir, cwd string, fileNames []string) []string {
+	if len(fileNames) > 0 && filepath.IsAbs(fileNames[0]) {
+		return findParentPaths(stat, homedir, cwd, fileNames)
+	}
+	paths := make([]string, 0)
+	if homedir != "" && homedir != cwd {
+		if file := firstExisting(stat, homedir, fileNames); file != "" {
+			paths = append(paths, file)
 		}
 	}
+	if file := firstExisting(stat, cwd, fileNames); file != "" {
+		paths = append(paths, file)
+	}
 	return paths
 }
 
+// isSubPath returns true if path is dir or is within dir.
+func isSubPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func (f *FigTree) FindParentPaths(fileName string) []string {
-	return FindParentPaths(f.home, f.workDir, fileName)
+	return findParentPaths(f.stat, f.home, f.workDir, []string{fileName})
 }
 
 var camelCaseWords = regexp.MustCompile("[0-9A-Za-z]+")
@@ -404,6 +1855,72 @@ type Merger struct {
 	preserveMap map[string]struct{}
 	Config      ConfigOptions `json:"config,omitempty" yaml:"config,omitempty"`
 	ignore      []string
+	deepCopy    bool
+	logger      Logger
+	// includeChain is the include chain for the current sourceFile
+	includeChain []string
+	// preserveAnchors retains yaml aliases when assigning to yaml.Node
+	// values, see PreserveAnchors
+	preserveAnchors bool
+	// secrets are the values decrypted from `!age` values by location
+	secrets map[secretKey]struct{}
+	// ignoreKeys are key patterns that will not be merged, see
+	// WithIgnoreKeys
+	ignoreKeys [][]string
+	// onlyKeys, when not nil, are the only key paths that will be merged,
+	// and skipKeys are key paths that will not be merged, see WithHomeFirst
+	onlyKeys [][]string
+	skipKeys [][]string
+	// refreshKeys, when not nil, are the only key paths that will be
+	// merged, see RefreshStale
+	refreshKeys [][]string
+	// keyPath is the path of keys to the value currently being merged
+	keyPath []string
+	// setRemovals tracks the values removed from `merge=set` lists so
+	// they are not added back by lower precedence sources.
+	setRemovals map[setKey]map[string]struct{}
+	// advanceHooks are called at the end of each document, see
+	// WithAdvanceHook
+	advanceHooks []AdvanceHook
+	// decimalComma allows strings like "1,5" to be assigned to floats, see
+	// WithDecimalComma
+	decimalComma bool
+	// untyped makes all scalars from config files strings, see
+	// WithoutImplicitTyping
+	untyped bool
+	// strict makes keys that do not match any struct field errors, see
+	// WithStrictDecode
+	strict bool
+	// transforms are the names of the transforms for the current field,
+	// transformed is set when one ran, see RegisterTransform
+	transforms  []string
+	transformed bool
+	// recorder is called for each change to the options, recorded are the
+	// key paths with an event for the current document, see
+	// WithMergeRecorder
+	recorder MergeRecorder
+	recorded map[string]bool
+	// anyElements controls the elements added to []any lists, see
+	// WithAnyListElements
+	anyElements AnyListElements
+	// nameTags adds figtree name tags to struct fields created from map
+	// keys, see WithNameTags
+	nameTags bool
+	// provenance is the source of each value assigned, by key path, see
+	// Provenance
+	provenance map[string]SourceLocation
+	// locks are the key paths locked by previous documents with the lock
+	// config pragma
+	locks []valueLock
+	// present is the key paths that have a value in any source merged, so
+	// tag defaults do not replace explicit zero values
+	present map[string]struct{}
+}
+
+// valueLock is a key path pattern locked by source.
+type valueLock struct {
+	keyPath []string
+	source  string
 }
 
 type MergeOption func(*Merger)
@@ -422,6 +1939,143 @@ func PreserveMap(keys ...string) MergeOption {
 	}
 }
 
+// DeepCopyValues will ensure all values assigned during the merge are deep
+// copies of the source values, so the merged result does not share any maps,
+// slices or pointers with the sources.
+func DeepCopyValues() MergeOption {
+	return func(m *Merger) {
+		m.deepCopy = true
+	}
+}
+
+// PreserveAnchors will retain yaml aliases when merging into yaml.Node
+// values, rather than replacing each alias with a copy of the anchored node.
+// When the merged nodes are serialized the output will then use the original
+// anchors and aliases.  Values that are not yaml.Nodes are always expanded.
+func PreserveAnchors() MergeOption {
+	return func(m *Merger) {
+		m.preserveAnchors = true
+	}
+}
+
+// WithNameTags makes MakeMergeStruct add a `figtree:",name=..."` tag to the
+// struct fields created from map keys, recording the canonical name chosen
+// for the field.  The generated structs will then keep the same field
+// identity when merged again, see CanonicalFieldName.
+func WithNameTags() MergeOption {
+	return func(m *Merger) {
+		m.nameTags = true
+	}
+}
+
+// WithDecimalComma allows string values to be assigned to float options,
+// accepting a comma as the decimal separator, like "1,5", as well as the
+// usual "1.5".  Without this option strings cannot be assigned to floats.
+func WithDecimalComma() MergeOption {
+	return func(m *Merger) {
+		m.decimalComma = true
+	}
+}
+
+// WithoutImplicitTyping disables the implicit typing of yaml scalars, so
+// all scalars from config files are strings until they are converted for
+// the type of the option they are assigned to.  Values like `no`, `012` or
+// `1.10` are then retained as written when assigned to strings or `any`,
+// and integers are always parsed as decimal.  Scalars with an explicit tag,
+// like `!!int 12`, and null values are still typed by yaml.
+func WithoutImplicitTyping() MergeOption {
+	return func(m *Merger) {
+		m.untyped = true
+	}
+}
+
+// WithStrictDecode makes keys in config files that do not correspond to any
+// struct field errors, reported at the file, line and column of the key, so
+// typos like `stirng1: value` are not silently ignored.  The `config`
+// pragma key at the top of the document and keys ignored by the merger are
+// still allowed, and maps and `any` values accept any key.
+func WithStrictDecode() MergeOption {
+	return func(m *Merger) {
+		m.strict = true
+	}
+}
+
+// AnyListElements controls the type of the elements merged into []any
+// lists, see WithAnyListElements.
+type AnyListElements int
+
+const (
+	// AnyListElementsAsIs adds elements as they are found in the source,
+	// so Options from structs remain Options while values from config files
+	// are plain values.  This is the default.
+	AnyListElementsAsIs AnyListElements = iota
+	// AnyListElementsUnwrapped adds the value of Options rather than the
+	// Option, so all elements are plain values.
+	AnyListElementsUnwrapped
+	// AnyListElementsWrapped adds all elements as Option[any], with the
+	// source of the element.
+	AnyListElementsWrapped
+)
+
+// WithAnyListElements sets how elements are added when merging into []any
+// lists, so consumers iterating the lists get a predictable element type.
+func WithAnyListElements(mode AnyListElements) MergeOption {
+	return func(m *Merger) {
+		m.anyElements = mode
+	}
+}
+
+// normalizeAnyElement converts the []any element elem according to the
+// WithAnyListElements mode, coord is the location of the element source.
+func (m *Merger) normalizeAnyElement(elem reflect.Value, coord *FileCoordinate) {
+	if m.anyElements == AnyListElementsAsIs || elem.IsNil() {
+		return
+	}
+	option := toOption(elem.Elem())
+	switch m.anyElements {
+	case AnyListElementsUnwrapped:
+		if option != nil {
+			elem.Set(reflect.ValueOf(option.GetValue()))
+		}
+	case AnyListElementsWrapped:
+		if _, ok := elem.Interface().(Option[any]); ok {
+			return
+		}
+		wrapped := Option[any]{
+			Source:  m.newSource(coord),
+			Defined: true,
+			Value:   elem.Interface(),
+		}
+		if option != nil {
+			wrapped.Source = option.GetSource()
+			wrapped.Defined = option.IsDefined()
+			wrapped.Value = option.GetValue()
+		}
+		elem.Set(reflect.ValueOf(wrapped))
+	}
+}
+
+// WithMergeLogger sets the Logger used while merging, otherwise the global
+// Log is used.
+func WithMergeLogger(logger Logger) MergeOption {
+	return func(m *Merger) {
+		m.logger = logger
+	}
+}
+
+// WithIgnoreKeys will skip merging any keys matching the patterns.  Patterns
+// are dot separated key paths, like `credentials.token`, where each path
+// element is matched with path.Match, so `credentials.*` will ignore every
+// key under `credentials`.  Ignoring a key also ignores all the keys nested
+// under it.
+func WithIgnoreKeys(patterns ...string) MergeOption {
+	return func(m *Merger) {
+		for _, pattern := range patterns {
+			m.ignoreKeys = append(m.ignoreKeys, strings.Split(pattern, "."))
+		}
+	}
+}
+
 func NewMerger(options ...MergeOption) *Merger {
 	m := &Merger{
 		sourceFile:  "merge",
@@ -430,15 +2084,148 @@ func NewMerger(options ...MergeOption) *Merger {
 	for _, opt := range options {
 		opt(m)
 	}
-	return m
+	return m
+}
+
+// Reset clears the state retained by the Merger between sources, like the
+// fields that have been overwritten, so it can be reused as if it was new.
+func (m *Merger) Reset() {
+	m.Config = ConfigOptions{}
+	m.ignore = nil
+	m.setRemovals = nil
+	m.secrets = nil
+	m.keyPath = nil
+	m.includeChain = nil
+	m.provenance = nil
+	m.locks = nil
+	m.recorded = nil
+	m.present = nil
+}
+
+// Provenance returns the source of the value assigned to each key path,
+// like `map1.key2`, by the Merger, including values assigned to fields that
+// are not Options.  The first source to assign a value is reported, unless
+// the value was overwritten by a later source.  Items in lists are reported
+// with the path of the list, and the source is the first that added items.
+func (m *Merger) Provenance() map[string]SourceLocation {
+	provenance := make(map[string]SourceLocation, len(m.provenance))
+	for k, v := range m.provenance {
+		provenance[k] = v
+	}
+	return provenance
+}
+
+// recordProvenance records the source of the value assigned at the current
+// key path.  Values assigned to a key path that already has a value are
+// list items, unless they were overwritten.
+func (m *Merger) recordProvenance(src mergeSource, coord *FileCoordinate, opts assignOptions) {
+	if len(m.keyPath) == 0 {
+		return
+	}
+	key := strings.Join(m.keyPath, ".")
+	source := m.valueSource(src, coord, opts)
+	previous, exists := m.provenance[key]
+	if exists && !opts.Overwrite {
+		m.recordChange(MergeEvent{Key: key, Change: MergeChangeAppended, Source: source})
+		return
+	}
+	if exists {
+		m.recordChange(MergeEvent{Key: key, Change: MergeChangeOverridden, Source: source, Previous: &previous})
+	} else {
+		m.recordChange(MergeEvent{Key: key, Change: MergeChangeSet, Source: source})
+	}
+	if m.provenance == nil {
+		m.provenance = map[string]SourceLocation{}
+	}
+	m.provenance[key] = source
+}
+
+// markPresent records that src has a value for the current key path.
+func (m *Merger) markPresent(src mergeSource) {
+	if src.isZero() || src.node != nil && src.node.Tag == "!!null" {
+		return
+	}
+	if m.present == nil {
+		m.present = map[string]struct{}{}
+	}
+	m.present[strings.Join(m.keyPath, ".")] = struct{}{}
+}
+
+// isPresent returns true if any source merged had a value for the key path.
+func (m *Merger) isPresent(keyPath []string) bool {
+	_, ok := m.present[strings.Join(keyPath, ".")]
+	return ok
+}
+
+// recordShadowed records a MergeChangeShadowed event when the src value at
+// the current key path was not assigned because it already has a value.
+func (m *Merger) recordShadowed(src mergeSource) {
+	if m.recorder == nil || len(m.keyPath) == 0 {
+		return
+	}
+	key := strings.Join(m.keyPath, ".")
+	previous, exists := m.provenance[key]
+	if !exists {
+		return
+	}
+	reflected, coord, err := src.reflect()
+	if err != nil {
+		return
+	}
+	opts := assignOptions{}
+	if option := toOption(reflected); option != nil {
+		if !option.IsDefined() {
+			return
+		}
+		opts.sourceLocation = option.GetSource()
+	}
+	source := m.valueSource(src, coord, opts)
+	if source.String() == previous.String() {
+		return
+	}
+	m.recordChange(MergeEvent{Key: key, Change: MergeChangeShadowed, Source: source, Previous: &previous})
+}
+
+// valueSource returns the source of the value assigned at coord.
+func (m *Merger) valueSource(src mergeSource, coord *FileCoordinate, opts assignOptions) SourceLocation {
+	source := opts.sourceLocation
+	if source.Name == "" {
+		source.Name = m.sourceFile
+		source.IncludeChain = strings.Join(m.includeChain, includeChainSeparator)
+	}
+	if coord != nil {
+		source.Location = coord
+	}
+	if m.isSecret(src) {
+		source.Secret = true
+	}
+	if m.transformed {
+		source.Transforms = strings.Join(m.transforms, "|")
+	}
+	return source
+}
+
+// AdvanceHook is called by Merger.Advance at the end of each document with
+// the name of the source and the fields the document overwrote, see
+// WithAdvanceHook.
+type AdvanceHook func(source string, overwritten []string)
+
+// WithAdvanceHook adds a hook called at the end of each document merged,
+// see Merger.Advance.
+func WithAdvanceHook(hook AdvanceHook) MergeOption {
+	return func(m *Merger) {
+		m.advanceHooks = append(m.advanceHooks, hook)
+	}
 }
 
-// advance will move all the current overwrite properties to
-// the ignore properties, then reset the overwrite properties.
-// This is used after a document has be processed so the next
-// document does not modify overwritten fields.
-func (m *Merger) advance() {
-	for _, overwrite := range m.Config.Overwrite {
+// Advance marks the end of the current document.  It will move all the
+// current overwrite properties to the ignore properties, then reset the
+// overwrite properties, so the next document does not modify overwritten
+// fields.  Loaders that merge documents with LoadConfigSourceWithMerger
+// must call Advance after each document.
+func (m *Merger) Advance() {
+	overwritten := m.Config.Overwrite
+	for _, overwrite := range overwritten {
 		found := false
 		for _, ignore := range m.ignore {
 			if ignore == overwrite {
@@ -451,21 +2238,93 @@ func (m *Merger) advance() {
 		}
 	}
 	m.Config.Overwrite = nil
+	for _, lock := range m.Config.Lock {
+		m.locks = append(m.locks, valueLock{keyPath: strings.Split(lock, "."), source: m.sourceFile})
+	}
+	m.Config.Lock = nil
+	m.recorded = nil
+	for _, hook := range m.advanceHooks {
+		hook(m.sourceFile, overwritten)
+	}
+}
+
+// Ignored returns the fields overwritten by previous documents, these fields
+// are not modified by later documents.
+func (m *Merger) Ignored() []string {
+	return append([]string{}, m.ignore...)
+}
+
+func (m *Merger) log() Logger {
+	if m.logger != nil {
+		return m.logger
+	}
+	return Log
+}
+
+// newSource returns the SourceLocation for the current source file.
+func (m *Merger) newSource(coord *FileCoordinate) SourceLocation {
+	return NewSource(m.sourceFile, WithLocation(coord), WithIncludeChain(m.includeChain))
+}
+
+// copyValue returns a deep copy of v when the Merger was created with
+// DeepCopyValues, otherwise v is returned as-is.
+func (m *Merger) copyValue(v reflect.Value) reflect.Value {
+	if m.deepCopy {
+		return deepCopyValue(v)
+	}
+	return v
 }
 
 // Merge will attempt to merge the data from src into dst. src and dst may each
 // be either a map or a struct. Structs do not need to have the same structure,
 // but any field name that exists in both structs will must be the same type.
-func Merge(dst, src interface{}) error {
+func Merge(dst, src interface{}, options ...MergeOption) error {
 	dstValue := reflect.ValueOf(dst)
 	if dstValue.Kind() == reflect.Struct {
 		return errors.New("dst argument cannot be a struct (should be *struct)")
 	}
-	m := NewMerger()
+	m := NewMerger(options...)
 	_, err := m.mergeStructs(dstValue, newMergeSource(reflect.ValueOf(src)), false)
 	return err
 }
 
+// Overlay returns a new value, of the same type as base, with the values
+// from overrides merged with precedence over the values from base.  Neither
+// base nor overrides are modified, and the overwrite state of m is not used
+// or changed, so Overlay is safe to use with a shared base for request
+// scoped overrides.  Only overrides is deep copied, the returned value may
+// share maps, slices and pointers with base unless m was created with
+// DeepCopyValues, so it should be treated as read-only.
+func (m *Merger) Overlay(base, overrides interface{}) (merged interface{}, err error) {
+	baseValue := reflect.ValueOf(base)
+	if !baseValue.IsValid() {
+		return nil, errors.New("base argument cannot be nil")
+	}
+	typ := baseValue.Type()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	dst := reflect.New(typ)
+	o := *m
+	o.Reset()
+	if overridesValue := reflect.ValueOf(overrides); overridesValue.IsValid() {
+		// overrides are copied since values merged into them, like maps
+		// stored in interfaces, would otherwise be modified by merging base
+		o.deepCopy = true
+		if _, err := o.mergeStructs(dst, newMergeSource(overridesValue), false); err != nil {
+			return nil, err
+		}
+		o.deepCopy = m.deepCopy
+	}
+	if _, err := o.mergeStructs(dst, newMergeSource(baseValue), false); err != nil {
+		return nil, err
+	}
+	if baseValue.Kind() == reflect.Pointer {
+		return dst.Interface(), nil
+	}
+	return dst.Elem().Interface(), nil
+}
+
 // MakeMergeStruct will take multiple structs and return a pointer to a zero value for the
 // anonymous struct that has all the public fields from all the structs merged into one struct.
 // If there are multiple structs with the same field names, the first appearance of that name
@@ -555,7 +2414,7 @@ func (m *Merger) makeMergeStruct(values ...reflect.Value) reflect.Value {
 				field = reflect.StructField{
 					Name: camelCase(key.String()),
 					Type: t,
-					Tag:  reflect.StructTag(fmt.Sprintf(`json:"%s" yaml:"%s"`, key.String(), key.String())),
+					Tag:  m.mapFieldTag(key.String()),
 				}
 				if f, ok := foundFields[field.Name]; ok {
 					if f.Type.Kind() == reflect.Struct && t.Kind() == reflect.Struct {
@@ -586,6 +2445,15 @@ func (m *Merger) makeMergeStruct(values ...reflect.Value) reflect.Value {
 	return reflect.New(newType)
 }
 
+// mapFieldTag returns the struct tag for a field created from the map key by
+// makeMergeStruct.
+func (m *Merger) mapFieldTag(key string) reflect.StructTag {
+	if m.nameTags {
+		return reflect.StructTag(fmt.Sprintf(`json:"%s" yaml:"%s" figtree:",name=%s"`, key, key, camelCase(key)))
+	}
+	return reflect.StructTag(fmt.Sprintf(`json:"%s" yaml:"%s"`, key, key))
+}
+
 func (m *Merger) mapToStruct(src reflect.Value) (reflect.Value, error) {
 	if src.Kind() != reflect.Map {
 		return reflect.Value{}, nil
@@ -623,7 +2491,7 @@ func (m *Merger) mapToStruct(src reflect.Value) (reflect.Value, error) {
 				return reflect.Value{}, err
 			}
 		default:
-			dest.FieldByName(structFieldName).Set(reflect.ValueOf(keyval.Interface()))
+			dest.FieldByName(structFieldName).Set(m.copyValue(reflect.ValueOf(keyval.Interface())))
 		}
 	}
 	return dest, nil
@@ -657,6 +2525,12 @@ func structToMap(src mergeSource) (mergeSource, error) {
 
 type ConfigOptions struct {
 	Overwrite []string `json:"overwrite,omitempty" yaml:"overwrite,omitempty"`
+	// Lock are the key paths, like `api-url` or `servers.*.host`, that other
+	// documents cannot change once this document has set them.  A document
+	// that sets a locked key to a different value is an error, whether it is
+	// merged before or after the locking document, so a lock in a parent
+	// directory or /etc also applies to the files below it.
+	Lock []string `json:"lock,omitempty" yaml:"lock,omitempty"`
 }
 
 func yamlFieldName(sf reflect.StructField) string {
@@ -696,6 +2570,184 @@ func CanonicalFieldName(sf reflect.StructField) string {
 	return camelCase(yamlFieldName(sf))
 }
 
+// figtreeTagValue returns the value for `key=value` in the figtree struct
+// tag of sf, for example: `figtree:",sources=file|env"`.
+func figtreeTagValue(sf reflect.StructField, key string) (string, bool) {
+	if tag, ok := sf.Tag.Lookup("figtree"); ok {
+		for _, part := range strings.Split(tag, ",") {
+			if strings.HasPrefix(part, key+"=") {
+				return strings.TrimPrefix(part, key+"="), true
+			}
+		}
+	}
+	return "", false
+}
+
+// sourceKind will return the kind of source for the source name, used
+// to restrict the sources allowed for fields tagged with
+// `figtree:",sources=..."`.  Config files are kind "file", configs read from
+// URLs, like ConfigProvider sources, are kind "remote" and feature flag values
+// are kind "flag".
+func sourceKind(name string) string {
+	switch name {
+	case defaultSource, overrideSource, promptSource, envSource:
+		return name
+	}
+	if strings.HasPrefix(name, flagSourcePrefix) {
+		return "flag"
+	}
+	if strings.HasPrefix(name, envSourcePrefix) {
+		return envSource
+	}
+	if strings.HasPrefix(name, defaultSourcePrefix) {
+		return defaultSource
+	}
+	if isRemoteSource(name) {
+		return "remote"
+	}
+	return "file"
+}
+
+// isRemoteSource returns true if the source name is a URL, like
+// `https://config.example.com/app.yml`.
+func isRemoteSource(name string) bool {
+	scheme, _, ok := strings.Cut(name, "://")
+	if !ok || len(scheme) < 2 {
+		// a single letter is a windows drive
+		return false
+	}
+	for i, r := range scheme {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type sourceNotAllowedError struct {
+	field          string
+	kind           string
+	allowed        []string
+	sourceLocation SourceLocation
+}
+
+func (e sourceNotAllowedError) Error() string {
+	return fmt.Sprintf("%s: %s cannot be set from %s source, allowed sources: %s", e.sourceLocation, e.field, e.kind, strings.Join(e.allowed, ", "))
+}
+
+// checkAllowedSources will return an error if the field is tagged with
+// `figtree:",sources=..."` and src is from a kind of source that is not in the
+// allowed list.  Default values are always allowed.
+func (m *Merger) checkAllowedSources(sf reflect.StructField, name string, src mergeSource) error {
+	tag, ok := figtreeTagValue(sf, "sources")
+	if !ok || !src.isValid() || src.isZero() {
+		return nil
+	}
+	val, coord, err := src.reflect()
+	if err != nil {
+		return walky.ErrFilename(err, m.sourceFile)
+	}
+	source := m.newSource(coord)
+	if option := toOption(val); option != nil && option.GetSource().Name != "" {
+		source = option.GetSource()
+	}
+	kind := sourceKind(source.Name)
+	if kind == defaultSource {
+		return nil
+	}
+	allowed := strings.Split(tag, "|")
+	for _, a := range allowed {
+		if a == kind {
+			return nil
+		}
+	}
+	return errors.WithStack(sourceNotAllowedError{
+		field:          name,
+		kind:           kind,
+		allowed:        allowed,
+		sourceLocation: source,
+	})
+}
+
+type lockedValueError struct {
+	key            string
+	lockedBy       string
+	sourceLocation SourceLocation
+}
+
+func (e lockedValueError) Error() string {
+	return fmt.Sprintf("%s: %s is locked by %s and cannot be changed", e.sourceLocation, e.key, e.lockedBy)
+}
+
+// checkLock will return an error if the current key path is locked with the
+// lock config pragma and the documents set different values for it.  Locks
+// from previous documents stop src from changing the value of dst, and locks
+// from the current document report values set by previous, higher
+// precedence, documents that differ from src, so a lock in /etc also applies
+// to the config files in the working directory.  Values that were not set by
+// both documents, and defaults, are allowed.
+func (m *Merger) checkLock(dst reflect.Value, src mergeSource) error {
+	var lock *valueLock
+	for i := range m.locks {
+		if matchesKeyPatterns([][]string{m.locks[i].keyPath}, m.keyPath) {
+			lock = &m.locks[i]
+			break
+		}
+	}
+	current := false
+	for _, pattern := range m.Config.Lock {
+		if matchesKeyPatterns([][]string{strings.Split(pattern, ".")}, m.keyPath) {
+			current = true
+			break
+		}
+	}
+	if lock == nil && !current || sourceKind(m.sourceFile) == defaultSource {
+		return nil
+	}
+	if !dst.IsValid() || isZeroOrDefaultOption(dst) || isZero(dst) || !src.isValid() || src.isZero() {
+		return nil
+	}
+	val, coord, err := src.reflect()
+	if err != nil {
+		return walky.ErrFilename(err, m.sourceFile)
+	}
+	if fmt.Sprint(Normalize(dst.Interface())) == fmt.Sprint(Normalize(val.Interface())) {
+		return nil
+	}
+	source := m.newSource(coord)
+	if option := toOption(val); option != nil && option.GetSource().Name != "" {
+		source = option.GetSource()
+	}
+	key := strings.Join(m.keyPath, ".")
+	dstSource, dstKnown := m.provenance[key]
+	if option := toOption(dst); option != nil && option.GetSource().Name != "" {
+		dstSource, dstKnown = option.GetSource(), true
+	}
+	if lock != nil {
+		lockedBy := lock.source
+		if dstKnown {
+			lockedBy = dstSource.String()
+		}
+		return errors.WithStack(lockedValueError{
+			key:            key,
+			lockedBy:       lockedBy,
+			sourceLocation: source,
+		})
+	}
+	if !dstKnown || dstSource.Name == m.sourceFile {
+		// set by an earlier section of the locking document
+		return nil
+	}
+	return errors.WithStack(lockedValueError{
+		key:            key,
+		lockedBy:       source.String(),
+		sourceLocation: dstSource,
+	})
+}
+
 func (m *Merger) mustOverwrite(name string) bool {
 	for _, prop := range m.Config.Overwrite {
 		if name == prop {
@@ -714,6 +2766,57 @@ func (m *Merger) mustIgnore(name string) bool {
 	return false
 }
 
+// enterKey adds key to the current key path, the returned function will
+// restore the key path.
+func (m *Merger) enterKey(key string) func() {
+	m.keyPath = append(m.keyPath, key)
+	return func() {
+		m.keyPath = m.keyPath[:len(m.keyPath)-1]
+	}
+}
+
+// mustIgnoreKeyPath returns true if the current key path matches any of the
+// WithIgnoreKeys patterns, or is excluded by onlyKeys, skipKeys or
+// refreshKeys.
+func (m *Merger) mustIgnoreKeyPath() bool {
+	if m.onlyKeys != nil && !m.inKeyPaths(m.onlyKeys) {
+		return true
+	}
+	if m.refreshKeys != nil && !m.inKeyPaths(m.refreshKeys) {
+		return true
+	}
+	for _, key := range m.skipKeys {
+		if len(m.keyPath) >= len(key) && keyPathHasPrefix(m.keyPath, key) {
+			return true
+		}
+	}
+	return matchesKeyPatterns(m.ignoreKeys, m.keyPath)
+}
+
+// inKeyPaths returns true if the current key path is one of keys, or is a
+// parent or child of one of them.
+func (m *Merger) inKeyPaths(keys [][]string) bool {
+	for _, key := range keys {
+		if keyPathHasPrefix(m.keyPath, key) || keyPathHasPrefix(key, m.keyPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyPathHasPrefix returns true if keyPath starts with all of prefix.
+func keyPathHasPrefix(keyPath, prefix []string) bool {
+	if len(keyPath) < len(prefix) {
+		return false
+	}
+	for i, key := range prefix {
+		if keyPath[i] != key {
+			return false
+		}
+	}
+	return true
+}
+
 func isZeroOrDefaultOption(v reflect.Value) bool {
 	if option := toOption(v); option != nil {
 		// an option can only be `zero` if it is undefined
@@ -788,6 +2891,65 @@ func (e notAssignableError) Error() string {
 	return fmt.Sprintf("%s: %s is not assignable to %s", e.sourceLocation, e.srcType, e.dstType)
 }
 
+type overflowError struct {
+	value          any
+	dstType        reflect.Type
+	sourceLocation SourceLocation
+}
+
+func (e overflowError) Error() string {
+	return fmt.Sprintf("%s: %v overflows %s", e.sourceLocation, e.value, e.dstType)
+}
+
+// overflowError returns an overflowError for src at coord, or at the source
+// of the Option src was unwrapped from.
+func (m *Merger) overflowError(src reflect.Value, dstType reflect.Type, coord *FileCoordinate, opts assignOptions) error {
+	source := m.newSource(coord)
+	if coord == nil && opts.sourceLocation.Name != "" {
+		source = opts.sourceLocation
+	}
+	return errors.WithStack(overflowError{
+		value:          src.Interface(),
+		dstType:        dstType,
+		sourceLocation: source,
+	})
+}
+
+// convertOverflows returns true if converting the numeric src to t would
+// not preserve the value, like 300 to int8 or -1 to uint16.
+func convertOverflows(src reflect.Value, t reflect.Type) bool {
+	dst := reflect.Zero(t)
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v := src.Int()
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return dst.OverflowInt(v)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return v < 0 || dst.OverflowUint(uint64(v))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v := src.Uint()
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v > math.MaxInt64 || dst.OverflowInt(int64(v))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return dst.OverflowUint(v)
+		}
+	case reflect.Float32, reflect.Float64:
+		v := src.Float()
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v < math.MinInt64 || v >= math.MaxInt64 || dst.OverflowInt(int64(v))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return v < 0 || v >= math.MaxUint64 || dst.OverflowUint(uint64(v))
+		case reflect.Float32:
+			return !math.IsInf(v, 0) && dst.OverflowFloat(v)
+		}
+	}
+	return false
+}
+
 var stringType = reflect.ValueOf("").Type()
 
 type assignOptions struct {
@@ -812,12 +2974,17 @@ type assignOptions struct {
 // bool return value will indicate if the assignment happened, which will be
 // false when the trying to assign to a non-zero, or non-default value (without
 // Overwrite set)
-func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOptions) (bool, error) {
+func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOptions) (ok bool, err error) {
 	reflectedSrc, coord, err := src.reflect()
 	if err != nil {
 		return false, walky.ErrFilename(err, m.sourceFile)
 	}
-	Log.Debugf("assignValue: %#v to %#v [opts: %#v]\n", reflectedSrc, dest, opts)
+	defer func() {
+		if ok && err == nil {
+			m.recordProvenance(src, coord, opts)
+		}
+	}()
+	m.log().Debugf("assignValue: %#v to %#v [opts: %#v]\n", reflectedSrc, dest, opts)
 	if !dest.IsValid() || !reflectedSrc.IsValid() {
 		return false, nil
 	}
@@ -828,8 +2995,12 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 		return false, errors.Errorf("Cannot assign %#v to unsettable value %#v", reflectedSrc, dest)
 	}
 
+	// pointers that are converted from strings, like *time.Location, are
+	// assigned as is rather than dereferenced when the source is a string
+	converted := dest.Kind() == reflect.Pointer && reflectedSrc.Kind() == reflect.String && needsStringConversion(dest.Addr().Interface())
+
 	// if we have a pointer value, deref (and create if nil)
-	if dest.Kind() == reflect.Pointer {
+	if dest.Kind() == reflect.Pointer && !converted {
 		if dest.IsNil() {
 			dest.Set(reflect.New(dest.Type().Elem()))
 		}
@@ -837,7 +3008,7 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 	}
 
 	// if src is a pointer, deref, return if nil and not overwriting
-	if reflectedSrc.Kind() == reflect.Pointer {
+	if reflectedSrc.Kind() == reflect.Pointer && !converted {
 		reflectedSrc = reflectedSrc.Elem()
 		// reflectedSrc might be invalid if it was Nil so lets handle that now
 		if !reflectedSrc.IsValid() {
@@ -853,7 +3024,10 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 	// if is assignable. We cannot assign float32 to float64, but we can
 	// convert float32 to float64 and then assign.  Note we skip conversion
 	// to strings since almost anything can be converted to a string
-	if dest.Kind() != reflect.String && reflectedSrc.CanConvert(dest.Type()) {
+	if dest.Kind() != reflect.String && reflectedSrc.CanConvert(dest.Type()) && !isDurationConversion(reflectedSrc, dest.Type()) {
+		if convertOverflows(reflectedSrc, dest.Type()) {
+			return false, m.overflowError(reflectedSrc, dest.Type(), coord, opts)
+		}
 		reflectedSrc = reflectedSrc.Convert(dest.Type())
 	}
 
@@ -885,6 +3059,10 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 					// since we want an `any` we should be good with
 					// just creating the src type
 					destOptionValue = reflect.New(reflectedSrc.Type()).Elem()
+				} else if valueField := dest.FieldByName("Value"); valueField.IsValid() {
+					// other interface types, like Option[error], get
+					// a zero value of the interface type
+					destOptionValue = reflect.New(valueField.Type()).Elem()
 				}
 			}
 			if !destOptionValue.CanSet() {
@@ -902,10 +3080,14 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 				source := opts.sourceLocation
 				if source.Name == "" {
 					source.Name = m.sourceFile
+					source.IncludeChain = strings.Join(m.includeChain, includeChainSeparator)
 				}
 				if coord != nil {
 					source.Location = coord
 				}
+				if m.isSecret(src) {
+					source.Secret = true
+				}
 				option.SetSource(source)
 			}
 			return ok, nil
@@ -915,8 +3097,12 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 	// if we are assigning to a yaml.Node then try to preserve the raw
 	// yaml.Node input, otherwise encode the src into the Node.
 	if node, ok := dest.Interface().(yaml.Node); ok {
+		if src.alias != nil && m.preserveAnchors {
+			dest.Set(reflect.ValueOf(*src.alias))
+			return true, nil
+		}
 		if src.node != nil {
-			dest.Set(reflect.ValueOf(*src.node))
+			dest.Set(m.copyValue(reflect.ValueOf(*src.node)))
 			return true, nil
 		}
 		if err := node.Encode(reflectedSrc.Interface()); err != nil {
@@ -926,6 +3112,19 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 		return true, nil
 	}
 
+	// strings are transformed for fields tagged with
+	// `figtree:",transform=..."`, see RegisterTransform
+	if len(m.transforms) > 0 && dest.Kind() == reflect.String && reflectedSrc.Kind() == reflect.String {
+		transformed, err := m.transform(reflectedSrc.String())
+		if err != nil {
+			if src.node != nil {
+				return false, walky.ErrFilename(walky.NewYAMLError(err, src.node), m.sourceFile)
+			}
+			return false, errors.Wrapf(err, "%s", m.newSource(coord))
+		}
+		reflectedSrc = reflect.ValueOf(transformed).Convert(reflectedSrc.Type())
+	}
+
 	if reflectedSrc.Type().AssignableTo(dest.Type()) {
 		shouldAssignDest := opts.Overwrite || isZero(dest) || (opts.destIsDefault && !opts.srcIsDefault)
 		if shouldAssignDest {
@@ -948,11 +3147,59 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 					// slices are mutable, so create a brand new shiny one
 					cp := reflect.MakeSlice(reflectedSrc.Type(), reflectedSrc.Len(), reflectedSrc.Len())
 					reflect.Copy(cp, reflectedSrc)
-					dest.Set(cp)
+					dest.Set(m.copyValue(cp))
 				}
 			default:
-				dest.Set(reflectedSrc)
+				dest.Set(m.copyValue(reflectedSrc))
+			}
+			return true, nil
+		}
+		return false, nil
+	}
+
+	// complex, error, time zone and locale values cannot be represented
+	// directly in yaml, so we convert them from their string form.
+	if needsStringConversion(dest.Addr().Interface()) && !isCollection(reflectedSrc) {
+		str := formatScalar(reflectedSrc.Interface())
+		if src.node != nil && src.node.Kind == yaml.ScalarNode {
+			str = src.node.Value
+		}
+		if err := convertString(str, dest.Addr().Interface()); err != nil {
+			err = errors.Wrapf(err, "%s is not assignable to %s, invalid value %#v", reflectedSrc.Type(), dest.Type(), str)
+			if src.node != nil {
+				return false, walky.ErrFilename(walky.NewYAMLError(err, src.node), m.sourceFile)
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	if m.untyped && src.node != nil && src.node.Kind == yaml.ScalarNode && reflectedSrc.Kind() == reflect.String {
+		if parsed, ok, err := parseNumber(reflectedSrc.String(), dest.Type(), m.decimalComma); ok {
+			if err != nil {
+				err = errors.Wrapf(err, "%s is not assignable to %s, invalid value %#v", reflectedSrc.Type(), dest.Type(), reflectedSrc.String())
+				return false, walky.ErrFilename(walky.NewYAMLError(err, src.node), m.sourceFile)
+			}
+			if opts.Overwrite || isZero(dest) || (opts.destIsDefault && !opts.srcIsDefault) {
+				dest.Set(parsed)
+				return true, nil
+			}
+			return false, nil
+		}
+	}
+
+	if m.decimalComma && reflectedSrc.Kind() == reflect.String && (dest.Kind() == reflect.Float32 || dest.Kind() == reflect.Float64) {
+		str := reflectedSrc.String()
+		f, err := parseFloat(str, dest.Type().Bits(), true)
+		if err != nil {
+			err = errors.Wrapf(err, "%s is not assignable to %s, invalid float value %#v", reflectedSrc.Type(), dest.Type(), str)
+			if src.node != nil {
+				return false, walky.ErrFilename(walky.NewYAMLError(err, src.node), m.sourceFile)
 			}
+			return false, err
+		}
+		if opts.Overwrite || isZero(dest) || (opts.destIsDefault && !opts.srcIsDefault) {
+			dest.SetFloat(f)
 			return true, nil
 		}
 		return false, nil
@@ -974,7 +3221,7 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 				notAssignableError{
 					srcType:        reflectedSrc.Type(),
 					dstType:        dest.Type(),
-					sourceLocation: NewSource(m.sourceFile, WithLocation(coord)),
+					sourceLocation: m.newSource(coord),
 				},
 			)
 		case reflect.Struct:
@@ -982,13 +3229,13 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 			// unless that struct is an option struct in which case
 			// we use convert the value
 			if option := toOption(reflectedSrc); option != nil {
-				dest.Set(reflect.ValueOf(fmt.Sprint(option.GetValue())))
+				dest.Set(reflect.ValueOf(formatScalar(option.GetValue())))
 			}
 			return false, errors.WithStack(
 				notAssignableError{
 					srcType:        reflectedSrc.Type(),
 					dstType:        dest.Type(),
-					sourceLocation: NewSource(m.sourceFile, WithLocation(coord)),
+					sourceLocation: m.newSource(coord),
 				},
 			)
 		default:
@@ -999,7 +3246,7 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 			if src.node != nil && src.node.Kind == yaml.ScalarNode {
 				dest.Set(reflect.ValueOf(src.node.Value))
 			} else {
-				dest.Set(reflect.ValueOf(fmt.Sprint(reflectedSrc.Interface())))
+				dest.Set(reflect.ValueOf(formatScalar(reflectedSrc.Interface())))
 			}
 		}
 		return true, nil
@@ -1036,7 +3283,7 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 			notAssignableError{
 				srcType:        reflectedSrc.Type(),
 				dstType:        dest.Type(),
-				sourceLocation: NewSource(m.sourceFile, WithLocation(coord)),
+				sourceLocation: m.newSource(coord),
 			},
 		)
 	}
@@ -1047,8 +3294,11 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 	if reflectedSrc.CanConvert(dest.Type()) {
 		shouldAssignDest := opts.Overwrite || isZero(dest) || (opts.destIsDefault && !opts.srcIsDefault)
 		if shouldAssignDest {
+			if convertOverflows(reflectedSrc, dest.Type()) {
+				return false, m.overflowError(reflectedSrc, dest.Type(), coord, opts)
+			}
 			reflectedSrc = reflectedSrc.Convert(dest.Type())
-			dest.Set(reflectedSrc)
+			dest.Set(m.copyValue(reflectedSrc))
 			return true, nil
 		}
 		return false, nil
@@ -1058,7 +3308,7 @@ func (m *Merger) assignValue(dest reflect.Value, src mergeSource, opts assignOpt
 		notAssignableError{
 			srcType:        reflectedSrc.Type(),
 			dstType:        dest.Type(),
-			sourceLocation: NewSource(m.sourceFile, WithLocation(coord)),
+			sourceLocation: m.newSource(coord),
 		},
 	)
 }
@@ -1067,6 +3317,9 @@ type mergeSource struct {
 	reflected reflect.Value
 	node      *yaml.Node
 	coord     *FileCoordinate
+	// alias is the original alias node when node was resolved from an
+	// alias, used to preserve aliases with PreserveAnchors.
+	alias *yaml.Node
 }
 
 func newMergeSource(src any) mergeSource {
@@ -1086,9 +3339,13 @@ func newMergeSource(src any) mergeSource {
 			reflected: cast,
 		}
 	case *yaml.Node:
-		return mergeSource{
+		ms := mergeSource{
 			node: walky.Indirect(cast),
 		}
+		if unwrapped := walky.UnwrapDocument(cast); unwrapped != nil && unwrapped.Kind == yaml.AliasNode {
+			ms.alias = unwrapped
+		}
+		return ms
 	}
 	panic(fmt.Sprintf("Unknown type: %T", src))
 }
@@ -1139,6 +3396,13 @@ func (ms *mergeSource) isList() bool {
 	return false
 }
 
+func (ms *mergeSource) isString() bool {
+	if ms.node != nil {
+		return ms.node.Kind == yaml.ScalarNode
+	}
+	return ms.reflected.Kind() == reflect.String
+}
+
 func (ms *mergeSource) isZero() bool {
 	if ms.node != nil {
 		// values directly from config files cannot be 'zero'
@@ -1258,6 +3522,20 @@ type fieldYAML struct {
 	Value       reflect.Value
 }
 
+// unknownKeyError returns the error for a key in the mapping node that does
+// not correspond to any struct field, see WithStrictDecode.
+func (m *Merger) unknownKeyError(node *yaml.Node, key string) error {
+	keyNode, _ := walky.GetKeyValue(node, walky.NewStringNode(key))
+	if keyNode == nil {
+		// the key is from a merged map
+		keyNode = node
+	}
+	return walky.ErrFilename(
+		walky.NewYAMLError(errors.Errorf("unknown key %q", strings.Join(m.keyPath, ".")), keyNode),
+		m.sourceFile,
+	)
+}
+
 // populateYAMLMaps will collect a map by field name where
 // those field names are converted to a common name used in YAML
 // documents so we can easily merge fields and maps together from
@@ -1323,7 +3601,7 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 	}
 
 	if !dst.IsValid() || !src.isValid() {
-		Log.Debugf("Valid: dst:%v src:%t", dst.IsValid(), src.isValid())
+		m.log().Debugf("Valid: dst:%v src:%t", dst.IsValid(), src.isValid())
 		return false, nil
 	}
 
@@ -1335,6 +3613,13 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 		if m.mustIgnore(fieldName) {
 			return nil
 		}
+		if !anon {
+			defer m.enterKey(fieldName)()
+			if m.mustIgnoreKeyPath() {
+				return nil
+			}
+			m.markPresent(srcField)
+		}
 
 		dstFieldByYAML, ok := dstFieldsByYAML[fieldName]
 		if !ok {
@@ -1348,6 +3633,9 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 				}
 				changed = changed || ok
 			}
+			if m.strict && src.node != nil && !(fieldName == "config" && len(m.keyPath) == 1) {
+				return m.unknownKeyError(src.node, fieldName)
+			}
 			// if original value does not have the same struct field
 			// then just skip this field.
 			return nil
@@ -1359,6 +3647,16 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 			return nil
 		}
 
+		if err := m.checkAllowedSources(dstFieldByYAML.StructField, fieldName, srcField); err != nil {
+			return err
+		}
+		defer m.enterTransforms(dstFieldByYAML.StructField)()
+		if !anon {
+			if err := m.checkLock(dstFieldByYAML.Value, srcField); err != nil {
+				return err
+			}
+		}
+
 		dstField := dstFieldByYAML.Value
 
 		fieldChanged := false
@@ -1376,8 +3674,10 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 			}
 		}
 
-		// if we have a pointer value, deref (and create if nil)
-		if dstField.Kind() == reflect.Pointer {
+		// if we have a pointer value, deref (and create if nil), pointers
+		// that are converted from strings, like *regexp.Regexp, are
+		// assigned as is when the source is a string
+		if dstField.Kind() == reflect.Pointer && !(srcField.isString() && dstField.CanAddr() && needsStringConversion(dstField.Addr().Interface())) {
 			if dstField.IsNil() {
 				newField := reflect.New(dstField.Type().Elem())
 				defer func(origField reflect.Value) {
@@ -1390,6 +3690,27 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 			dstField = dstField.Elem()
 		}
 
+		// plugin config is retained as raw config from each source rather
+		// than merged, see PluginsOption.
+		if dstField.Type() == pluginsType {
+			ok, err := m.mergePlugins(dstField, srcField)
+			fieldChanged = ok
+			changed = changed || ok
+			return err
+		}
+
+		if strategy, ok := figtreeTagValue(dstFieldByYAML.StructField, "merge"); ok && !overwrite && !m.mustOverwrite(fieldName) {
+			handled, ok, err := m.mergeWithStrategy(strategy, fieldName, dstField, srcField)
+			if err != nil {
+				return err
+			}
+			if handled {
+				fieldChanged = ok
+				changed = changed || ok
+				return nil
+			}
+		}
+
 		val, _, err := srcField.reflect()
 		if err != nil {
 			return walky.ErrFilename(err, m.sourceFile)
@@ -1413,12 +3734,12 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 			}
 			changed = changed || fieldChanged
 			if fieldChanged {
-				return nil
+				return m.setTTL(dstFieldByYAML.StructField, dstField)
 			}
 		}
 		switch dstField.Kind() {
 		case reflect.Map:
-			Log.Debugf("Merging Map: %#v to %#v [overwrite: %t]", val, dstField, overwrite || m.mustOverwrite(fieldName))
+			m.log().Debugf("Merging Map: %#v to %#v [overwrite: %t]", val, dstField, overwrite || m.mustOverwrite(fieldName))
 			ok, err := m.mergeStructs(dstField, srcField, overwrite || m.mustOverwrite(fieldName))
 			if err != nil {
 				return errors.WithStack(err)
@@ -1427,7 +3748,7 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 			changed = changed || ok
 			return nil
 		case reflect.Slice, reflect.Array:
-			Log.Debugf("Merging %#v to %#v [overwrite: %t]", val, dstField, overwrite || m.mustOverwrite(fieldName))
+			m.log().Debugf("Merging %#v to %#v [overwrite: %t]", val, dstField, overwrite || m.mustOverwrite(fieldName))
 			merged, ok, err := m.mergeArrays(dstField, srcField, overwrite || m.mustOverwrite(fieldName))
 			if err != nil {
 				return err
@@ -1441,7 +3762,7 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 		case reflect.Struct:
 			// only merge structs if they are not special structs (options or yaml.Node):
 			if !isSpecial(dstField) {
-				Log.Debugf("Merging Struct: %#v to %#v [overwrite: %t]", val, dstField, overwrite || m.mustOverwrite(fieldName))
+				m.log().Debugf("Merging Struct: %#v to %#v [overwrite: %t]", val, dstField, overwrite || m.mustOverwrite(fieldName))
 				ok, err := m.mergeStructs(dstField, srcField, overwrite || m.mustOverwrite(fieldName))
 				if err != nil {
 					return errors.WithStack(err)
@@ -1451,6 +3772,9 @@ func (m *Merger) mergeStructs(dst reflect.Value, src mergeSource, overwrite bool
 				return nil
 			}
 		}
+		if !shouldAssign {
+			m.recordShadowed(srcField)
+		}
 		return assignErr
 	})
 	if err != nil {
@@ -1467,6 +3791,13 @@ func (m *Merger) mergeMaps(dst reflect.Value, src mergeSource, overwrite bool) (
 			return false, err
 		}
 	}
+	if dst.Type() == featureSetType && src.isList() {
+		var err error
+		src, err = featureSetToMap(src)
+		if err != nil {
+			return false, walky.ErrFilename(err, m.sourceFile)
+		}
+	}
 	if !src.isMap() {
 		return false, nil
 	}
@@ -1480,6 +3811,13 @@ func (m *Merger) mergeMaps(dst reflect.Value, src mergeSource, overwrite bool) (
 
 	changed := false
 	err := src.foreachKey(func(key reflect.Value, value mergeSource) error {
+		defer m.enterKey(fmt.Sprint(key.Interface()))()
+		if m.mustIgnoreKeyPath() {
+			return nil
+		}
+		if err := m.checkLock(dst.MapIndex(key), value); err != nil {
+			return err
+		}
 		if !dst.MapIndex(key).IsValid() {
 			dstElem := reflect.New(dst.Type().Elem()).Elem()
 			ok, err := m.assignValue(dstElem, value, assignOptions{
@@ -1496,6 +3834,7 @@ func (m *Merger) mergeMaps(dst reflect.Value, src mergeSource, overwrite bool) (
 				}
 				if loc.Name == "" {
 					loc.Name = m.sourceFile
+					loc.IncludeChain = strings.Join(m.includeChain, includeChainSeparator)
 				}
 				option.SetSource(loc)
 			}
@@ -1510,7 +3849,7 @@ func (m *Merger) mergeMaps(dst reflect.Value, src mergeSource, overwrite bool) (
 					}
 					dst.Set(reflect.MakeMap(dst.Type()))
 				}
-				Log.Debugf("Setting %v to %#v", key.Interface(), dstElem.Interface())
+				m.log().Debugf("Setting %v to %#v", key.Interface(), dstElem.Interface())
 				dst.SetMapIndex(key, dstElem)
 				changed = changed || ok
 				return nil
@@ -1531,15 +3870,23 @@ func (m *Merger) mergeMaps(dst reflect.Value, src mergeSource, overwrite bool) (
 		dstValKind := dstVal.Kind()
 		switch {
 		case dstValKind == reflect.Map:
-			Log.Debugf("Merging: %#v to %#v", value, dstVal)
-			ok, err := m.mergeStructs(dstVal, value, overwrite || m.mustOverwrite(key.String()))
+			m.log().Debugf("Merging: %#v to %#v", value, dstVal)
+			// map values are not settable, so merge into a settable copy
+			// to allow nil maps to be created, then set the map key to
+			// the new value
+			newVal := reflect.New(dstVal.Type()).Elem()
+			newVal.Set(dstVal)
+			ok, err := m.mergeStructs(newVal, value, overwrite || m.mustOverwrite(key.String()))
 			if err != nil {
 				return errors.WithStack(err)
 			}
-			changed = changed || ok
+			if ok {
+				dst.SetMapIndex(key, newVal)
+				changed = true
+			}
 			return nil
 		case dstValKind == reflect.Struct && !isSpecial(dstVal):
-			Log.Debugf("Merging: %#v to %#v", value, dstVal)
+			m.log().Debugf("Merging: %#v to %#v", value, dstVal)
 			if !dstVal.CanAddr() {
 				// we can't address dstVal so we need to make a new value
 				// outside the map, merge into the new value, then
@@ -1563,7 +3910,7 @@ func (m *Merger) mergeMaps(dst reflect.Value, src mergeSource, overwrite bool) (
 			changed = changed || ok
 			return nil
 		case dstValKind == reflect.Slice, dstValKind == reflect.Array:
-			Log.Debugf("Merging: %#v to %#v", value, dstVal)
+			m.log().Debugf("Merging: %#v to %#v", value, dstVal)
 			merged, ok, err := m.mergeArrays(dstVal, value, overwrite || m.mustOverwrite(key.String()))
 			if err != nil {
 				return err
@@ -1574,6 +3921,7 @@ func (m *Merger) mergeMaps(dst reflect.Value, src mergeSource, overwrite bool) (
 			changed = changed || ok
 		default:
 			if !isZero(dstVal) {
+				m.recordShadowed(value)
 				return nil
 			}
 			reflected, _, err := value.reflect()
@@ -1584,10 +3932,10 @@ func (m *Merger) mergeMaps(dst reflect.Value, src mergeSource, overwrite bool) (
 				return nil
 			}
 			if !dstVal.IsValid() || reflected.Type().AssignableTo(dstVal.Type()) {
-				dst.SetMapIndex(key, reflected)
+				dst.SetMapIndex(key, m.copyValue(reflected))
 			} else {
 				if srcOption := toOption(reflected); srcOption != nil {
-					dst.SetMapIndex(key, reflect.ValueOf(srcOption.GetValue()))
+					dst.SetMapIndex(key, m.copyValue(reflect.ValueOf(srcOption.GetValue())))
 					return nil
 				}
 				settableDstVal := reflect.New(dstVal.Type()).Elem()
@@ -1673,7 +4021,7 @@ func (m *Merger) mergeArrays(dst reflect.Value, src mergeSource, overwrite bool)
 			notAssignableError{
 				srcType:        reflectedSrc.Type(),
 				dstType:        dst.Type(),
-				sourceLocation: NewSource(m.sourceFile, WithLocation(coord)),
+				sourceLocation: m.newSource(coord),
 			},
 		)
 	}
@@ -1685,11 +4033,15 @@ func (m *Merger) mergeArrays(dst reflect.Value, src mergeSource, overwrite bool)
 	if cp.Len() == 0 {
 		skipDedup = true
 	}
+	var seen *dedupSet
+	if !skipDedup && dst.Kind() == reflect.Slice {
+		seen = newDedupSet(cp)
+	}
 
 	var zero interface{}
 	changed := overwrite
 	err := src.foreach(func(ix int, item mergeSource) error {
-		reflected, _, err := item.reflect()
+		reflected, coord, err := item.reflect()
 		if err != nil {
 			return walky.ErrFilename(err, m.sourceFile)
 		}
@@ -1726,32 +4078,15 @@ func (m *Merger) mergeArrays(dst reflect.Value, src mergeSource, overwrite bool)
 			return nil
 		}
 
-		if !skipDedup {
-			for i := 0; i < cp.Len(); i++ {
-				destElem := cp.Index(i)
-				if destOption := toOption(destElem); destOption != nil {
-					destElem = reflect.ValueOf(destOption.GetValue())
-				}
-
-				// try to assign the input to a tmp value so all the normal
-				// conversions happen before we compare it to existing elements.
-				// Otherwise we might end up with extra dups in the array
-				// that are the same value
-				if destElem.CanInterface() {
-					// ensure the destElem is not a nil value for a pointer type
-					// .. in which case this will return a zero Value, which
-					// cannot have `Type` called on it.
-					if !reflect.ValueOf(destElem.Interface()).IsValid() {
-						continue
-					}
-					tmpVal := reflect.New(reflect.ValueOf(destElem.Interface()).Type()).Elem()
-					_, err := m.assignValue(tmpVal, item, assignOptions{})
-					if err == nil {
-						if reflect.DeepEqual(destElem.Interface(), tmpVal.Interface()) {
-							return nil
-						}
-					}
-				}
+		var key reflect.Value
+		if seen != nil {
+			// convert the input to the element value type so all the normal
+			// conversions happen before we compare it to existing elements.
+			// Otherwise we might end up with extra dups in the array
+			// that are the same value
+			key = m.dedupKey(cp.Type().Elem(), compareValue, item)
+			if seen.contains(key) {
+				return nil
 			}
 		}
 
@@ -1759,14 +4094,14 @@ func (m *Merger) mergeArrays(dst reflect.Value, src mergeSource, overwrite bool)
 		dstKind := dstElem.Kind()
 		switch {
 		case dstKind == reflect.Map, (dstKind == reflect.Struct && !isSpecial(dstElem)):
-			Log.Debugf("Merging: %#v to %#v", reflected, dstElem)
+			m.log().Debugf("Merging: %#v to %#v", reflected, dstElem)
 			ok, err := m.mergeStructs(dstElem, item, overwrite)
 			if err != nil {
 				return errors.WithStack(err)
 			}
 			changed = changed || ok
 		case dstKind == reflect.Slice, dstKind == reflect.Array:
-			Log.Debugf("Merging: %#v to %#v", reflected, dstElem)
+			m.log().Debugf("Merging: %#v to %#v", reflected, dstElem)
 			merged, ok, err := m.mergeArrays(dstElem, item, overwrite)
 			if err != nil {
 				return err
@@ -1783,9 +4118,15 @@ func (m *Merger) mergeArrays(dst reflect.Value, src mergeSource, overwrite bool)
 				return err
 			}
 			changed = changed || ok
+			if dstKind == reflect.Interface {
+				m.normalizeAnyElement(dstElem, coord)
+			}
 		}
 
 		cp = reflect.Append(cp, dstElem)
+		if seen != nil {
+			seen.add(key)
+		}
 		return nil
 	})
 	if err != nil {
@@ -1810,7 +4151,7 @@ func (f *FigTree) formatEnvValue(value reflect.Value) (string, bool) {
 	case string:
 		return t, true
 	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
-		return fmt.Sprintf("%v", t), true
+		return formatScalar(t), true
 	default:
 		switch value.Kind() {
 		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
@@ -1834,7 +4175,7 @@ func (f *FigTree) formatEnvValue(value reflect.Value) (string, bool) {
 			GetValue() interface{}
 		}
 		if get, ok := t.(gettable); ok {
-			return fmt.Sprintf("%v", get.GetValue()), true
+			return formatScalar(get.GetValue()), true
 		} else {
 			if b, err := json.Marshal(t); err == nil {
 				val := strings.TrimSpace(string(b))
@@ -1848,9 +4189,40 @@ func (f *FigTree) formatEnvValue(value reflect.Value) (string, bool) {
 	return "", false
 }
 
+// exportEnv applies the changeSet from PopulateEnv with the ChangeSetFunc,
+// see WithApplyChangeSet.
+func (f *FigTree) exportEnv(changeSet map[string]*string) error {
+	f.exported.record(changeSet, f.getenv)
+	return f.applyChangeSet(changeSet)
+}
+
+// exportEnvKey returns true if the option for key should be exported to the
+// environment, see WithEnvKeyFilter.
+func (f *FigTree) exportEnvKey(key string) bool {
+	return f.envKeyFilter == nil || f.envKeyFilter(key)
+}
+
+// PopulateEnv returns the environment variables for the top level options in
+// data, see WithEnvPrefix and WithEnvKeyFilter.  Nested structs, maps and
+// lists are serialized as JSON with map keys sorted, so the values are
+// identical for identical options.
 func (f *FigTree) PopulateEnv(data interface{}) (changeSet map[string]*string) {
 	changeSet = make(map[string]*string)
+	f.envFields(data, func(envName, key string, value reflect.Value) {
+		val, ok := f.formatEnvValue(value)
+		if ok {
+			changeSet[envName] = &val
+		} else {
+			changeSet[envName] = nil
+		}
+	})
+	return changeSet
+}
 
+// envFields calls fn with the environment variable name, the config key and
+// the value for each of the top level options in data, using the naming
+// rules described in PopulateEnv.
+func (f *FigTree) envFields(data interface{}, fn func(envName, key string, value reflect.Value)) {
 	options := reflect.ValueOf(data)
 	if options.Kind() == reflect.Ptr {
 		options = reflect.ValueOf(options.Elem().Interface())
@@ -1858,6 +4230,9 @@ func (f *FigTree) PopulateEnv(data interface{}) (changeSet map[string]*string) {
 	if options.Kind() == reflect.Map {
 		for _, key := range options.MapKeys() {
 			if strKey, ok := key.Interface().(string); ok {
+				if !f.exportEnvKey(strKey) {
+					continue
+				}
 				// first chunk up string so that `foo-bar` becomes ["foo", "bar"]
 				parts := strings.FieldsFunc(strKey, func(r rune) bool {
 					return !unicode.IsLetter(r) && !unicode.IsNumber(r)
@@ -1870,13 +4245,7 @@ func (f *FigTree) PopulateEnv(data interface{}) (changeSet map[string]*string) {
 				}
 
 				name := strings.Join(allParts, "_")
-				envName := f.formatEnvName(name)
-				val, ok := f.formatEnvValue(options.MapIndex(key))
-				if ok {
-					changeSet[envName] = &val
-				} else {
-					changeSet[envName] = nil
-				}
+				fn(f.formatEnvName(name), strKey, options.MapIndex(key))
 			}
 		}
 	} else if options.Kind() == reflect.Struct {
@@ -1887,6 +4256,9 @@ func (f *FigTree) PopulateEnv(data interface{}) (changeSet map[string]*string) {
 				// unexported field, skipping
 				continue
 			}
+			if !inlineField(structField) && !f.exportEnvKey(yamlFieldName(structField)) {
+				continue
+			}
 
 			envNames := []string{strings.Join(camelcase.Split(structField.Name), "_")}
 			formatName := true
@@ -1895,10 +4267,7 @@ func (f *FigTree) PopulateEnv(data interface{}) (changeSet map[string]*string) {
 					// if we have a tag like: `figtree:",inline"` then we
 					// want to the field as a top level member and not serialize
 					// the raw struct to json, so just recurse here
-					nestedEnvSet := f.PopulateEnv(options.Field(i).Interface())
-					for k, v := range nestedEnvSet {
-						changeSet[k] = v
-					}
+					f.envFields(options.Field(i).Interface(), fn)
 					continue
 				}
 				if strings.Contains(tag, ",raw") {
@@ -1912,10 +4281,13 @@ func (f *FigTree) PopulateEnv(data interface{}) (changeSet map[string]*string) {
 						continue
 					}
 					for _, part := range parts {
-						if strings.HasPrefix(part, "name=") {
+						// skip tag options like `name=`, `sources=` and `merge=`
+						if strings.Contains(part, "=") {
 							continue
 						}
-						envNames = strings.Split(part, ";")
+						if part != "" {
+							envNames = strings.Split(part, ";")
+						}
 						break
 					}
 				}
@@ -1925,15 +4297,8 @@ func (f *FigTree) PopulateEnv(data interface{}) (changeSet map[string]*string) {
 				if formatName {
 					envName = f.formatEnvName(name)
 				}
-				val, ok := f.formatEnvValue(options.Field(i))
-				if ok {
-					changeSet[envName] = &val
-				} else {
-					changeSet[envName] = nil
-				}
+				fn(envName, yamlFieldName(structField), options.Field(i))
 			}
 		}
 	}
-
-	return changeSet
 }
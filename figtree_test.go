@@ -1046,6 +1046,39 @@ func TestMakeMergeStruct(t *testing.T) {
 	assert.Equal(t, input["map"].(map[string]interface{})["mapkey"], reflect.ValueOf(got).Elem().FieldByName("Map").FieldByName("Mapkey").Interface())
 }
 
+func TestMakeMergeStructWithNameTags(t *testing.T) {
+	input := map[string]interface{}{
+		"map-key": "mapval1",
+		"map": map[string]interface{}{
+			"mapkey": "mapval2",
+		},
+	}
+
+	m := NewMerger(WithNameTags())
+	got := m.MakeMergeStruct(input)
+	typ := reflect.TypeOf(got).Elem()
+
+	field, ok := typ.FieldByName("MapKey")
+	require.True(t, ok)
+	assert.Equal(t, reflect.StructTag(`json:"map-key" yaml:"map-key" figtree:",name=MapKey"`), field.Tag)
+	assert.Equal(t, field.Name, CanonicalFieldName(field))
+	field, ok = typ.FieldByName("Map")
+	require.True(t, ok)
+	nested, ok := field.Type.FieldByName("Mapkey")
+	require.True(t, ok)
+	assert.Equal(t, reflect.StructTag(`json:"mapkey" yaml:"mapkey" figtree:",name=Mapkey"`), nested.Tag)
+
+	// the generated struct retains the same fields when merged again
+	again := m.MakeMergeStruct(reflect.ValueOf(got).Elem().Interface(), input)
+	assert.Equal(t, typ, reflect.TypeOf(again).Elem())
+
+	require.NoError(t, Merge(got, &input))
+	assert.Equal(t, "mapval1", reflect.ValueOf(got).Elem().FieldByName("MapKey").Interface())
+
+	schema := m.MakeMergeSchema(input)
+	assert.Equal(t, `json:"map-key" yaml:"map-key" figtree:",name=MapKey"`, schema.Fields[1].Tag)
+}
+
 func TestMakeMergeStructWithDups(t *testing.T) {
 	input := map[string]interface{}{
 		"mapkey": "mapval1",
@@ -2066,6 +2099,69 @@ sub:
 	require.Equal(t, expected, dest)
 }
 
+func TestLoadConfigToNodeMaps(t *testing.T) {
+	type Section struct {
+		Raw map[string]yaml.Node `yaml:"raw"`
+	}
+	type data struct {
+		Sections map[string]yaml.Node            `yaml:"sections"`
+		Nested   Section                         `yaml:"nested"`
+		Deep     map[string]map[string]yaml.Node `yaml:"deep"`
+		List     []map[string]yaml.Node          `yaml:"list"`
+		Name     StringOption                    `yaml:"name"`
+	}
+
+	top, err := SourceFromString("top.yml", `
+sections:
+  a: {x: 1}
+nested:
+  raw:
+    r: [1, 2]
+deep:
+  d1:
+    d2: abc
+list:
+  - l: {y: 2}
+name: top
+`)
+	require.NoError(t, err)
+	bottom, err := SourceFromString("bottom.yml", `
+sections:
+  a: {x: 2}
+  b: def
+deep:
+  d1:
+    d3: ghi
+  d4:
+    d5: jkl
+`)
+	require.NoError(t, err)
+
+	dest := data{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{top, bottom}, &dest)
+	require.NoError(t, err)
+
+	require.Len(t, dest.Sections, 2)
+	assert.Equal(t, yaml.MappingNode, dest.Sections["a"].Kind)
+	assert.Equal(t, 3, dest.Sections["a"].Line)
+	assert.Equal(t, yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "def", Line: 4, Column: 6}, dest.Sections["b"])
+	require.Len(t, dest.Nested.Raw, 1)
+	assert.Equal(t, yaml.SequenceNode, dest.Nested.Raw["r"].Kind)
+	assert.Equal(t, map[string]map[string]yaml.Node{
+		"d1": {
+			"d2": {Kind: yaml.ScalarNode, Tag: "!!str", Value: "abc", Line: 9, Column: 9},
+			"d3": {Kind: yaml.ScalarNode, Tag: "!!str", Value: "ghi", Line: 7, Column: 9},
+		},
+		"d4": {
+			"d5": {Kind: yaml.ScalarNode, Tag: "!!str", Value: "jkl", Line: 9, Column: 9},
+		},
+	}, dest.Deep)
+	require.Len(t, dest.List, 1)
+	assert.Equal(t, 11, dest.List[0]["l"].Line)
+	assert.Equal(t, StringOption{tSrc("top.yml", 12, 7), true, "top"}, dest.Name)
+}
+
 type UnmarshalInt int
 
 func (t *UnmarshalInt) UnmarshalYAML(unmarshal func(any) error) error {
@@ -3443,3 +3539,140 @@ func TestMergeWithDstStructArg(t *testing.T) {
 	err := Merge(dest, src)
 	require.Error(t, err)
 }
+
+func TestLoadConfigPreserveAnchors(t *testing.T) {
+	type data struct {
+		Base    yaml.Node            `yaml:"base"`
+		Derived yaml.Node            `yaml:"derived"`
+		List    []yaml.Node          `yaml:"list"`
+		Nested  map[string]yaml.Node `yaml:"nested"`
+	}
+
+	src, err := SourceFromString("config.yml", `
+base: &base
+  a: 1
+derived: *base
+list:
+  - *base
+nested:
+  inner: *base
+`)
+	require.NoError(t, err)
+
+	// by default aliases are expanded
+	dest := data{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &dest)
+	require.NoError(t, err)
+	assert.Equal(t, yaml.MappingNode, dest.Derived.Kind)
+
+	dest = data{}
+	fig = newFigTreeFromEnv(WithPreserveAnchors())
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &dest)
+	require.NoError(t, err)
+	assert.Equal(t, yaml.MappingNode, dest.Base.Kind)
+	assert.Equal(t, "base", dest.Base.Anchor)
+	assert.Equal(t, yaml.AliasNode, dest.Derived.Kind)
+
+	got, err := yaml.Marshal(dest)
+	require.NoError(t, err)
+	expected := `base: &base
+    a: 1
+derived: *base
+list:
+    - *base
+nested:
+    inner: *base
+`
+	assert.Equal(t, expected, string(got))
+}
+
+func TestLoadConfigIntegerOverflow(t *testing.T) {
+	type data struct {
+		Small  int8            `yaml:"small"`
+		Port   uint16          `yaml:"port"`
+		Limit  Option[int16]   `yaml:"limit"`
+		Ratio  Option[float32] `yaml:"ratio"`
+		Counts []Option[uint8] `yaml:"counts"`
+	}
+	for _, tt := range []struct {
+		config string
+		err    string
+	}{
+		{"small: 300\n", "test:1:8: 300 overflows int8"},
+		{"port: 70000\n", "test:1:7: 70000 overflows uint16"},
+		{"port: -1\n", "test:1:7: -1 overflows uint16"},
+		{"limit: 40000\n", "test:1:8: 40000 overflows int16"},
+		{"ratio: 1e300\n", "test:1:8: 1e+300 overflows float32"},
+		{"counts: [1, 256]\n", "test:1:13: 256 overflows uint8"},
+	} {
+		t.Run(tt.config, func(t *testing.T) {
+			source, err := SourceFromString("test", tt.config)
+			require.NoError(t, err)
+			fig := newFigTreeFromEnv()
+			got := data{}
+			err = fig.LoadAllConfigSources([]ConfigSource{source}, &got)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	source, err := SourceFromString("test", "small: -128\nport: 65535\nlimit: 32767\nratio: 0.5\ncounts: [255]\n")
+	require.NoError(t, err)
+	got := data{}
+	require.NoError(t, newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{source}, &got))
+	assert.Equal(t, int8(-128), got.Small)
+	assert.Equal(t, uint16(65535), got.Port)
+	assert.Equal(t, int16(32767), got.Limit.Value)
+	assert.Equal(t, float32(0.5), got.Ratio.Value)
+	assert.Equal(t, uint8(255), got.Counts[0].Value)
+}
+
+func TestFloatFormatting(t *testing.T) {
+	assert.Equal(t, "3.33", formatScalar(float32(3.33)))
+	assert.Equal(t, "0.1", formatScalar(0.1))
+	assert.Equal(t, "1e+21", formatScalar(1e21))
+
+	type data struct {
+		Name  string        `yaml:"name"`
+		Ratio Float32Option `yaml:"ratio"`
+	}
+	// float32 option values are stringified without float64 noise
+	src := data{Ratio: NewFloat32Option(3.33)}
+	dst := map[string]string{}
+	require.NoError(t, Merge(&dst, &src))
+	assert.Equal(t, "3.33", dst["ratio"])
+
+	fig := newFigTreeFromEnv()
+	StringifyValue = true
+	defer func() {
+		StringifyValue = false
+	}()
+	changeSet := fig.PopulateEnv(&src)
+	assert.Equal(t, "3.33", *changeSet["FIGTREE_RATIO"])
+}
+
+func TestLoadConfigWithDecimalComma(t *testing.T) {
+	type data struct {
+		Ratio Float64Option `yaml:"ratio"`
+		Scale float32       `yaml:"scale"`
+	}
+	source, err := SourceFromString("test", "ratio: \"1,5\"\nscale: \"2.25\"\n")
+	require.NoError(t, err)
+
+	got := data{}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{source}, &got)
+	require.Error(t, err)
+
+	got = data{}
+	fig := newFigTreeFromEnv(WithMergeOptions(WithDecimalComma()))
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{source}, &got))
+	assert.Equal(t, Float64Option{tSrc("test", 1, 8), true, 1.5}, got.Ratio)
+	assert.Equal(t, float32(2.25), got.Scale)
+
+	bad, err := SourceFromString("test", "ratio: \"1,5,0\"\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{bad}, &data{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid float value "1,5,0"`)
+}
@@ -0,0 +1,55 @@
+// Package figtreetest provides utilities to test config file cascades
+// without config files on disk.
+package figtreetest
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"testing/fstest"
+
+	"github.com/coryb/figtree"
+)
+
+// Tree is a virtual directory tree of config files, keyed by absolute slash
+// separated path, like:
+//
+//	figtreetest.Tree{
+//		"/etc/myapp.yml":          "color: never\n",
+//		"/home/bob/myapp.yml":     "color: always\n",
+//		"/home/bob/repo/myapp.yml": "name: repo\n",
+//	}
+type Tree map[string]string
+
+// FS returns the tree as an fs.FS suitable for figtree.WithFS.
+func (t Tree) FS() fs.FS {
+	fsys := fstest.MapFS{}
+	for name, content := range t {
+		fsys[strings.TrimPrefix(path.Clean(name), "/")] = &fstest.MapFile{
+			Data: []byte(content),
+			Mode: 0o644,
+		}
+	}
+	return fsys
+}
+
+// FigTree returns a FigTree that reads config files from the tree with cwd
+// as the working directory.  It does not use the process environment: the
+// home directory is unset unless figtree.WithHome is used, and the
+// environment variables for the options are not set.  Additional options
+// are applied after the defaults.
+func (t Tree) FigTree(cwd string, opts ...figtree.CreateOption) *figtree.FigTree {
+	defaults := []figtree.CreateOption{
+		figtree.WithFS(t.FS()),
+		figtree.WithCwd(cwd),
+		figtree.WithEnviron(func(string) string { return "" }),
+		figtree.WithApplyChangeSet(func(map[string]*string) error { return nil }),
+	}
+	return figtree.NewFigTree(append(defaults, opts...)...)
+}
+
+// LoadAllConfigs loads configFile from the tree into options, as if the
+// working directory was cwd, see Tree.FigTree.
+func (t Tree) LoadAllConfigs(cwd, configFile string, options any, opts ...figtree.CreateOption) error {
+	return t.FigTree(cwd, opts...).LoadAllConfigs(configFile, options)
+}
@@ -0,0 +1,52 @@
+package figtreetest
+
+import (
+	"testing"
+
+	"github.com/coryb/figtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeLoadAllConfigs(t *testing.T) {
+	figtree.StringifyValue = false
+	type Options struct {
+		Color figtree.StringOption     `yaml:"color"`
+		Name  figtree.StringOption     `yaml:"name"`
+		Tags  figtree.ListStringOption `yaml:"tags"`
+	}
+	tree := Tree{
+		"/etc/myapp.yml":            "color: never\ntags: [etc]\n",
+		"/home/bob/myapp.yml":       "color: always\ntags: [home]\n",
+		"/work/repo/myapp.yml":      "name: repo\ntags: [repo]\n",
+		"/work/repo/sub/myapp.yml":  "name: sub\n",
+		"/work/other/myapp.yml":     "name: other\n",
+		"/work/repo/sub/README.txt": "not a config\n",
+	}
+
+	opts := Options{}
+	err := tree.LoadAllConfigs("/work/repo/sub", "myapp.yml", &opts, figtree.WithHome("/home/bob"))
+	require.NoError(t, err)
+	assert.Equal(t, "always", opts.Color.Value)
+	assert.Equal(t, "../../../home/bob/myapp.yml:1:8", opts.Color.Source.String())
+	assert.Equal(t, "sub", opts.Name.Value)
+	assert.Equal(t, []string{"repo", "home", "etc"}, []string{
+		opts.Tags[0].Value, opts.Tags[1].Value, opts.Tags[2].Value,
+	})
+
+	// without a home directory
+	opts = Options{}
+	require.NoError(t, tree.LoadAllConfigs("/work/other", "myapp.yml", &opts))
+	assert.Equal(t, "never", opts.Color.Value)
+	assert.Equal(t, "other", opts.Name.Value)
+
+	// the extensions are probed in the tree
+	opts = Options{}
+	require.NoError(t, tree.LoadAllConfigs("/work/repo", "myapp", &opts))
+	assert.Equal(t, "repo", opts.Name.Value)
+
+	// nothing is read from the real file system
+	opts = Options{}
+	require.NoError(t, tree.LoadAllConfigs("/tmp", "figtree.yml", &opts))
+	assert.False(t, opts.Name.IsDefined())
+}
@@ -0,0 +1,110 @@
+package figtreetest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/coryb/figtree"
+)
+
+// UpdateGolden makes Golden write the golden files rather than compare them,
+// it is true when the FIGTREETEST_UPDATE environment variable is set to a
+// true value, like `FIGTREETEST_UPDATE=1 go test ./...`.  Test packages may
+// also set it from their own flag:
+//
+//	func TestMain(m *testing.M) {
+//		flag.BoolVar(&figtreetest.UpdateGolden, "update", false, "update golden files")
+//		flag.Parse()
+//		os.Exit(m.Run())
+//	}
+var UpdateGolden, _ = strconv.ParseBool(os.Getenv("FIGTREETEST_UPDATE"))
+
+// GoldenFormat is a serialization of options compared by Golden.
+type GoldenFormat struct {
+	// Extension is appended to the golden file name, like ".yml".
+	Extension string
+	Marshal   func(options any) ([]byte, error)
+}
+
+var (
+	// GoldenYAML is the options as YAML, see figtree.Marshal.
+	GoldenYAML = GoldenFormat{
+		Extension: ".yml",
+		Marshal: func(options any) ([]byte, error) {
+			return figtree.Marshal(options, figtree.WithIndent(2))
+		},
+	}
+	// GoldenYAMLWithSources is the options as YAML with the source of
+	// each option.
+	GoldenYAMLWithSources = GoldenFormat{
+		Extension: ".sources.yml",
+		Marshal: func(options any) ([]byte, error) {
+			return figtree.Marshal(options, figtree.WithIndent(2), figtree.WithSources())
+		},
+	}
+	// GoldenJSON is the options as indented JSON, see figtree.MarshalJSON.
+	GoldenJSON = GoldenFormat{
+		Extension: ".json",
+		Marshal: func(options any) ([]byte, error) {
+			return marshalJSON(options)
+		},
+	}
+	// GoldenJSONWithSources is the options as indented JSON with the source
+	// of each option.
+	GoldenJSONWithSources = GoldenFormat{
+		Extension: ".sources.json",
+		Marshal: func(options any) ([]byte, error) {
+			return marshalJSON(options, figtree.WithSources())
+		},
+	}
+)
+
+func marshalJSON(options any, opts ...figtree.EncodeOption) ([]byte, error) {
+	data, err := figtree.MarshalJSON(options, append(opts, figtree.WithIndent(2))...)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Golden compares the options serialized in each format with the golden
+// files named `testdata/<name><extension>`, all four Golden formats are
+// compared when no formats are given.  When UpdateGolden is set the golden
+// files are written instead.
+//
+// The serialized options are stable: map keys are sorted and struct fields
+// are in declaration order, so golden files only change when the loaded
+// options change.
+func Golden(t testing.TB, name string, options any, formats ...GoldenFormat) {
+	t.Helper()
+	if len(formats) == 0 {
+		formats = []GoldenFormat{GoldenYAML, GoldenYAMLWithSources, GoldenJSON, GoldenJSONWithSources}
+	}
+	for _, format := range formats {
+		file := filepath.Join("testdata", name+format.Extension)
+		got, err := format.Marshal(options)
+		if err != nil {
+			t.Fatalf("failed to serialize options for %s: %s", file, err)
+		}
+		if UpdateGolden {
+			if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				t.Fatalf("failed to create golden file directory: %s", err)
+			}
+			if err := os.WriteFile(file, got, 0o644); err != nil {
+				t.Fatalf("failed to update golden file: %s", err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(file)
+		if err != nil {
+			t.Errorf("failed to read golden file, set FIGTREETEST_UPDATE=1 to create it: %s", err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("options do not match golden file %s, set FIGTREETEST_UPDATE=1 to update it\ngot:\n%s\nwant:\n%s", file, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package figtreetest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coryb/figtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type goldenOptions struct {
+	Color figtree.StringOption            `yaml:"color"`
+	Tags  figtree.ListStringOption        `yaml:"tags"`
+	Env   map[string]figtree.StringOption `yaml:"env"`
+	Count figtree.IntOption               `yaml:"count"`
+}
+
+func loadGoldenOptions(t *testing.T) goldenOptions {
+	t.Helper()
+	tree := Tree{
+		"/etc/myapp.yml":       "color: never\nenv:\n  b: etc\n  a: etc\n",
+		"/work/repo/myapp.yml": "tags: [repo, shared]\nenv:\n  c: repo\ncount: 2\n",
+	}
+	opts := goldenOptions{}
+	require.NoError(t, tree.LoadAllConfigs("/work/repo", "myapp.yml", &opts))
+	return opts
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, "golden", loadGoldenOptions(t))
+}
+
+// recordingTB records the failures reported by Golden.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestGoldenMismatch(t *testing.T) {
+	if UpdateGolden {
+		t.Skip("golden files are being updated")
+	}
+	opts := loadGoldenOptions(t)
+	opts.Count = figtree.NewIntOption(3)
+
+	tb := &recordingTB{TB: t}
+	Golden(tb, "golden", opts, GoldenYAML, GoldenJSON)
+	require.Len(t, tb.errors, 2)
+	assert.Contains(t, tb.errors[0], "options do not match golden file testdata/golden.yml, set FIGTREETEST_UPDATE=1 to update it")
+	assert.Contains(t, tb.errors[0], "count: 3")
+	assert.Contains(t, tb.errors[1], "testdata/golden.json")
+
+	tb = &recordingTB{TB: t}
+	Golden(tb, "missing", opts, GoldenYAML)
+	require.Len(t, tb.errors, 1)
+	assert.Contains(t, tb.errors[0], "set FIGTREETEST_UPDATE=1 to create it")
+}
@@ -0,0 +1,60 @@
+package figtree
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+
+	"emperror.dev/errors"
+)
+
+// redactedSecret replaces secret values in fingerprints when no salt is
+// configured.
+const redactedSecret = "<secret>"
+
+// WithFingerprintSalt sets the salt used to include secret values in
+// Fingerprint.  Secrets are included as an HMAC of the value keyed by salt,
+// so replicas sharing the salt get the same fingerprint without exposing the
+// secret.  Without a salt secrets are redacted, so changes to secret values
+// do not change the fingerprint.
+func WithFingerprintSalt(salt []byte) CreateOption {
+	return func(f *FigTree) {
+		f.fingerprintSalt = append([]byte{}, salt...)
+	}
+}
+
+func (f *FigTree) WithFingerprintSalt(salt []byte) {
+	WithFingerprintSalt(salt)(f)
+}
+
+// Fingerprint returns a stable hex encoded sha256 digest of the values in
+// options, for reporting a config version or detecting drift between
+// replicas.  Only the values contribute, not the sources they were loaded
+// from, and map keys are hashed in sorted order so equal options always
+// have the same fingerprint.  Secret values decrypted from the configs are
+// salted or redacted, see WithFingerprintSalt.
+func (f *FigTree) Fingerprint(options any) (string, error) {
+	n := normalizer{visiting: map[uintptr]bool{}, secret: f.fingerprintSecret}
+	// encoding/json sorts map keys, giving a canonical encoding
+	data, err := json.Marshal(n.normalize(reflect.ValueOf(options)))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode options for fingerprint")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (f *FigTree) fingerprintSecret(value any) any {
+	if len(f.fingerprintSalt) == 0 {
+		return redactedSecret
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return redactedSecret
+	}
+	mac := hmac.New(sha256.New, f.fingerprintSalt)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
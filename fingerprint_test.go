@@ -0,0 +1,57 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	fig := newFigTreeFromEnv()
+	fingerprint := func(opts TestOptions) string {
+		t.Helper()
+		fp, err := fig.Fingerprint(&opts)
+		require.NoError(t, err)
+		return fp
+	}
+
+	opts := TestOptions{
+		String1: StringOption{tSrc("a.yml", 1, 7), true, "value"},
+		Map1: MapStringOption{
+			"a": NewStringOption("1"),
+			"b": NewStringOption("2"),
+			"c": NewStringOption("3"),
+		},
+	}
+	fp := fingerprint(opts)
+	assert.Len(t, fp, 64)
+
+	// sources do not change the fingerprint
+	other := DeepCopy(opts)
+	other.String1.Source = tSrc("b.yml", 3, 9)
+	assert.Equal(t, fp, fingerprint(other))
+
+	// values do
+	other.Map1["b"] = NewStringOption("changed")
+	assert.NotEqual(t, fp, fingerprint(other))
+
+	// secrets are redacted without a salt
+	secret := func(value string) StringOption {
+		o := NewStringOption(value)
+		o.Source.Secret = true
+		return o
+	}
+	opts.LeaveEmpty = secret("hunter2")
+	fp = fingerprint(opts)
+	opts.LeaveEmpty = secret("hunter3")
+	assert.Equal(t, fp, fingerprint(opts))
+
+	// and salted with a salt
+	fig = newFigTreeFromEnv(WithFingerprintSalt([]byte("salt")))
+	opts.LeaveEmpty = secret("hunter2")
+	fp = fingerprint(opts)
+	assert.Equal(t, fp, fingerprint(opts))
+	opts.LeaveEmpty = secret("hunter3")
+	assert.NotEqual(t, fp, fingerprint(opts))
+}
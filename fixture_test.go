@@ -0,0 +1,39 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceFromFixtures(t *testing.T) {
+	top, err := SourceFromString("top.yml", "str1: top\nint1: 1\n")
+	require.NoError(t, err)
+	override, err := SourceFromMap("override.yml", map[string]any{
+		"str1": "override",
+		"map1": map[string]any{"key1": "val1"},
+		"arr1": []string{"a"},
+	})
+	require.NoError(t, err)
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{override, top}, &opts)
+	require.NoError(t, err)
+
+	expected := TestOptions{
+		String1: StringOption{tSrc("override.yml", 5, 7), true, "override"},
+		Array1: ListStringOption{
+			StringOption{tSrc("override.yml", 2, 7), true, "a"},
+		},
+		Map1: map[string]StringOption{
+			"key1": {tSrc("override.yml", 4, 11), true, "val1"},
+		},
+		Int1: IntOption{tSrc("top.yml", 2, 7), true, 1},
+	}
+	assert.Exactly(t, expected, opts)
+
+	_, err = SourceFromString("bad.yml", "str1: [")
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package figtree
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestFlagProvider(t *testing.T) {
+	type server struct {
+		Host StringOption `yaml:"host"`
+		Port IntOption    `yaml:"port"`
+	}
+	type options struct {
+		Name    StringOption `yaml:"name"`
+		Feature BoolOption   `yaml:"feature"`
+		Server  server       `yaml:"server"`
+		Missing StringOption `yaml:"missing"`
+	}
+
+	var config yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("name: file\nfeature: false\nserver:\n  host: localhost\n  port: 80\n"), &config))
+
+	flags := map[string]any{
+		"name":        "flag",
+		"feature":     true,
+		"server.port": 8080,
+	}
+	provider := FlagProviderFunc(func(key string) (any, bool, error) {
+		v, ok := flags[key]
+		return v, ok, nil
+	})
+
+	opts := options{}
+	fig := newFigTreeFromEnv(
+		WithOverrides(map[string]any{"name": "override"}),
+		WithFlagProvider(provider, "name", "feature", "server.port", "missing"),
+	)
+	err := fig.LoadAllConfigSources([]ConfigSource{{Config: &config, Filename: "figtree.yml"}}, &opts)
+	require.NoError(t, err)
+
+	expected := options{
+		Name:    StringOption{NewSource("override"), true, "override"},
+		Feature: BoolOption{NewSource("flag:feature"), true, true},
+		Server: server{
+			Host: StringOption{tSrc("figtree.yml", 4, 9), true, "localhost"},
+			Port: IntOption{NewSource("flag:server.port"), true, 8080},
+		},
+	}
+	assert.Equal(t, expected, opts)
+
+	fig = newFigTreeFromEnv(WithFlagProvider(FlagProviderFunc(func(key string) (any, bool, error) {
+		return nil, false, errors.New("unavailable")
+	}), "name"))
+	err = fig.LoadAllConfigSources(nil, &opts)
+	assert.EqualError(t, err, `failed to get value for flag "name": unavailable`)
+}
@@ -0,0 +1,376 @@
+package figtree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// NegatedBoolOption is a command line flag value that will set the wrapped
+// BoolOption to the inverse of the parsed value.  Kingpin automatically
+// provides `--no-<flag>` forms for BoolOption flags via IsBoolFlag, this can
+// be used to register explicit negation flags with command line libraries that
+// do not, like pflag:
+//
+//	flags.Var(figtree.NegateBoolOption(&opts.Feature), "no-feature", "disable feature")
+//	flags.Lookup("no-feature").NoOptDefVal = "true"
+type NegatedBoolOption struct {
+	option *BoolOption
+}
+
+// NegateBoolOption returns a NegatedBoolOption for o.
+func NegateBoolOption(o *BoolOption) *NegatedBoolOption {
+	return &NegatedBoolOption{option: o}
+}
+
+// Set implements part of the Value interface as defined by the kingpin command
+// line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+func (n *NegatedBoolOption) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	n.option.Value = !b
+	n.option.Source = OverrideSource
+	n.option.Defined = true
+	return nil
+}
+
+// String implements part of the Value interface as defined by the kingpin
+// command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+func (n *NegatedBoolOption) String() string {
+	if n.option == nil {
+		return "false"
+	}
+	return fmt.Sprint(!n.option.Value)
+}
+
+// IsBoolFlag implements part of the boolFlag interface as defined by the
+// kingpin command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L42-L45
+func (n *NegatedBoolOption) IsBoolFlag() bool {
+	return true
+}
+
+// Type implements part of the Value interface as defined by the pflag
+// command line option library:
+// https://github.com/spf13/pflag/blob/v1.0.5/flag.go#L187-L191
+func (n *NegatedBoolOption) Type() string {
+	return "bool"
+}
+
+// CountOption is an IntOption that is incremented each time the command line
+// flag is provided, for example `-v -v -v` for verbosity levels.  The count
+// starts from any value already loaded from config files, so a config file
+// with `verbose: 1` and a command line with `-vv` results in a value of 3.
+type CountOption struct {
+	Option[int]
+}
+
+// NewCountOption returns a CountOption with the default value dflt.
+func NewCountOption(dflt int) CountOption {
+	return CountOption{NewOption(dflt)}
+}
+
+// Set implements part of the Value interface as defined by the kingpin command
+// line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+// Boolean true values (and "+1" as used by pflag) will increment the count,
+// false will reset the count to zero and integer values set the count.
+func (o *CountOption) Set(s string) error {
+	switch s {
+	case "", "+1":
+		o.Value++
+	default:
+		if b, err := strconv.ParseBool(s); err == nil {
+			if b {
+				o.Value++
+			} else {
+				o.Value = 0
+			}
+		} else if err := convertString(s, &o.Value); err != nil {
+			return err
+		}
+	}
+	o.Source = OverrideSource
+	o.Defined = true
+	return nil
+}
+
+// IsBoolFlag implements part of the boolFlag interface as defined by the
+// kingpin command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L42-L45
+func (o CountOption) IsBoolFlag() bool {
+	return true
+}
+
+// IsCumulative implements part of the repeatableFlag interface as defined by
+// the kingpin command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L49-L52
+func (o CountOption) IsCumulative() bool {
+	return true
+}
+
+// Type implements part of the Value interface as defined by the pflag
+// command line option library:
+// https://github.com/spf13/pflag/blob/v1.0.5/flag.go#L187-L191
+func (o CountOption) Type() string {
+	return "count"
+}
+
+// MapFlagOption configures how a MapFlagValue parses command line values.
+type MapFlagOption func(*mapFlagConfig)
+
+type mapFlagConfig struct {
+	delimiters string
+	nested     string
+	infer      bool
+}
+
+// WithMapDelimiters sets the characters that separate the key from the value,
+// the value is split at the first occurrence of any of the delimiters so the
+// value itself may contain delimiters.  The default delimiters are `=` and `:`,
+// an empty delimiters string keeps the defaults.
+func WithMapDelimiters(delimiters string) MapFlagOption {
+	return func(c *mapFlagConfig) {
+		if delimiters != "" {
+			c.delimiters = delimiters
+		}
+	}
+}
+
+// WithNestedKeys will split keys on sep to create nested maps, so
+// `server.tls.port=8443` will set the "port" key in the "tls" map of the
+// "server" value.  Intermediate maps are created as needed and existing sibling
+// keys are preserved.  Nested keys require a MapOption[any] or
+// MapOption[map[string]any].
+func WithNestedKeys(sep string) MapFlagOption {
+	return func(c *mapFlagConfig) {
+		c.nested = sep
+	}
+}
+
+// WithTypeInference will store integer, float and boolean values as int,
+// float64 and bool rather than strings, for MapOption[any] and nested keys.
+// Other values, and values for other MapOption types, are unchanged.
+func WithTypeInference() MapFlagOption {
+	return func(c *mapFlagConfig) {
+		c.infer = true
+	}
+}
+
+// MapFlagValue is a command line flag value for a MapOption with configurable
+// parsing, see NewMapFlag.
+type MapFlagValue[T any] struct {
+	option *MapOption[T]
+	config mapFlagConfig
+}
+
+// NewMapFlag returns a command line flag value that will parse KEY=VALUE
+// arguments into o according to opts.  With no opts this behaves the same as
+// using the MapOption directly as a flag value.
+func NewMapFlag[T any](o *MapOption[T], opts ...MapFlagOption) *MapFlagValue[T] {
+	m := &MapFlagValue[T]{
+		option: o,
+		config: mapFlagConfig{
+			delimiters: "=:",
+		},
+	}
+	for _, opt := range opts {
+		opt(&m.config)
+	}
+	return m
+}
+
+// Set implements part of the Value interface as defined by the kingpin command
+// line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+func (m *MapFlagValue[T]) Set(value string) error {
+	ix := strings.IndexAny(value, m.config.delimiters)
+	if ix < 0 {
+		return errors.Errorf("expected KEY%sVALUE got '%s'", m.config.delimiters[:1], value)
+	}
+	key, val := value[:ix], value[ix+1:]
+	if *m.option == nil {
+		*m.option = MapOption[T]{}
+	}
+	if m.config.nested == "" || !strings.Contains(key, m.config.nested) {
+		opt := Option[T]{}
+		if err := opt.Set(val); err != nil {
+			return err
+		}
+		if v, ok := any(&opt.Value).(*any); ok && m.config.infer {
+			*v = inferScalar(val)
+		}
+		(*m.option)[key] = opt
+		return nil
+	}
+
+	path := strings.Split(key, m.config.nested)
+	var root any = (*m.option)[path[0]].Value
+	var leaf any = val
+	if m.config.infer {
+		leaf = inferScalar(val)
+	}
+	nested, err := setNestedKey(root, path[1:], leaf)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %q", key)
+	}
+	typed, ok := any(nested).(T)
+	if !ok {
+		var zero T
+		return errors.Errorf("nested key %q requires a map value type, got %T", key, zero)
+	}
+	(*m.option)[path[0]] = Option[T]{
+		Source:  OverrideSource,
+		Defined: true,
+		Value:   typed,
+	}
+	return nil
+}
+
+// setNestedKey will set the leaf value at path within current, creating
+// intermediate maps as needed. The updated map is returned.
+func setNestedKey(current any, path []string, leaf any) (map[string]any, error) {
+	m, ok := current.(map[string]any)
+	if !ok {
+		if current != nil {
+			return nil, errors.Errorf("cannot set key %q on non-map value %T", path[0], current)
+		}
+		m = map[string]any{}
+	} else {
+		// copy the map so we do not mutate values that may be shared
+		// with config sources
+		cp := make(map[string]any, len(m)+1)
+		for k, v := range m {
+			cp[k] = v
+		}
+		m = cp
+	}
+	if len(path) == 1 {
+		m[path[0]] = leaf
+		return m, nil
+	}
+	child, err := setNestedKey(m[path[0]], path[1:], leaf)
+	if err != nil {
+		return nil, err
+	}
+	m[path[0]] = child
+	return m, nil
+}
+
+// String implements part of the Value interface as defined by the kingpin
+// command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+func (m *MapFlagValue[T]) String() string {
+	if m.option == nil {
+		return ""
+	}
+	return m.option.String()
+}
+
+// IsCumulative implements part of the remainderArg interface as defined by the
+// kingpin command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L49-L52
+func (m *MapFlagValue[T]) IsCumulative() bool {
+	return true
+}
+
+// Type implements part of the Value interface as defined by the pflag
+// command line option library:
+// https://github.com/spf13/pflag/blob/v1.0.5/flag.go#L187-L191
+func (m *MapFlagValue[T]) Type() string {
+	return "map"
+}
+
+// InferredFlagValue is a command line flag value for an Option[any] or
+// ListOption[any] that stores integer, float and boolean arguments as int,
+// float64 and bool values rather than strings.  Other arguments are stored
+// as strings, the same as using the option directly as a flag value.
+type InferredFlagValue struct {
+	option *Option[any]
+	list   *ListOption[any]
+}
+
+// NewInferredFlag returns a command line flag value for o that infers the
+// type of the argument, see InferredFlagValue.
+func NewInferredFlag(o *Option[any]) *InferredFlagValue {
+	return &InferredFlagValue{option: o}
+}
+
+// NewInferredListFlag returns a command line flag value for o that infers
+// the type of each argument, see InferredFlagValue.
+func NewInferredListFlag(o *ListOption[any]) *InferredFlagValue {
+	return &InferredFlagValue{list: o}
+}
+
+// Set implements part of the Value interface as defined by the kingpin command
+// line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+func (v *InferredFlagValue) Set(s string) error {
+	opt := Option[any]{
+		Source:  OverrideSource,
+		Defined: true,
+		Value:   inferScalar(s),
+	}
+	if v.list != nil {
+		*v.list = append(*v.list, opt)
+		return nil
+	}
+	*v.option = opt
+	return nil
+}
+
+// String implements part of the Value interface as defined by the kingpin
+// command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+func (v *InferredFlagValue) String() string {
+	if v.list != nil {
+		return v.list.String()
+	}
+	if v.option == nil {
+		return ""
+	}
+	return v.option.String()
+}
+
+// IsCumulative implements part of the remainderArg interface as defined by the
+// kingpin command line option library:
+// https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L49-L52
+func (v *InferredFlagValue) IsCumulative() bool {
+	return v.list != nil
+}
+
+// Type implements part of the Value interface as defined by the pflag
+// command line option library:
+// https://github.com/spf13/pflag/blob/v1.0.5/flag.go#L187-L191
+func (v *InferredFlagValue) Type() string {
+	if v.list != nil {
+		return "list"
+	}
+	return "value"
+}
+
+// inferScalar returns s as an int, float64 or bool if it is a decimal
+// integer, a float or `true`/`false`, otherwise s is returned unchanged.
+func inferScalar(s string) any {
+	if i, err := strconv.ParseInt(s, 10, 0); err == nil {
+		return int(i)
+	}
+	if strings.ContainsAny(s, "0123456789") {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return s
+}
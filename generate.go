@@ -0,0 +1,213 @@
+package figtree
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"unicode"
+
+	"emperror.dev/errors"
+	"github.com/coryb/walky"
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateOptions control the Go source generated by GenerateStruct.
+type GenerateOptions struct {
+	// StructName is the name of the generated struct type, the default is
+	// "Options".
+	StructName string
+}
+
+type GenerateOption func(*GenerateOptions)
+
+// WithStructName sets the name of the struct type generated by
+// GenerateStruct.
+func WithStructName(name string) GenerateOption {
+	return func(o *GenerateOptions) {
+		o.StructName = name
+	}
+}
+
+// GenerateStruct returns formatted Go source for a struct type that can be
+// used to load config files like the example config in node.  Scalar values
+// become the matching figtree Option type, lists of scalars become ListOption
+// types and maps become nested structs with json and yaml tags for each key.
+// Values with mixed or unknown types use RawTypeOption.  This is intended as
+// a development time utility to scaffold a typed options struct, the
+// generated code is expected to be reviewed and adjusted.
+func GenerateStruct(node *yaml.Node, opts ...GenerateOption) (string, error) {
+	o := GenerateOptions{StructName: "Options"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	node = walky.UnwrapDocument(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return "", errors.New("example config must be a map")
+	}
+	t, err := inferGenType(node)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "type %s ", o.StructName)
+	t.write(buf)
+	buf.WriteString("\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to format generated struct")
+	}
+	return string(src), nil
+}
+
+// genType is the inferred type for a value in the example config.
+type genType struct {
+	// scalar is the Option type suffix for scalar values, like "String"
+	scalar string
+	// list is the element type for lists
+	list *genType
+	// fields are the fields for structs, in the order found
+	fields []genField
+}
+
+type genField struct {
+	name string
+	key  string
+	typ  *genType
+}
+
+var rawGenType = &genType{scalar: "RawType"}
+
+func scalarGenType(node *yaml.Node) *genType {
+	switch node.ShortTag() {
+	case "!!str", "!!timestamp", "!!binary":
+		return &genType{scalar: "String"}
+	case "!!int":
+		return &genType{scalar: "Int"}
+	case "!!float":
+		return &genType{scalar: "Float64"}
+	case "!!bool":
+		return &genType{scalar: "Bool"}
+	}
+	return rawGenType
+}
+
+func inferGenType(node *yaml.Node) (*genType, error) {
+	node = walky.Indirect(node)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return scalarGenType(node), nil
+	case yaml.SequenceNode:
+		var elem *genType
+		for _, item := range node.Content {
+			t, err := inferGenType(item)
+			if err != nil {
+				return nil, err
+			}
+			elem = mergeGenTypes(elem, t)
+		}
+		if elem == nil {
+			elem = rawGenType
+		}
+		return &genType{list: elem}, nil
+	case yaml.MappingNode:
+		t := &genType{fields: []genField{}}
+		err := walky.RangeMap(node, func(keyNode, valueNode *yaml.Node) error {
+			ft, err := inferGenType(valueNode)
+			if err != nil {
+				return err
+			}
+			t.addField(keyNode.Value, ft)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	return nil, errors.Errorf("unsupported yaml node at line %d, column %d", node.Line, node.Column)
+}
+
+// addField adds a field for the map key, if the key has already been added
+// then the field types are merged.
+func (t *genType) addField(key string, ft *genType) {
+	for i, f := range t.fields {
+		if f.key == key {
+			t.fields[i].typ = mergeGenTypes(f.typ, ft)
+			return
+		}
+	}
+	name := genFieldName(key)
+	// ensure the field names are unique, different keys can have the
+	// same camel case name.
+	unique := name
+	for i := 2; t.hasFieldName(unique); i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	t.fields = append(t.fields, genField{name: unique, key: key, typ: ft})
+}
+
+func (t *genType) hasFieldName(name string) bool {
+	for _, f := range t.fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func genFieldName(key string) string {
+	name := camelCase(key)
+	if name == "" {
+		return "Field"
+	}
+	if !unicode.IsLetter([]rune(name)[0]) {
+		return "X" + name
+	}
+	return name
+}
+
+// mergeGenTypes returns a type that can hold values of both a and b, used
+// for list elements and repeated keys.
+func mergeGenTypes(a, b *genType) *genType {
+	switch {
+	case a == nil:
+		return b
+	case a.scalar != "" && b.scalar != "":
+		if a.scalar == b.scalar {
+			return a
+		}
+		if a.scalar == "Int" && b.scalar == "Float64" || a.scalar == "Float64" && b.scalar == "Int" {
+			return &genType{scalar: "Float64"}
+		}
+	case a.list != nil && b.list != nil:
+		return &genType{list: mergeGenTypes(a.list, b.list)}
+	case a.fields != nil && b.fields != nil:
+		merged := &genType{fields: append([]genField{}, a.fields...)}
+		for _, f := range b.fields {
+			merged.addField(f.key, f.typ)
+		}
+		return merged
+	}
+	return rawGenType
+}
+
+func (t *genType) write(buf *bytes.Buffer) {
+	switch {
+	case t.scalar != "":
+		fmt.Fprintf(buf, "figtree.%sOption", t.scalar)
+	case t.list != nil && t.list.scalar != "":
+		fmt.Fprintf(buf, "figtree.List%sOption", t.list.scalar)
+	case t.list != nil:
+		buf.WriteString("[]")
+		t.list.write(buf)
+	default:
+		buf.WriteString("struct {\n")
+		for _, f := range t.fields {
+			fmt.Fprintf(buf, "%s ", f.name)
+			f.typ.write(buf)
+			fmt.Fprintf(buf, " `json:%s yaml:%s`\n", strconv.Quote(f.key), strconv.Quote(f.key))
+		}
+		buf.WriteString("}")
+	}
+}
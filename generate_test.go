@@ -0,0 +1,60 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerateStruct(t *testing.T) {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`
+name: example
+count: 3
+ratio: 1.5
+enabled: true
+tags: [a, b]
+numbers: [1, 2.5]
+mixed: [1, a]
+any: null
+server:
+  host: localhost
+  port-number: 8080
+users:
+  - name: bob
+  - name: alice
+    admin: true
+9lives: cat
+`), &node)
+	require.NoError(t, err)
+
+	got, err := GenerateStruct(&node, WithStructName("Config"))
+	require.NoError(t, err)
+
+	expected := "type Config struct {\n" +
+		"\tName    figtree.StringOption      `json:\"name\" yaml:\"name\"`\n" +
+		"\tCount   figtree.IntOption         `json:\"count\" yaml:\"count\"`\n" +
+		"\tRatio   figtree.Float64Option     `json:\"ratio\" yaml:\"ratio\"`\n" +
+		"\tEnabled figtree.BoolOption        `json:\"enabled\" yaml:\"enabled\"`\n" +
+		"\tTags    figtree.ListStringOption  `json:\"tags\" yaml:\"tags\"`\n" +
+		"\tNumbers figtree.ListFloat64Option `json:\"numbers\" yaml:\"numbers\"`\n" +
+		"\tMixed   figtree.ListRawTypeOption `json:\"mixed\" yaml:\"mixed\"`\n" +
+		"\tAny     figtree.RawTypeOption     `json:\"any\" yaml:\"any\"`\n" +
+		"\tServer  struct {\n" +
+		"\t\tHost       figtree.StringOption `json:\"host\" yaml:\"host\"`\n" +
+		"\t\tPortNumber figtree.IntOption    `json:\"port-number\" yaml:\"port-number\"`\n" +
+		"\t} `json:\"server\" yaml:\"server\"`\n" +
+		"\tUsers []struct {\n" +
+		"\t\tName  figtree.StringOption `json:\"name\" yaml:\"name\"`\n" +
+		"\t\tAdmin figtree.BoolOption   `json:\"admin\" yaml:\"admin\"`\n" +
+		"\t} `json:\"users\" yaml:\"users\"`\n" +
+		"\tX9lives figtree.StringOption `json:\"9lives\" yaml:\"9lives\"`\n" +
+		"}\n"
+	assert.Equal(t, expected, got)
+
+	require.NoError(t, yaml.Unmarshal([]byte(`[a, b]`), &node))
+	_, err = GenerateStruct(&node)
+	require.Error(t, err)
+}
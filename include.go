@@ -0,0 +1,125 @@
+package figtree
+
+import (
+	"context"
+	"path/filepath"
+
+	"emperror.dev/errors"
+	"github.com/coryb/walky"
+	"gopkg.in/yaml.v3"
+)
+
+// WithExtends allows config files to extend other config files with the
+// top level `extends` key, a file or list of files, like:
+//
+//	extends: ../base.yml
+//	color: always
+//
+// The extended files are loaded like files included with the include config
+// pragma: relative paths are relative to the directory of the extending
+// file, the extended files take lower precedence than the extending file
+// and extending a file that extends the original file is an
+// IncludeCycleError.  This suits configs that live side by side rather than
+// in ancestor directories.  The `extends` key is not merged into the
+// options.
+func WithExtends() CreateOption {
+	return func(f *FigTree) {
+		f.extends = true
+	}
+}
+
+func (f *FigTree) WithExtends() {
+	WithExtends()(f)
+}
+
+// readIncludes reads the files included by cs with the include config
+// pragma, like:
+//
+//	config:
+//	  include: [other.yml, ../shared.yml]
+//
+// or extended with the `extends` key, see WithExtends.  Relative include
+// paths are relative to the directory of the including file.  The included
+// sources are returned in precedence order, each one followed by the files
+// it includes, so they are merged after the including file and before any
+// file that follows it.  Included files that do not exist are an error, and
+// an IncludeCycleError is returned when a file includes itself directly or
+// indirectly.
+func (f *FigTree) readIncludes(ctx context.Context, cs ConfigSource) ([]ConfigSource, error) {
+	if cs.Config == nil {
+		return nil, nil
+	}
+	root := walky.UnwrapDocument(cs.Config)
+	nodes := []*yaml.Node{}
+	if pragma := walky.GetKey(root, "config"); pragma != nil {
+		include, err := includeFiles(cs.Filename, walky.GetKey(pragma, "include"), "config include")
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, include...)
+	}
+	if f.extends {
+		extends, err := includeFiles(cs.Filename, walky.GetKey(root, "extends"), "extends")
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, extends...)
+	}
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	dir := f.workDir
+	if cs.Version != nil && cs.Version.Path != "" {
+		dir = filepath.Dir(cs.Version.Path)
+	}
+	chain := append(append([]string{}, cs.IncludeChain...), cs.Filename)
+	sources := []ConfigSource{}
+	for _, node := range nodes {
+		file := node.Value
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		docs, err := f.ReadFileDocuments(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		if docs == nil {
+			return nil, errors.Errorf("%s: included config %s not found", sourceLine(cs.Filename, node), node.Value)
+		}
+		for _, included := range docs {
+			if err := CheckIncludeCycle(chain, included.Filename); err != nil {
+				return nil, err
+			}
+			included.IncludeChain = chain
+			included.Priority = cs.Priority
+			included.home = cs.home
+			nested, err := f.readIncludes(ctx, included)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, included)
+			sources = append(sources, nested...)
+		}
+	}
+	return sources, nil
+}
+
+// includeFiles returns the file name nodes of node, a file or list of files
+// for the include pragma or the extends key, named by what in errors.
+func includeFiles(file string, node *yaml.Node, what string) ([]*yaml.Node, error) {
+	if node == nil {
+		return nil, nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		node = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{node}}
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil, errors.Errorf("%s: %s must be a file or list of files", sourceLine(file, node), what)
+	}
+	for _, n := range node.Content {
+		if n.Kind != yaml.ScalarNode || n.Value == "" {
+			return nil, errors.Errorf("%s: %s must be a file or list of files", sourceLine(file, n), what)
+		}
+	}
+	return node.Content, nil
+}
@@ -0,0 +1,161 @@
+package figtree
+
+import (
+	"path/filepath"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestIncludeChain(t *testing.T) {
+	var parent, child yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("str1: parent\n"), &parent))
+	require.NoError(t, yaml.Unmarshal([]byte("int1: 1\nmap1:\n  key: value\n"), &child))
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv()
+	err := fig.LoadAllConfigSources([]ConfigSource{
+		{Config: &parent, Filename: "a.yml"},
+		{Config: &child, Filename: "c.yml", IncludeChain: []string{"a.yml", "b.yml"}},
+	}, &opts)
+	require.NoError(t, err)
+
+	chainSrc := func(l, c int) SourceLocation {
+		return NewSource("c.yml", WithLocation(&FileCoordinate{Line: l, Column: c}), WithIncludeChain([]string{"a.yml", "b.yml"}))
+	}
+	expected := TestOptions{
+		String1: StringOption{tSrc("a.yml", 1, 7), true, "parent"},
+		Int1:    IntOption{chainSrc(1, 7), true, 1},
+		Map1: MapStringOption{
+			"key": StringOption{chainSrc(3, 8), true, "value"},
+		},
+	}
+	assert.Exactly(t, expected, opts)
+	assert.Equal(t, "a.yml -> b.yml -> c.yml:1:7", opts.Int1.Source.String())
+
+	data, err := MarshalJSONWithSource(&opts)
+	require.NoError(t, err)
+	got := TestOptions{}
+	require.NoError(t, UnmarshalJSONWithSource(data, &got))
+	assert.Exactly(t, expected, got)
+}
+
+func TestIncludeCycle(t *testing.T) {
+	var config yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("str1: value\n"), &config))
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv()
+	err := fig.LoadAllConfigSources([]ConfigSource{
+		{Config: &config, Filename: "b.yml", IncludeChain: []string{"a.yml", "b.yml", "c.yml"}},
+	}, &opts)
+	require.Error(t, err)
+	assert.EqualError(t, err, "include cycle detected: b.yml -> c.yml -> b.yml")
+	var cycleErr IncludeCycleError
+	require.True(t, errors.As(err, &cycleErr))
+	assert.Equal(t, []string{"b.yml", "c.yml", "b.yml"}, cycleErr.Chain)
+
+	assert.NoError(t, CheckIncludeCycle([]string{"a.yml"}, "b.yml"))
+}
+
+func TestLoadAllConfigsInclude(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml":       "str1: root\nfloat1: 2.5\nmap1:\n  k: root\n",
+		"app/figtree.yml":   "config:\n  include: [local.yml, ../shared/base.yml]\nstr1: app\n",
+		"app/local.yml":     "str1: local\nint1: 1\n",
+		"shared/base.yml":   "config:\n  include: nested.yml\nint1: 2\nfloat1: 1.5\n",
+		"shared/nested.yml": "bool1: true\nmap1:\n  k: nested\n",
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "app")))
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+
+	includedSrc := func(name string, l, c int, chain ...string) SourceLocation {
+		return NewSource(name, WithLocation(&FileCoordinate{Line: l, Column: c}), WithIncludeChain(chain))
+	}
+	expected := TestOptions{
+		String1: StringOption{tSrc("figtree.yml", 3, 7), true, "app"},
+		Int1:    IntOption{includedSrc("local.yml", 2, 7, "figtree.yml"), true, 1},
+		Float1:  Float32Option{includedSrc("../shared/base.yml", 4, 9, "figtree.yml"), true, 1.5},
+		Bool1:   BoolOption{includedSrc("../shared/nested.yml", 1, 8, "figtree.yml", "../shared/base.yml"), true, true},
+		Map1: MapStringOption{
+			"k": StringOption{includedSrc("../shared/nested.yml", 3, 6, "figtree.yml", "../shared/base.yml"), true, "nested"},
+		},
+	}
+	assert.Exactly(t, expected, opts)
+	assert.Equal(t, "figtree.yml -> ../shared/base.yml -> ../shared/nested.yml:1:8", opts.Bool1.Source.String())
+}
+
+func TestLoadAllConfigsIncludeCycle(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml": "config:\n  include: a.yml\nstr1: top\n",
+		"a.yml":       "config:\n  include: [b.yml]\n",
+		"b.yml":       "config:\n  include: [figtree.yml]\n",
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(dir))
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.Error(t, err)
+	assert.EqualError(t, err, "include cycle detected: figtree.yml -> a.yml -> b.yml -> figtree.yml")
+	var cycleErr IncludeCycleError
+	require.True(t, errors.As(err, &cycleErr))
+}
+
+func TestLoadAllConfigsIncludeNotFound(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml": "config:\n  include: [missing.yml]\n",
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(dir))
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "figtree.yml:2:13: included config missing.yml not found")
+}
+
+func TestLoadAllConfigsExtends(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"svc/app.yml":     "extends: base.yml\nstr1: app\n",
+		"svc/base.yml":    "extends: [../common.yml]\nstr1: base\nint1: 2\n",
+		"common.yml":      "int1: 1\nbool1: true\n",
+		"cycle/a.yml":     "extends: b.yml\n",
+		"cycle/b.yml":     "extends: a.yml\n",
+		"invalid/app.yml": "extends:\n  file: base.yml\n",
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "svc")), WithExtends(), WithMergeOptions(WithStrictDecode()))
+	err := fig.LoadAllConfigs("app.yml", &opts)
+	require.NoError(t, err)
+
+	extendedSrc := func(name string, l, c int, chain ...string) SourceLocation {
+		return NewSource(name, WithLocation(&FileCoordinate{Line: l, Column: c}), WithIncludeChain(chain))
+	}
+	expected := TestOptions{
+		String1: StringOption{tSrc("app.yml", 2, 7), true, "app"},
+		Int1:    IntOption{extendedSrc("base.yml", 3, 7, "app.yml"), true, 2},
+		Bool1:   BoolOption{extendedSrc("../common.yml", 2, 8, "app.yml", "base.yml"), true, true},
+	}
+	assert.Exactly(t, expected, opts)
+
+	fig = newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "cycle")), WithExtends())
+	err = fig.LoadAllConfigs("a.yml", &TestOptions{})
+	assert.EqualError(t, err, "include cycle detected: a.yml -> b.yml -> a.yml")
+
+	fig = newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "invalid")), WithExtends())
+	err = fig.LoadAllConfigs("app.yml", &TestOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "app.yml:2:3: extends must be a file or list of files")
+
+	// without WithExtends the extends key is not special
+	fig = newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "svc")))
+	opts = TestOptions{}
+	require.NoError(t, fig.LoadAllConfigs("app.yml", &opts))
+	assert.False(t, opts.Int1.Defined)
+}
@@ -0,0 +1,151 @@
+package figtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"emperror.dev/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeJSONNode decodes the JSON document in data into node, recording the
+// line and column of every value so Options loaded from JSON files have the
+// same source locations as those loaded from YAML files.  Empty data leaves
+// node unchanged.
+func decodeJSONNode(file string, data []byte, node *yaml.Node) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	d := jsonNodeDecoder{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	d.dec.UseNumber()
+	for i, b := range data {
+		if b == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	content, err := d.value()
+	if err == nil {
+		if _, err = d.dec.Token(); err == nil {
+			err = errors.New("unexpected data after top-level value")
+		} else if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	if err != nil {
+		offset := d.dec.InputOffset()
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset = syntaxErr.Offset
+		}
+		line, column := d.position(offset)
+		return errors.Errorf("%s:%d:%d: %s", file, line, column, err)
+	}
+	*node = yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Line:    content.Line,
+		Column:  content.Column,
+		Content: []*yaml.Node{content},
+	}
+	return nil
+}
+
+type jsonNodeDecoder struct {
+	data []byte
+	dec  *json.Decoder
+	// lines are the offsets of the start of each line after the first.
+	lines []int
+}
+
+// position returns the 1-based line and column for the byte offset.
+func (d *jsonNodeDecoder) position(offset int64) (int, int) {
+	if offset > int64(len(d.data)) {
+		offset = int64(len(d.data))
+	}
+	line := sort.SearchInts(d.lines, int(offset)+1)
+	start := 0
+	if line > 0 {
+		start = d.lines[line-1]
+	}
+	return line + 1, utf8.RuneCount(d.data[start:offset]) + 1
+}
+
+// next returns the next token along with its line and column.
+func (d *jsonNodeDecoder) next() (json.Token, int, int, error) {
+	offset := int(d.dec.InputOffset())
+	for offset < len(d.data) {
+		switch d.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+			continue
+		}
+		break
+	}
+	tok, err := d.dec.Token()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	line, column := d.position(int64(offset))
+	return tok, line, column, nil
+}
+
+// value decodes the next JSON value into a yaml.Node.
+func (d *jsonNodeDecoder) value() (*yaml.Node, error) {
+	tok, line, column, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{Line: line, Column: column}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			node.Kind, node.Tag, node.Style = yaml.MappingNode, "!!map", yaml.FlowStyle
+			for d.dec.More() {
+				key, err := d.value()
+				if err != nil {
+					return nil, err
+				}
+				val, err := d.value()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, key, val)
+			}
+		case '[':
+			node.Kind, node.Tag, node.Style = yaml.SequenceNode, "!!seq", yaml.FlowStyle
+			for d.dec.More() {
+				val, err := d.value()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, val)
+			}
+		}
+		// consume the closing delimiter
+		if _, err := d.dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.Kind, node.Tag, node.Value, node.Style = yaml.ScalarNode, "!!str", t, yaml.DoubleQuotedStyle
+	case json.Number:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!int", t.String()
+		if strings.ContainsAny(node.Value, ".eE") {
+			node.Tag = "!!float"
+		}
+	case bool:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!bool", "false"
+		if t {
+			node.Value = "true"
+		}
+	case nil:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!null", "null"
+	}
+	return node, nil
+}
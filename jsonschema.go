@@ -0,0 +1,236 @@
+package figtree
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// JSONSchemaVersion is the `$schema` of the documents from GenerateSchema.
+const JSONSchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// GenerateSchema returns a JSON Schema document for config files loaded into
+// opts, so editors can validate and complete the config files.  Options are
+// described by the schema of their value, ListOptions as arrays and
+// MapOptions as objects, inline fields and the fields of embedded structs
+// are described in their parent.  Durations, times, URLs, regexps, locations
+// and locales are strings.
+//
+// The schema of a field can be refined with the jsonschema struct tag, a
+// comma separated list of `key=value` settings where a comma in a value is
+// escaped as `\,`:
+//
+//	Color StringOption `yaml:"color" jsonschema:"description=When to use color,enum=auto,enum=always,enum=never,default=auto"`
+//
+// The supported keys are title, description, format, pattern, enum, default,
+// minimum and maximum, and the `required` flag.  Fields tagged
+// `figtree:",required"` are also required.
+func GenerateSchema(opts any) ([]byte, error) {
+	v := reflect.ValueOf(opts)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, errors.Errorf("options must be a struct, got %T", opts)
+	}
+	schema, err := jsonSchema(MakeMergeSchema(opts))
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = JSONSchemaVersion
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(data, '\n'), nil
+}
+
+// jsonSchema returns the JSON Schema for s.
+func jsonSchema(s SchemaType) (map[string]any, error) {
+	switch s.Name {
+	case "time.Duration":
+		// durations are strings like "1m30s" or integer seconds
+		return map[string]any{"type": []string{"string", "integer"}}, nil
+	case "time.Time":
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case "url.URL":
+		return map[string]any{"type": "string", "format": "uri"}, nil
+	case "regexp.Regexp":
+		return map[string]any{"type": "string", "format": "regex"}, nil
+	case "time.Location", "complex64", "complex128", "error":
+		return map[string]any{"type": "string"}, nil
+	}
+	switch s.Kind {
+	case SchemaOption:
+		if s.Elem == nil {
+			return map[string]any{}, nil
+		}
+		return jsonSchema(*s.Elem)
+	case SchemaString:
+		return map[string]any{"type": "string"}, nil
+	case SchemaBool:
+		return map[string]any{"type": "boolean"}, nil
+	case SchemaInt:
+		return map[string]any{"type": "integer"}, nil
+	case SchemaUint:
+		return map[string]any{"type": "integer", "minimum": 0}, nil
+	case SchemaFloat:
+		return map[string]any{"type": "number"}, nil
+	case SchemaList:
+		items, err := jsonSchema(*s.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case SchemaMap:
+		values, err := jsonSchema(*s.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case SchemaStruct:
+		properties := map[string]any{}
+		required := []string{}
+		embedded := []SchemaField{}
+		for _, field := range s.Fields {
+			tag := reflect.StructTag(field.Tag)
+			if tag.Get("yaml") == "-" {
+				continue
+			}
+			if field.Embedded && field.Type.Kind == SchemaStruct {
+				embedded = append(embedded, field)
+				continue
+			}
+			property, err := jsonSchema(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			isRequired, err := applySchemaTag(property, tag.Get("jsonschema"))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid jsonschema tag for %s", field.Key)
+			}
+			sf := reflect.StructField{Tag: tag}
+			if isRequired || figtreeTagFlag(sf, "required") {
+				required = append(required, field.Key)
+			}
+			properties[field.Key] = property
+		}
+		// the fields of embedded structs are read from the parent, fields
+		// of the parent take precedence
+		for _, field := range embedded {
+			inner, err := jsonSchema(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			added := map[string]bool{}
+			innerProperties, _ := inner["properties"].(map[string]any)
+			for key, property := range innerProperties {
+				if _, ok := properties[key]; !ok {
+					properties[key] = property
+					added[key] = true
+				}
+			}
+			innerRequired, _ := inner["required"].([]string)
+			for _, key := range innerRequired {
+				if added[key] {
+					required = append(required, key)
+				}
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema, nil
+	}
+	// any, and types that cannot be described
+	return map[string]any{}, nil
+}
+
+// applySchemaTag updates schema with the settings in the jsonschema tag, it
+// returns true if the tag has the required flag.
+func applySchemaTag(schema map[string]any, tag string) (bool, error) {
+	if tag == "" {
+		return false, nil
+	}
+	required := false
+	for _, setting := range splitEscaped(tag, ',') {
+		key, value, _ := strings.Cut(setting, "=")
+		switch key {
+		case "required":
+			required = true
+		case "title", "description", "format", "pattern":
+			schema[key] = value
+		case "enum":
+			typed, err := schemaValue(schema, value)
+			if err != nil {
+				return false, err
+			}
+			enum, _ := schema["enum"].([]any)
+			schema["enum"] = append(enum, typed)
+		case "default":
+			typed, err := schemaValue(schema, value)
+			if err != nil {
+				return false, err
+			}
+			schema["default"] = typed
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, errors.Errorf("%s must be a number, got %q", key, value)
+			}
+			schema[key] = n
+		default:
+			return false, errors.Errorf("unknown setting %q", key)
+		}
+	}
+	return required, nil
+}
+
+// schemaValue converts value to the type of schema for enum and default
+// settings.
+func schemaValue(schema map[string]any, value string) (any, error) {
+	var typed any = value
+	var err error
+	switch schema["type"] {
+	case "integer":
+		typed, err = strconv.ParseInt(value, 10, 64)
+	case "number":
+		typed, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		typed, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return nil, errors.Errorf("invalid %s value %q", schema["type"], value)
+	}
+	return typed, nil
+}
+
+// splitEscaped splits s at each sep that is not escaped with a backslash,
+// the escapes are removed.
+func splitEscaped(s string, sep rune) []string {
+	parts := []string{}
+	buf := strings.Builder{}
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if r != sep && r != '\\' {
+				buf.WriteRune('\\')
+			}
+			buf.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == sep:
+			parts = append(parts, buf.String())
+			buf.Reset()
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return append(parts, buf.String())
+}
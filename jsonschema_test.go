@@ -0,0 +1,107 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSchema(t *testing.T) {
+	type Server struct {
+		Host URLOption `yaml:"host" jsonschema:"description=The server URL\\, with scheme"`
+		Port uint16    `yaml:"port" figtree:",required"`
+	}
+	type Common struct {
+		Debug BoolOption `yaml:"debug"`
+	}
+	type config struct {
+		Common  `yaml:",inline"`
+		Color   StringOption     `yaml:"color" jsonschema:"enum=auto,enum=always,enum=never,default=auto"`
+		Retries IntOption        `yaml:"retries" jsonschema:"minimum=0,maximum=10,default=3,required"`
+		Ratio   Float64Option    `yaml:"ratio"`
+		Timeout DurationOption   `yaml:"timeout"`
+		Since   TimeOption       `yaml:"since"`
+		Exclude RegexpOption     `yaml:"exclude"`
+		Tags    ListStringOption `yaml:"tags"`
+		Labels  MapStringOption  `yaml:"labels"`
+		Server  Server           `yaml:"server"`
+		Extra   any              `yaml:"extra"`
+		Skipped string           `yaml:"-"`
+	}
+
+	schema, err := GenerateSchema(&config{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": ["retries"],
+  "properties": {
+    "debug": {"type": "boolean"},
+    "color": {"type": "string", "enum": ["auto", "always", "never"], "default": "auto"},
+    "retries": {"type": "integer", "minimum": 0, "maximum": 10, "default": 3},
+    "ratio": {"type": "number"},
+    "timeout": {"type": ["string", "integer"]},
+    "since": {"type": "string", "format": "date-time"},
+    "exclude": {"type": "string", "format": "regex"},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "server": {
+      "type": "object",
+      "required": ["port"],
+      "properties": {
+        "host": {"type": "string", "format": "uri", "description": "The server URL, with scheme"},
+        "port": {"type": "integer", "minimum": 0}
+      }
+    },
+    "extra": {}
+  }
+}`, string(schema))
+
+	type invalid struct {
+		Count IntOption `yaml:"count" jsonschema:"default=many"`
+	}
+	_, err = GenerateSchema(invalid{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid jsonschema tag for count: invalid integer value "many"`)
+
+	_, err = GenerateSchema("config")
+	require.Error(t, err)
+}
+
+func TestGenerateSchemaEmbedded(t *testing.T) {
+	type Auth struct {
+		Token StringOption `yaml:"token" figtree:",required"`
+		Color StringOption `yaml:"color"`
+	}
+	type Common struct {
+		Auth
+		Debug BoolOption `yaml:"debug"`
+	}
+	type config struct {
+		Common
+		Color IntOption `yaml:"color"`
+	}
+
+	// embedded structs are flattened like the loader reads them
+	schema, err := GenerateSchema(&config{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": ["token"],
+  "properties": {
+    "color": {"type": "integer"},
+    "debug": {"type": "boolean"},
+    "token": {"type": "string"}
+  }
+}`, string(schema))
+
+	source, err := SourceFromString("figtree.yml", "token: secret\ndebug: true\ncolor: 1\n")
+	require.NoError(t, err)
+	opts := config{}
+	require.NoError(t, newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{source}, &opts))
+	assert.Equal(t, "secret", opts.Token.Value)
+	assert.True(t, opts.Debug.Value)
+	assert.Equal(t, 1, opts.Color.Value)
+}
@@ -0,0 +1,150 @@
+package figtree
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/coryb/walky"
+	"gopkg.in/yaml.v3"
+)
+
+// KeyAliasHook is called for each use of a key alias in a config file with
+// the alias, the key it was renamed to and the location of the alias, see
+// WithKeyAliases.
+type KeyAliasHook func(alias, key string, source SourceLocation)
+
+// WithKeyAliases renames keys in config files before they are merged, so
+// options can be renamed while old config files still work, like:
+//
+//	figtree.WithKeyAliases(map[string]string{
+//		"old-name":         "new-name",
+//		"server.listen-on": "server.address",
+//	})
+//
+// Aliases and keys are dotted key paths, the value of an alias is moved to
+// the key, creating any missing parent maps.  When a document has both the
+// alias and the key, the key takes precedence.  Each use of an alias is
+// logged as deprecated, use WithKeyAliasHook to collect the warnings.
+func WithKeyAliases(aliases map[string]string) CreateOption {
+	return func(f *FigTree) {
+		// copied so a FigTree from Copy does not share the aliases
+		merged := map[string]string{}
+		for alias, key := range f.keyAliases {
+			merged[alias] = key
+		}
+		for alias, key := range aliases {
+			merged[alias] = key
+		}
+		f.keyAliases = merged
+	}
+}
+
+func (f *FigTree) WithKeyAliases(aliases map[string]string) {
+	WithKeyAliases(aliases)(f)
+}
+
+// WithKeyAliasHook adds a hook called for each use of a key alias, for
+// example to show deprecation warnings, see WithKeyAliases.
+func WithKeyAliasHook(hook KeyAliasHook) CreateOption {
+	return func(f *FigTree) {
+		f.keyAliasHooks = append(f.keyAliasHooks, hook)
+	}
+}
+
+func (f *FigTree) WithKeyAliasHook(hook KeyAliasHook) {
+	WithKeyAliasHook(hook)(f)
+}
+
+// applyKeyAliases returns config with the WithKeyAliases aliases renamed to
+// their keys.  The config is copied when it has any alias, so the source is
+// not modified.
+func (f *FigTree) applyKeyAliases(m *Merger, config *yaml.Node) *yaml.Node {
+	aliases := []string{}
+	for alias := range f.keyAliases {
+		if _, keyNode, _ := lookupKeyPath(config, strings.Split(alias, ".")); keyNode != nil {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) == 0 {
+		return config
+	}
+	sort.Strings(aliases)
+	config = walky.CopyNode(config)
+	for _, alias := range aliases {
+		key := f.keyAliases[alias]
+		parent, keyNode, valueNode := lookupKeyPath(config, strings.Split(alias, "."))
+		if keyNode == nil {
+			// the parent of the alias was moved by another alias
+			continue
+		}
+		source := m.newSource(&FileCoordinate{Line: keyNode.Line, Column: keyNode.Column})
+		m.log().Debugf("%s: %s is deprecated, use %s", source, alias, key)
+		for _, hook := range f.keyAliasHooks {
+			hook(alias, key, source)
+		}
+		path := strings.Split(key, ".")
+		newParent := mappingPath(config, path[:len(path)-1])
+		if newParent == nil {
+			m.log().Debugf("%s: cannot move %s to %s, the parent is not a map", source, alias, key)
+			continue
+		}
+		removeMapKey(parent, keyNode)
+		if walky.GetKey(newParent, path[len(path)-1]) != nil {
+			continue
+		}
+		renamed := *keyNode
+		renamed.Value = path[len(path)-1]
+		newParent.Content = append(newParent.Content, &renamed, valueNode)
+	}
+	return config
+}
+
+// lookupKeyPath returns the map containing the key path, and the key and
+// value nodes of the key path, or nils if it is not found.
+func lookupKeyPath(node *yaml.Node, path []string) (parent, keyNode, valueNode *yaml.Node) {
+	parent = walky.Indirect(walky.UnwrapDocument(node))
+	for i, name := range path {
+		if parent == nil || parent.Kind != yaml.MappingNode {
+			return nil, nil, nil
+		}
+		keyNode, valueNode = walky.GetKeyValue(parent, walky.NewStringNode(name))
+		if keyNode == nil {
+			return nil, nil, nil
+		}
+		if i < len(path)-1 {
+			parent = walky.Indirect(valueNode)
+		}
+	}
+	return parent, keyNode, valueNode
+}
+
+// mappingPath returns the map at path, creating missing maps, or nil if a
+// value in the path is not a map.
+func mappingPath(node *yaml.Node, path []string) *yaml.Node {
+	current := walky.Indirect(walky.UnwrapDocument(node))
+	for _, name := range path {
+		if current == nil || current.Kind != yaml.MappingNode {
+			return nil
+		}
+		next := walky.GetKey(current, name)
+		if next == nil {
+			next = walky.NewMappingNode()
+			current.Content = append(current.Content, walky.NewStringNode(name), next)
+		}
+		current = walky.Indirect(next)
+	}
+	if current == nil || current.Kind != yaml.MappingNode {
+		return nil
+	}
+	return current
+}
+
+// removeMapKey removes keyNode and its value from the map.
+func removeMapKey(mapNode, keyNode *yaml.Node) {
+	for i := 0; i+1 < len(mapNode.Content); i += 2 {
+		if mapNode.Content[i] == keyNode {
+			mapNode.Content = append(mapNode.Content[:i:i], mapNode.Content[i+2:]...)
+			return
+		}
+	}
+}
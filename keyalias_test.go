@@ -0,0 +1,70 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWithKeyAliases(t *testing.T) {
+	type server struct {
+		Address StringOption `yaml:"address"`
+	}
+	type config struct {
+		NewName StringOption `yaml:"new-name"`
+		Server  server       `yaml:"server"`
+		Port    IntOption    `yaml:"port"`
+		Int1    IntOption    `yaml:"int1"`
+	}
+
+	data := `old-name: renamed
+server:
+  listen-on: localhost
+old-port: 8080
+port: 9090
+int1: 1
+`
+	src, err := SourceFromString("config.yml", data)
+	require.NoError(t, err)
+
+	type warning struct {
+		alias, key, source string
+	}
+	warnings := []warning{}
+	fig := newFigTreeFromEnv(
+		WithKeyAliases(map[string]string{
+			"old-name":         "new-name",
+			"server.listen-on": "server.address",
+		}),
+		WithKeyAliases(map[string]string{"old-port": "port"}),
+		WithKeyAliasHook(func(alias, key string, source SourceLocation) {
+			warnings = append(warnings, warning{alias, key, source.String()})
+		}),
+		WithMergeOptions(WithStrictDecode()),
+	)
+	for i := 0; i < 2; i++ {
+		warnings = warnings[:0]
+		opts := config{}
+		err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+		require.NoError(t, err)
+		assert.Equal(t, StringOption{tSrc("config.yml", 1, 11), true, "renamed"}, opts.NewName)
+		assert.Equal(t, StringOption{tSrc("config.yml", 3, 14), true, "localhost"}, opts.Server.Address)
+		// the key takes precedence over the alias
+		assert.Equal(t, 9090, opts.Port.Value)
+		assert.Equal(t, 1, opts.Int1.Value)
+		assert.Equal(t, []warning{
+			{"old-name", "new-name", "config.yml:1:1"},
+			{"old-port", "port", "config.yml:4:1"},
+			{"server.listen-on", "server.address", "config.yml:3:3"},
+		}, warnings)
+	}
+
+	// missing parent maps are created
+	src, err = SourceFromString("config.yml", "address: remote\n")
+	require.NoError(t, err)
+	fig = newFigTreeFromEnv(WithKeyAliases(map[string]string{"address": "server.address"}))
+	opts := config{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &opts))
+	assert.Equal(t, StringOption{tSrc("config.yml", 1, 10), true, "remote"}, opts.Server.Address)
+}
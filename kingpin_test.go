@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	yaml "gopkg.in/yaml.v3"
 )
 
 type Stringish struct {
@@ -130,3 +131,159 @@ func TestCommandLineAny(t *testing.T) {
 
 	require.Equal(t, expected, opts)
 }
+
+func TestCommandLineBoolNegation(t *testing.T) {
+	type CommandLineOptions struct {
+		Bool1 BoolOption `yaml:"bool1,omitempty"`
+		Bool2 BoolOption `yaml:"bool2,omitempty"`
+	}
+
+	opts := CommandLineOptions{}
+	require.NoError(t, os.Chdir("d1/d2/d3"))
+	t.Cleanup(func() {
+		_ = os.Chdir("../../..")
+	})
+
+	fig := newFigTreeFromEnv()
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	require.Equal(t, BoolOption{tSrc("figtree.yml", 12, 8), true, true}, opts.Bool1)
+
+	app := kingpin.New("test", "testing")
+	app.Flag("bool1", "Bool1").SetValue(&opts.Bool1)
+	app.Flag("bool2", "Bool2").SetValue(&opts.Bool2)
+	app.Flag("disable-bool2", "Disable Bool2").SetValue(NegateBoolOption(&opts.Bool2))
+	_, err = app.Parse([]string{"--no-bool1", "--bool2", "--disable-bool2"})
+	require.NoError(t, err)
+
+	expected := CommandLineOptions{
+		Bool1: BoolOption{NewSource("override"), true, false},
+		Bool2: BoolOption{NewSource("override"), true, false},
+	}
+	require.Equal(t, expected, opts)
+}
+
+func TestCommandLineCount(t *testing.T) {
+	type CommandLineOptions struct {
+		Verbose CountOption `yaml:"verbose,omitempty"`
+		Quiet   CountOption `yaml:"quiet,omitempty"`
+		Debug   CountOption `yaml:"debug,omitempty"`
+	}
+
+	var config yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("verbose: 1\ndebug: 5\n"), &config))
+
+	opts := CommandLineOptions{
+		Quiet: NewCountOption(1),
+	}
+	fig := newFigTreeFromEnv()
+	err := fig.LoadConfigSource(&config, "figtree.yml", &opts)
+	require.NoError(t, err)
+	require.Equal(t, CountOption{Option[int]{tSrc("figtree.yml", 1, 10), true, 1}}, opts.Verbose)
+
+	app := kingpin.New("test", "testing")
+	app.Flag("verbose", "Verbose").Short('v').SetValue(&opts.Verbose)
+	app.Flag("quiet", "Quiet").Short('q').SetValue(&opts.Quiet)
+	app.Flag("debug", "Debug").SetValue(&opts.Debug)
+	_, err = app.Parse([]string{"-vv", "--verbose", "-q", "--no-debug"})
+	require.NoError(t, err)
+
+	expected := CommandLineOptions{
+		Verbose: CountOption{Option[int]{NewSource("override"), true, 4}},
+		Quiet:   CountOption{Option[int]{NewSource("override"), true, 2}},
+		Debug:   CountOption{Option[int]{NewSource("override"), true, 0}},
+	}
+	require.Equal(t, expected, opts)
+
+	require.NoError(t, opts.Verbose.Set("7"))
+	require.Equal(t, 7, opts.Verbose.Value)
+	require.Error(t, opts.Verbose.Set("lots"))
+}
+
+func TestCommandLineMapFlag(t *testing.T) {
+	type CommandLineOptions struct {
+		Set    MapOption[any]  `yaml:"set,omitempty"`
+		Labels MapStringOption `yaml:"labels,omitempty"`
+	}
+
+	var config yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("set:\n  server:\n    host: localhost\n    tls:\n      port: 443\n"), &config))
+
+	opts := CommandLineOptions{}
+	fig := newFigTreeFromEnv()
+	err := fig.LoadConfigSource(&config, "figtree.yml", &opts)
+	require.NoError(t, err)
+
+	app := kingpin.New("test", "testing")
+	app.Flag("set", "Set").SetValue(NewMapFlag(&opts.Set, WithNestedKeys(".")))
+	app.Flag("label", "Labels").SetValue(NewMapFlag(&opts.Labels, WithMapDelimiters("=")))
+	_, err = app.Parse([]string{
+		"--set", "server.tls.port=8443",
+		"--set", "server.tls.cert=/a=b",
+		"--set", "flat=value",
+		"--label", "url=https://example.com:8080/?q=1",
+	})
+	require.NoError(t, err)
+
+	expected := CommandLineOptions{
+		Set: MapOption[any]{
+			"server": {NewSource("override"), true, map[string]any{
+				"host": "localhost",
+				"tls": map[string]any{
+					"port": "8443",
+					"cert": "/a=b",
+				},
+			}},
+			"flat": {NewSource("override"), true, "value"},
+		},
+		Labels: MapStringOption{
+			"url": {NewSource("override"), true, "https://example.com:8080/?q=1"},
+		},
+	}
+	require.Equal(t, expected, opts)
+
+	err = NewMapFlag(&opts.Labels, WithNestedKeys(".")).Set("a.b=c")
+	require.Error(t, err)
+	err = NewMapFlag(&opts.Labels, WithMapDelimiters("=")).Set("a:b")
+	require.Error(t, err)
+	err = NewMapFlag(&opts.Labels, WithMapDelimiters("")).Set("ab")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "expected KEY=VALUE got 'ab'")
+}
+
+func TestCommandLineAnyInferred(t *testing.T) {
+	type CommandLineOptions struct {
+		Any1 Option[any]     `yaml:"any1,omitempty"`
+		Map1 MapOption[any]  `yaml:"map1,omitempty"`
+		Arr1 ListOption[any] `yaml:"arr1,omitempty"`
+	}
+
+	opts := CommandLineOptions{}
+	app := kingpin.New("test", "testing")
+	app.Flag("any1", "Any1").SetValue(NewInferredFlag(&opts.Any1))
+	app.Flag("map1", "Map1").SetValue(NewMapFlag(&opts.Map1, WithNestedKeys("."), WithTypeInference()))
+	app.Flag("arr1", "Arr1").SetValue(NewInferredListFlag(&opts.Arr1))
+	_, err := app.Parse([]string{
+		"--any1", "123",
+		"--map1", "ratio=1.5", "--map1", "debug=true", "--map1", "server.port=8443", "--map1", "name=012x",
+		"--arr1", "v1", "--arr1", "false", "--arr1=-7", "--arr1", "inf",
+	})
+	require.NoError(t, err)
+
+	expected := CommandLineOptions{
+		Any1: Option[any]{NewSource("override"), true, 123},
+		Map1: MapOption[any]{
+			"ratio":  {NewSource("override"), true, 1.5},
+			"debug":  {NewSource("override"), true, true},
+			"server": {NewSource("override"), true, map[string]any{"port": 8443}},
+			"name":   {NewSource("override"), true, "012x"},
+		},
+		Arr1: ListOption[any]{
+			{NewSource("override"), true, "v1"},
+			{NewSource("override"), true, false},
+			{NewSource("override"), true, -7},
+			{NewSource("override"), true, "inf"},
+		},
+	}
+	require.Equal(t, expected, opts)
+}
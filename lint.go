@@ -0,0 +1,129 @@
+package figtree
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lint rules reported in LintFinding.Rule.
+const (
+	// LintCaseCollision is reported for map keys that differ only by case,
+	// which will usually be merged into the same option.
+	LintCaseCollision = "case-collision"
+	// LintUnexpandedTemplate is reported for values that look like
+	// templates or variable references, like `${HOME}`, which figtree
+	// does not expand.
+	LintUnexpandedTemplate = "unexpanded-template"
+	// LintTabIndent is reported for block scalars where lines are indented
+	// with tabs, yaml retains the tabs as part of the value.
+	LintTabIndent = "tab-indent"
+	// LintDeepNesting is reported for values nested deeper than
+	// LintMaxDepth.
+	LintDeepNesting = "deep-nesting"
+)
+
+// LintMaxDepth is the maximum nesting of maps and lists before Lint reports
+// a LintDeepNesting finding.
+var LintMaxDepth = 10
+
+// LintFinding is a suspicious pattern found in a config source by Lint.
+type LintFinding struct {
+	Rule    string         `json:"rule" yaml:"rule"`
+	Source  SourceLocation `json:"source" yaml:"source"`
+	Key     string         `json:"key,omitempty" yaml:"key,omitempty"`
+	Message string         `json:"message" yaml:"message"`
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s: %s [%s]", f.Source, f.Message, f.Rule)
+}
+
+var templatePattern = regexp.MustCompile(`\$\{[^}]*\}|\{\{.*\}\}`)
+
+// Lint checks the config sources for suspicious patterns that are valid yaml
+// but likely mistakes, like keys that differ only by case or values that
+// look like unexpanded templates.  The findings are returned in source
+// order, an empty result means no problems were found.
+func (f *FigTree) Lint(sources []ConfigSource) []LintFinding {
+	findings := []LintFinding{}
+	for _, source := range sources {
+		if source.Config == nil {
+			continue
+		}
+		l := linter{source: source}
+		l.lint(source.Config, nil, 0)
+		findings = append(findings, l.findings...)
+	}
+	return findings
+}
+
+type linter struct {
+	source   ConfigSource
+	findings []LintFinding
+	// deep is set once a LintDeepNesting finding has been reported, so
+	// each deeply nested value is only reported once.
+	deep bool
+}
+
+func (l *linter) report(rule string, node *yaml.Node, path []string, format string, args ...any) {
+	l.findings = append(l.findings, LintFinding{
+		Rule: rule,
+		Source: NewSource(l.source.Filename,
+			WithLocation(&FileCoordinate{Line: node.Line, Column: node.Column}),
+			WithIncludeChain(l.source.IncludeChain),
+		),
+		Key:     strings.Join(path, "."),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lint(node *yaml.Node, path []string, depth int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			l.lint(content, path, depth)
+		}
+	case yaml.MappingNode, yaml.SequenceNode:
+		if depth >= LintMaxDepth {
+			if !l.deep {
+				l.report(LintDeepNesting, node, path, "value is nested %d levels deep, more than %d", depth+1, LintMaxDepth)
+				l.deep = true
+			}
+		} else {
+			l.deep = false
+		}
+		if node.Kind == yaml.SequenceNode {
+			for i, item := range node.Content {
+				l.lint(item, append(path, strconv.Itoa(i)), depth+1)
+			}
+			return
+		}
+		keys := map[string]*yaml.Node{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			folded := strings.ToLower(keyNode.Value)
+			if prev, ok := keys[folded]; ok && prev.Value != keyNode.Value {
+				l.report(LintCaseCollision, keyNode, path, "key %q differs only by case from %q at line %d", keyNode.Value, prev.Value, prev.Line)
+			} else if !ok {
+				keys[folded] = keyNode
+			}
+			l.lint(valueNode, append(path, keyNode.Value), depth+1)
+		}
+	case yaml.ScalarNode:
+		if match := templatePattern.FindString(node.Value); match != "" {
+			l.report(LintUnexpandedTemplate, node, path, "value contains %q which will not be expanded", match)
+		}
+		if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			for _, line := range strings.Split(node.Value, "\n") {
+				if strings.HasPrefix(line, "\t") {
+					l.report(LintTabIndent, node, path, "block value has lines indented with tabs")
+					break
+				}
+			}
+		}
+	}
+}
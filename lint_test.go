@@ -0,0 +1,57 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	src, err := SourceFromString("config.yml", "name: example\n"+
+		"Name: other\n"+
+		"home: ${HOME}/data\n"+
+		"script: |\n"+
+		"  echo start\n"+
+		"  \techo tabbed\n"+
+		"list:\n"+
+		"  - {greeting: \"{{ .Name }}\"}\n"+
+		"a: {b: {c: {d: {e: 1}}}}\n")
+	require.NoError(t, err)
+	clean, err := SourceFromString("clean.yml", "name: example\n")
+	require.NoError(t, err)
+
+	LintMaxDepth = 3
+	defer func() {
+		LintMaxDepth = 10
+	}()
+	fig := newFigTreeFromEnv()
+	findings := fig.Lint([]ConfigSource{clean, src})
+	expected := []LintFinding{{
+		Rule:    LintCaseCollision,
+		Source:  tSrc("config.yml", 2, 1),
+		Message: `key "Name" differs only by case from "name" at line 1`,
+	}, {
+		Rule:    LintUnexpandedTemplate,
+		Source:  tSrc("config.yml", 3, 7),
+		Key:     "home",
+		Message: `value contains "${HOME}" which will not be expanded`,
+	}, {
+		Rule:    LintTabIndent,
+		Source:  tSrc("config.yml", 4, 9),
+		Key:     "script",
+		Message: "block value has lines indented with tabs",
+	}, {
+		Rule:    LintUnexpandedTemplate,
+		Source:  tSrc("config.yml", 8, 16),
+		Key:     "list.0.greeting",
+		Message: `value contains "{{ .Name }}" which will not be expanded`,
+	}, {
+		Rule:    LintDeepNesting,
+		Source:  tSrc("config.yml", 9, 12),
+		Key:     "a.b.c",
+		Message: "value is nested 4 levels deep, more than 3",
+	}}
+	assert.Equal(t, expected, findings)
+	assert.Equal(t, `config.yml:2:1: key "Name" differs only by case from "name" at line 1 [case-collision]`, findings[0].String())
+}
@@ -0,0 +1,124 @@
+package figtree
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// LocationOption is an option for a time zone, in config files the zone is
+// the IANA zone name, like "America/Los_Angeles" or "UTC", which is loaded
+// with time.LoadLocation.  Invalid zone names are reported with the location
+// of the value in the config file.
+type LocationOption = Option[*time.Location]
+
+// LocaleOption is an option for a BCP 47 language tag like "en-US".
+type LocaleOption = Option[Locale]
+
+// Locale is a BCP 47 language tag, like "en", "en-US" or "zh-Hant-TW".  Values
+// are checked to be well formed tags with known language, script and region
+// subtags when set from config files and are normalized to the conventional
+// casing, "EN-us" becomes "en-US".
+type Locale string
+
+// localeTag matches well formed BCP 47 language tags: a language, optional
+// script, optional region, then any variants, extensions and private use
+// subtags.
+var localeTag = regexp.MustCompile(`(?i)^(?:[a-z]{2,3}(?:-[a-z]{3}){0,3}|[a-z]{4,8})(?:-[a-z]{4})?(?:-(?:[a-z]{2}|[0-9]{3}))?(?:-(?:[a-z0-9]{5,8}|[0-9][a-z0-9]{3}))*(?:-[0-9a-wyz](?:-[a-z0-9]{2,8})+)*(?:-x(?:-[a-z0-9]{1,8})+)?$|^x(?:-[a-z0-9]{1,8})+$`)
+
+// ParseLocale returns the normalized Locale for the language tag s, or an
+// error if s is not a well formed tag.  The language, extended language,
+// script and region subtags must be ISO 639, ISO 15924, ISO 3166 or UN M.49
+// codes, private use codes like "qaa" or "ZZ" are not allowed.  Variants and
+// extensions are not checked.  Underscores are accepted as separators, so
+// POSIX style names like "en_US" are also allowed.
+func ParseLocale(s string) (Locale, error) {
+	tag := strings.ReplaceAll(s, "_", "-")
+	if !localeTag.MatchString(tag) {
+		return "", errors.Errorf("invalid locale %q", s)
+	}
+	parts := strings.Split(strings.ToLower(tag), "-")
+	if parts[0] == "x" {
+		// private use tag
+		return Locale(strings.Join(parts, "-")), nil
+	}
+	extension := false
+	for i, part := range parts {
+		known := true
+		switch {
+		case extension:
+			// extension and private use subtags are lower case
+		case i == 0:
+			// language
+			known = len(part) == 2 && knownCode(localeLanguages2, part) ||
+				len(part) == 3 && knownCode(localeLanguages3, part)
+		case len(part) == 1:
+			extension = true
+		case len(part) == 2:
+			// region
+			known = knownCode(localeRegions, part)
+			parts[i] = strings.ToUpper(part)
+		case len(part) == 3 && !isAlpha(part):
+			// area
+			known = knownCode(localeAreas, part)
+		case len(part) == 3 && isExtlangs(parts[1:i]):
+			// extended language
+			known = knownCode(localeLanguages3, part)
+		case len(part) == 4 && isAlpha(part) && isExtlangs(parts[1:i]):
+			// script
+			known = knownCode(localeScripts, part)
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+		if !known {
+			return "", errors.Errorf("invalid locale %q, unknown subtag %q", s, part)
+		}
+	}
+	return Locale(strings.Join(parts, "-")), nil
+}
+
+// knownCode returns true if code is in table, the sorted codes of the same
+// length as code concatenated together.
+func knownCode(table, code string) bool {
+	n := len(table) / len(code)
+	i := sort.Search(n, func(i int) bool {
+		return table[i*len(code):(i+1)*len(code)] >= code
+	})
+	return i < n && table[i*len(code):(i+1)*len(code)] == code
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isExtlangs returns true if all the subtags are extended language subtags,
+// which are the only subtags allowed between the language and script.
+func isExtlangs(subtags []string) bool {
+	for _, subtag := range subtags {
+		if len(subtag) != 3 || !isAlpha(subtag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Set will parse and assign the language tag s, see ParseLocale.
+func (l *Locale) Set(s string) error {
+	locale, err := ParseLocale(s)
+	if err != nil {
+		return err
+	}
+	*l = locale
+	return nil
+}
+
+func (l Locale) String() string {
+	return string(l)
+}
@@ -0,0 +1,84 @@
+package figtree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseLocale(t *testing.T) {
+	for input, expected := range map[string]Locale{
+		"en":              "en",
+		"EN-us":           "en-US",
+		"en_US":           "en-US",
+		"zh-hant-tw":      "zh-Hant-TW",
+		"es-419":          "es-419",
+		"de-CH-1996":      "de-CH-1996",
+		"sl-rozaj-biske":  "sl-rozaj-biske",
+		"en-US-u-ca-greg": "en-US-u-ca-greg",
+		"x-whatever":      "x-whatever",
+		"zh-yue-HK":       "zh-yue-HK",
+		"und":             "und",
+		"haw-US":          "haw-US",
+		"sr-Latn-RS":      "sr-Latn-RS",
+	} {
+		got, err := ParseLocale(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+	for _, input := range []string{"", "e", "en-toolongsubtag", "en-", "en--US", "en-US-x", "xx-ZZ", "qqq", "en-ZZ", "en-Qaaa", "en-999", "zh-xxx-CN", "abcde"} {
+		_, err := ParseLocale(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestLoadLocationAndLocale(t *testing.T) {
+	type config struct {
+		Zone   LocationOption `yaml:"zone"`
+		Locale LocaleOption   `yaml:"locale"`
+	}
+
+	src, err := SourceFromString("config.yml", "zone: America/New_York\nlocale: en_us\n")
+	require.NoError(t, err)
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	zone, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	assert.Equal(t, LocationOption{tSrc("config.yml", 1, 7), true, zone}, opts.Zone)
+	assert.Equal(t, LocaleOption{tSrc("config.yml", 2, 9), true, "en-US"}, opts.Locale)
+
+	StringifyValue = true
+	defer func() {
+		StringifyValue = false
+	}()
+	got, err := yaml.Marshal(opts)
+	require.NoError(t, err)
+	assert.Equal(t, "zone: America/New_York\nlocale: en-US\n", string(got))
+
+	// invalid values are reported with their location
+	src, err = SourceFromString("config.yml", "zone: Mars/Olympus_Mons\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:7")
+	assert.Contains(t, err.Error(), "Mars/Olympus_Mons")
+
+	src, err = SourceFromString("config.yml", "locale: en_US.UTF-8\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:9")
+	assert.Contains(t, err.Error(), `invalid locale "en_US.UTF-8"`)
+
+	// and from the command line
+	opts = config{}
+	require.NoError(t, opts.Zone.Set("UTC"))
+	assert.Equal(t, time.UTC, opts.Zone.Value)
+	require.Error(t, opts.Locale.Set("not a locale"))
+}
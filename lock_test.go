@@ -0,0 +1,102 @@
+package figtree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockPragma(t *testing.T) {
+	admin, err := SourceFromString("/etc/figtree.yml", `
+config:
+  lock: [str1, map1.key1, bool1]
+str1: admin
+map1:
+  key1: admin
+float1: 1.5
+`)
+	require.NoError(t, err)
+
+	load := func(user string) (TestOptions, error) {
+		src, err := SourceFromString("figtree.yml", user)
+		require.NoError(t, err)
+		opts := TestOptions{}
+		fig := newFigTreeFromEnv()
+		err = fig.LoadAllConfigSources([]ConfigSource{admin, src}, &opts)
+		return opts, err
+	}
+
+	// unlocked values, locked values with the same value and locked keys
+	// the admin did not set are allowed
+	opts, err := load("str1: admin\nmap1:\n  key1: admin\n  key2: user\nfloat1: 2.5\nbool1: true\n")
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{tSrc("/etc/figtree.yml", 4, 7), true, "admin"}, opts.String1)
+	assert.Equal(t, MapStringOption{
+		"key1": {tSrc("/etc/figtree.yml", 6, 9), true, "admin"},
+		"key2": {tSrc("figtree.yml", 4, 9), true, "user"},
+	}, opts.Map1)
+	assert.Equal(t, Float32Option{tSrc("/etc/figtree.yml", 7, 9), true, 1.5}, opts.Float1)
+	assert.Equal(t, BoolOption{tSrc("figtree.yml", 6, 8), true, true}, opts.Bool1)
+
+	_, err = load("str1: user\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "figtree.yml:1:7: str1 is locked by /etc/figtree.yml:4:7 and cannot be changed")
+
+	_, err = load("map1:\n  key1: user\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "figtree.yml:2:9: map1.key1 is locked by /etc/figtree.yml:6:9 and cannot be changed")
+
+	// locks apply to fields that are not Options
+	builtin, err := SourceFromString("figtree.yml", "str1: user\n")
+	require.NoError(t, err)
+	plain := TestBuiltin{}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{admin, builtin}, &plain)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "figtree.yml:1:7: str1 is locked by /etc/figtree.yml:4:7 and cannot be changed")
+
+	// locks from lower precedence sources also apply to the values set by
+	// higher precedence sources
+	_, err = load("config:\n  lock: [float1]\nfloat1: 3.5\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/etc/figtree.yml:7:9: float1 is locked by figtree.yml:3:9 and cannot be changed")
+	opts, err = load("config:\n  lock: [float1]\nfloat1: 1.5\n")
+	require.NoError(t, err)
+	assert.Equal(t, Float32Option{tSrc("/etc/figtree.yml", 7, 9), true, 1.5}, opts.Float1)
+}
+
+func TestLockPragmaDiscovered(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml":     "config:\n  lock: [str1]\nstr1: admin\n",
+		"sub/figtree.yml": "str1: user\n",
+	})
+	load := func(opts ...CreateOption) (TestOptions, error) {
+		opts = append(opts, WithHome(t.TempDir()), WithCwd(filepath.Join(dir, "sub")))
+		got := TestOptions{}
+		err := newFigTreeFromEnv(opts...).LoadAllConfigs("figtree.yml", &got)
+		return got, err
+	}
+
+	// the parent directory is merged after the closer file, its lock still
+	// applies to the value set by the closer file
+	_, err := load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "figtree.yml:1:7: str1 is locked by ../figtree.yml:3:7 and cannot be changed")
+
+	// with a higher Priority the locking file is merged first
+	_, err = load(WithSourceMetadata(func(file string, cs *ConfigSource) {
+		if file == filepath.Join(dir, "figtree.yml") {
+			cs.Priority = 1
+		}
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "str1 is locked by ../figtree.yml:3:7 and cannot be changed")
+
+	// the same value is allowed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "figtree.yml"), []byte("str1: admin\n"), 0o644))
+	opts, err := load()
+	require.NoError(t, err)
+	assert.Equal(t, "admin", opts.String1.Value)
+}
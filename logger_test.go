@@ -0,0 +1,55 @@
+package figtree
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestWithLogger(t *testing.T) {
+	require.NoError(t, os.Chdir("d1/d2/d3"))
+	t.Cleanup(func() {
+		_ = os.Chdir("../../..")
+	})
+
+	global := &recordingLogger{}
+	orig := Log
+	Log = global
+	t.Cleanup(func() {
+		Log = orig
+	})
+
+	logger := &recordingLogger{}
+	fig := newFigTreeFromEnv(WithLogger(logger))
+	opts := TestOptions{}
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Contains(t, logger.messages, fmt.Sprintf("Reading config %s/figtree.yml", fig.workDir))
+	assert.Empty(t, global.messages)
+
+	// without a logger the global is used
+	fig = newFigTreeFromEnv()
+	opts = TestOptions{}
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.NotEmpty(t, global.messages)
+
+	merger := &recordingLogger{}
+	global.messages = nil
+	dst := map[string]any{}
+	err = Merge(&dst, map[string]any{"key": []any{map[string]any{"a": 1}}}, WithMergeLogger(merger))
+	require.NoError(t, err)
+	assert.NotEmpty(t, merger.messages)
+	assert.Empty(t, global.messages)
+}
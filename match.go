@@ -0,0 +1,75 @@
+package figtree
+
+import (
+	"path"
+
+	"emperror.dev/errors"
+	"github.com/coryb/walky"
+	"gopkg.in/yaml.v3"
+)
+
+// WithMatchContext sets the values used to target documents with the match
+// config pragma, like:
+//
+//	config:
+//	  match:
+//	    hostname: "web-*"
+//	    role: [canary, staging]
+//
+// A document with a match pragma is only loaded when every key matches the
+// value in the match context.  Values are glob patterns, see path.Match, and
+// a list of patterns matches when any pattern matches.  Keys missing from
+// the match context never match.
+func WithMatchContext(values map[string]string) CreateOption {
+	return func(f *FigTree) {
+		f.matchContext = values
+	}
+}
+
+func (f *FigTree) WithMatchContext(values map[string]string) {
+	WithMatchContext(values)(f)
+}
+
+// matches returns true if the match pragma of config matches the match
+// context, or if config has no match pragma.
+func (f *FigTree) matches(file string, config *yaml.Node) (bool, error) {
+	pragma := walky.GetKey(walky.UnwrapDocument(config), "config")
+	if pragma == nil {
+		return true, nil
+	}
+	match := walky.GetKey(pragma, "match")
+	if match == nil {
+		return true, nil
+	}
+	if match.Kind != yaml.MappingNode {
+		return false, errors.Errorf("%s: config match must be a map", sourceLine(file, match))
+	}
+	for i := 0; i+1 < len(match.Content); i += 2 {
+		key, patterns := match.Content[i], match.Content[i+1]
+		value, ok := f.matchContext[key.Value]
+		if !ok {
+			return false, nil
+		}
+		if patterns.Kind == yaml.ScalarNode {
+			patterns = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{patterns}}
+		}
+		if patterns.Kind != yaml.SequenceNode {
+			return false, errors.Errorf("%s: config match %s must be a pattern or list of patterns", sourceLine(file, patterns), key.Value)
+		}
+		matched := false
+		for _, pattern := range patterns.Content {
+			ok, err := path.Match(pattern.Value, value)
+			if err != nil {
+				return false, errors.Wrapf(err, "%s: invalid config match pattern %q", sourceLine(file, pattern), pattern.Value)
+			}
+			if ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,59 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigMatchContext(t *testing.T) {
+	canary, err := SourceFromString("canary.yml", `
+config:
+  match:
+    hostname: "web-*"
+    role: [canary, staging]
+str1: canary
+`)
+	require.NoError(t, err)
+	db, err := SourceFromString("db.yml", `
+config:
+  match:
+    hostname: db-*
+int1: 5
+`)
+	require.NoError(t, err)
+	base, err := SourceFromString("base.yml", "str1: base\nint1: 1\n")
+	require.NoError(t, err)
+	sources := []ConfigSource{canary, db, base}
+
+	load := func(context map[string]string) TestOptions {
+		t.Helper()
+		opts := TestOptions{}
+		fig := newFigTreeFromEnv(WithMatchContext(context))
+		require.NoError(t, fig.LoadAllConfigSources(sources, &opts))
+		return opts
+	}
+
+	opts := load(map[string]string{"hostname": "web-01", "role": "canary"})
+	assert.Equal(t, StringOption{tSrc("canary.yml", 6, 7), true, "canary"}, opts.String1)
+	assert.Equal(t, IntOption{tSrc("base.yml", 2, 7), true, 1}, opts.Int1)
+
+	opts = load(map[string]string{"hostname": "db-01", "role": "canary"})
+	assert.Equal(t, StringOption{tSrc("base.yml", 1, 7), true, "base"}, opts.String1)
+	assert.Equal(t, IntOption{tSrc("db.yml", 5, 7), true, 5}, opts.Int1)
+
+	// missing keys do not match
+	opts = load(map[string]string{"hostname": "web-01"})
+	assert.Equal(t, StringOption{tSrc("base.yml", 1, 7), true, "base"}, opts.String1)
+}
+
+func TestLoadConfigMatchInvalidPattern(t *testing.T) {
+	src, err := SourceFromString("bad.yml", "config:\n  match:\n    hostname: \"[\"\n")
+	require.NoError(t, err)
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithMatchContext(map[string]string{"hostname": "web"}))
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `bad.yml:3:15: invalid config match pattern "["`)
+}
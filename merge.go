@@ -0,0 +1,372 @@
+package figtree
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"emperror.dev/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Merge strategies that can be set on struct fields with the
+// `figtree:",merge=<strategy>"` tag to combine values from multiple sources
+// rather than using the value from the highest precedence source.
+const (
+	// MergeSum adds the numeric values from all sources, a sum that does not
+	// fit in the field type is an error.
+	MergeSum = "sum"
+	// MergeMax uses the largest numeric value from all sources.
+	MergeMax = "max"
+	// MergeMin uses the smallest numeric value from all sources.
+	MergeMin = "min"
+	// MergeSet uses the sorted union of the string values from all sources,
+	// a value prefixed with `!` removes that value from the set contributed
+	// by lower precedence sources.
+	MergeSet = "set"
+)
+
+// mergeWithStrategy will merge src into dst using the merge strategy.
+// handled is false when the strategy does not apply, for example when dst
+// has no value yet, in which case the normal merge rules should be used.
+func (m *Merger) mergeWithStrategy(strategy, name string, dst reflect.Value, src mergeSource) (handled, changed bool, err error) {
+	switch strategy {
+	case MergeSum, MergeMax, MergeMin:
+		return m.mergeNumeric(strategy, name, dst, src)
+	case MergeSet:
+		return m.mergeSet(name, dst, src)
+	}
+	return false, false, errors.Errorf("%s: unknown merge strategy %q", name, strategy)
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// mergeNumeric will combine the numeric value in src with dst according to
+// the sum, max or min strategy.  dst can be a numeric Option or a raw numeric
+// value.
+func (m *Merger) mergeNumeric(strategy, name string, dst reflect.Value, src mergeSource) (handled, changed bool, err error) {
+	target := dst
+	dstOption := toOption(dst)
+	if dstOption != nil {
+		target = dst.FieldByName("Value")
+	}
+	if !target.IsValid() || !isNumericKind(target.Kind()) {
+		return false, false, errors.Errorf("%s: merge=%s requires a numeric field, got %s", name, strategy, dst.Type())
+	}
+	if !src.isValid() || src.isZero() {
+		return true, false, nil
+	}
+	if dstOption != nil && isZeroOrDefaultOption(dst) || dstOption == nil && dst.IsZero() {
+		// nothing to combine with yet
+		return false, false, nil
+	}
+
+	val, coord, err := src.reflect()
+	if err != nil {
+		return false, false, err
+	}
+	source := m.newSource(coord)
+	if option := toOption(val); option != nil {
+		if option.IsDefault() || !option.IsDefined() {
+			// defaults never contribute once a value has been set
+			return true, false, nil
+		}
+		if option.GetSource().Name != "" {
+			source = option.GetSource()
+		}
+	}
+	srcValue := reflect.New(target.Type()).Elem()
+	if _, err := m.assignValue(srcValue, src, assignOptions{Overwrite: true}); err != nil {
+		return false, false, err
+	}
+
+	useSrc := false
+	switch strategy {
+	case MergeSum:
+		if sumOverflows(target, srcValue) {
+			return false, false, errors.WithStack(overflowError{
+				value:          fmt.Sprintf("%v + %v", target.Interface(), srcValue.Interface()),
+				dstType:        target.Type(),
+				sourceLocation: source,
+			})
+		}
+		switch {
+		case target.CanInt():
+			target.SetInt(target.Int() + srcValue.Int())
+		case target.CanUint():
+			target.SetUint(target.Uint() + srcValue.Uint())
+		default:
+			target.SetFloat(target.Float() + srcValue.Float())
+		}
+		return true, !srcValue.IsZero(), nil
+	case MergeMax:
+		useSrc = numericLess(target, srcValue)
+	case MergeMin:
+		useSrc = numericLess(srcValue, target)
+	}
+	if !useSrc {
+		return true, false, nil
+	}
+	target.Set(srcValue)
+	if dstOption != nil {
+		dstOption.SetSource(source)
+	}
+	return true, true, nil
+}
+
+// sumOverflows returns true if the sum of the numeric values a and b, both
+// the same type, does not fit in that type.
+func sumOverflows(a, b reflect.Value) bool {
+	switch {
+	case a.CanInt():
+		x, y := a.Int(), b.Int()
+		sum := x + y
+		return y > 0 && sum < x || y < 0 && sum > x || a.OverflowInt(sum)
+	case a.CanUint():
+		x, y := a.Uint(), b.Uint()
+		sum := x + y
+		return sum < x || a.OverflowUint(sum)
+	}
+	x, y := a.Float(), b.Float()
+	sum := x + y
+	if math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return false
+	}
+	return math.IsInf(sum, 0) || a.OverflowFloat(sum)
+}
+
+// numericLess returns true if numeric value a is less than b, both values
+// must be the same type.
+func numericLess(a, b reflect.Value) bool {
+	switch {
+	case a.CanInt():
+		return a.Int() < b.Int()
+	case a.CanUint():
+		return a.Uint() < b.Uint()
+	}
+	return a.Float() < b.Float()
+}
+
+// setKey identifies a `merge=set` list while merging.
+type setKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// setElemValue returns the string value of a `merge=set` list element.
+func setElemValue(v reflect.Value) (string, bool) {
+	if option := toOption(v); option != nil {
+		v = reflect.ValueOf(option.GetValue())
+	}
+	if !v.IsValid() || v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// mergeSet will merge the src list into the dst list as a set of strings,
+// see MergeSet.
+func (m *Merger) mergeSet(name string, dst reflect.Value, src mergeSource) (handled, changed bool, err error) {
+	if dst.Kind() != reflect.Slice || !isSetElemType(dst.Type().Elem()) {
+		return false, false, errors.Errorf("%s: merge=set requires a string list, got %s", name, dst.Type())
+	}
+	if !src.isValid() || src.isZero() {
+		return true, false, nil
+	}
+	if !src.isList() {
+		return false, false, nil
+	}
+
+	removed := map[string]struct{}{}
+	if dst.CanAddr() {
+		key := setKey{addr: dst.UnsafeAddr(), typ: dst.Type()}
+		if m.setRemovals == nil {
+			m.setRemovals = map[setKey]map[string]struct{}{}
+		}
+		if prev, ok := m.setRemovals[key]; ok {
+			removed = prev
+		} else {
+			m.setRemovals[key] = removed
+		}
+	}
+
+	existing := make(map[string]struct{}, dst.Len())
+	for i := 0; i < dst.Len(); i++ {
+		if value, ok := setElemValue(dst.Index(i)); ok {
+			existing[value] = struct{}{}
+		}
+	}
+
+	cp := reflect.MakeSlice(dst.Type(), dst.Len(), dst.Len())
+	reflect.Copy(cp, dst)
+	err = src.foreach(func(_ int, item mergeSource) error {
+		var value string
+		if item.node != nil {
+			if item.node.Kind != yaml.ScalarNode {
+				return errors.Errorf("%s: invalid set value at line %d, column %d, expected a string", name, item.node.Line, item.node.Column)
+			}
+			value = negatableNodeValue(item.node)
+		} else {
+			reflected, _, err := item.reflect()
+			if err != nil {
+				return err
+			}
+			var ok bool
+			if value, ok = setElemValue(reflected); !ok {
+				if !reflected.IsValid() {
+					return nil
+				}
+				return errors.Errorf("%s: invalid set value %v, expected a string", name, reflected)
+			}
+		}
+		if len(value) > 1 && value[0] == '!' {
+			removed[value[1:]] = struct{}{}
+			return nil
+		}
+		if _, ok := removed[value]; ok {
+			return nil
+		}
+		if _, ok := existing[value]; ok {
+			return nil
+		}
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		if _, err := m.assignValue(elem, item, assignOptions{}); err != nil {
+			return err
+		}
+		existing[value] = struct{}{}
+		cp = reflect.Append(cp, elem)
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return false, false, err
+	}
+	if changed {
+		sort.SliceStable(cp.Interface(), func(i, j int) bool {
+			a, _ := setElemValue(cp.Index(i))
+			b, _ := setElemValue(cp.Index(j))
+			return a < b
+		})
+		dst.Set(cp)
+	}
+	return true, changed, nil
+}
+
+// isSetElemType returns true if t is a string or an Option with a string
+// value.
+func isSetElemType(t reflect.Type) bool {
+	if t.Kind() == reflect.String {
+		return true
+	}
+	if isOptionType(t) {
+		if field, ok := t.FieldByName("Value"); ok {
+			return field.Type.Kind() == reflect.String
+		}
+	}
+	return false
+}
+
+// dedupSet tracks the values of list elements so duplicates can be found
+// when merging lists.  Hashable values are tracked in a map, other values
+// fall back to a linear scan with reflect.DeepEqual.
+type dedupSet struct {
+	hashed   map[any]struct{}
+	unhashed []any
+}
+
+func newDedupSet(list reflect.Value) *dedupSet {
+	s := &dedupSet{hashed: make(map[any]struct{}, list.Len())}
+	for i := 0; i < list.Len(); i++ {
+		s.add(dedupValue(list.Index(i)))
+	}
+	return s
+}
+
+// dedupValue returns the underlying value of a list element, unwrapping
+// Options and interfaces.
+func dedupValue(v reflect.Value) reflect.Value {
+	if option := toOption(v); option != nil {
+		return reflect.ValueOf(option.GetValue())
+	}
+	if v.IsValid() && v.CanInterface() {
+		return reflect.ValueOf(v.Interface())
+	}
+	return v
+}
+
+// isHashable returns true if values of type t can be used as map keys and
+// map key equality is the same as reflect.DeepEqual.
+func isHashable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return isHashable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isHashable(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (s *dedupSet) add(v reflect.Value) {
+	if !v.IsValid() || !v.CanInterface() {
+		return
+	}
+	if isHashable(v.Type()) {
+		s.hashed[v.Interface()] = struct{}{}
+		return
+	}
+	s.unhashed = append(s.unhashed, v.Interface())
+}
+
+func (s *dedupSet) contains(v reflect.Value) bool {
+	if !v.IsValid() || !v.CanInterface() {
+		return false
+	}
+	if isHashable(v.Type()) {
+		_, ok := s.hashed[v.Interface()]
+		return ok
+	}
+	for _, u := range s.unhashed {
+		if reflect.DeepEqual(u, v.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupKey returns the value of the list item normalized to the list element
+// value type, so it can be compared to the existing elements in a dedupSet.
+func (m *Merger) dedupKey(elemType reflect.Type, value reflect.Value, item mergeSource) reflect.Value {
+	valueType := elemType
+	if isOptionType(elemType) {
+		if field, ok := elemType.FieldByName("Value"); ok {
+			valueType = field.Type
+		}
+	}
+	if valueType.Kind() == reflect.Interface {
+		return dedupValue(value)
+	}
+	tmp := reflect.New(valueType).Elem()
+	if _, err := m.assignValue(tmp, item, assignOptions{}); err != nil {
+		return dedupValue(value)
+	}
+	return dedupValue(tmp)
+}
@@ -0,0 +1,352 @@
+package figtree
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeNumericStrategies(t *testing.T) {
+	type config struct {
+		Memory   IntOption     `yaml:"memory" figtree:",merge=sum"`
+		Ratio    Float64Option `yaml:"ratio" figtree:",merge=sum"`
+		Timeout  UintOption    `yaml:"timeout" figtree:",merge=max"`
+		Workers  IntOption     `yaml:"workers" figtree:",merge=min"`
+		Replicas int           `yaml:"replicas" figtree:",merge=sum"`
+		Name     StringOption  `yaml:"name"`
+	}
+
+	top, err := SourceFromString("top.yml", "memory: 512\nratio: 0.25\ntimeout: 10\nworkers: 8\nreplicas: 1\nname: top\n")
+	require.NoError(t, err)
+	mid, err := SourceFromString("mid.yml", "memory: 256\ntimeout: 30\nworkers: 2\nreplicas: 2\nname: mid\n")
+	require.NoError(t, err)
+	bottom, err := SourceFromString("bottom.yml", "memory: 128\nratio: 0.5\ntimeout: 20\nworkers: 4\n")
+	require.NoError(t, err)
+
+	opts := config{
+		Memory:  NewIntOption(64),
+		Workers: NewIntOption(1),
+	}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{top, mid, bottom}, &opts)
+	require.NoError(t, err)
+
+	expected := config{
+		Memory:   IntOption{tSrc("top.yml", 1, 9), true, 896},
+		Ratio:    Float64Option{tSrc("top.yml", 2, 8), true, 0.75},
+		Timeout:  UintOption{tSrc("mid.yml", 2, 10), true, 30},
+		Workers:  IntOption{tSrc("mid.yml", 3, 10), true, 2},
+		Replicas: 3,
+		Name:     StringOption{tSrc("top.yml", 6, 7), true, "top"},
+	}
+	assert.Equal(t, expected, opts)
+
+	// overrides contribute like any other source
+	opts = config{}
+	fig = newFigTreeFromEnv(WithOverrides(map[string]any{"memory": 1024}))
+	err = fig.LoadAllConfigSources([]ConfigSource{top}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, IntOption{NewSource("override"), true, 1536}, opts.Memory)
+}
+
+func TestMergeStrategyErrors(t *testing.T) {
+	type badStrategy struct {
+		Value IntOption `yaml:"value" figtree:",merge=bogus"`
+	}
+	type notNumeric struct {
+		Value StringOption `yaml:"value" figtree:",merge=sum"`
+	}
+	src, err := SourceFromString("figtree.yml", "value: 1\n")
+	require.NoError(t, err)
+
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &badStrategy{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `value: unknown merge strategy "bogus"`)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &notNumeric{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "value: merge=sum requires a numeric field")
+
+	type overflow struct {
+		Small  Option[int8] `yaml:"small" figtree:",merge=sum"`
+		Count  uint8        `yaml:"count" figtree:",merge=sum"`
+		Signed int64        `yaml:"signed" figtree:",merge=sum"`
+	}
+	for _, tt := range []struct {
+		config string
+		err    string
+	}{
+		{"small: 100\n", "figtree.yml:1:8: 100 + 100 overflows int8"},
+		{"count: 200\n", "figtree.yml:1:8: 200 + 200 overflows uint8"},
+		{"signed: 9223372036854775807\n", "figtree.yml:1:9: 9223372036854775807 + 9223372036854775807 overflows int64"},
+	} {
+		src, err := SourceFromString("figtree.yml", tt.config)
+		require.NoError(t, err)
+		err = fig.LoadAllConfigSources([]ConfigSource{src, src}, &overflow{})
+		require.Error(t, err, tt.config)
+		assert.Contains(t, err.Error(), tt.err)
+	}
+}
+
+func TestMergeSetStrategy(t *testing.T) {
+	type config struct {
+		Labels ListStringOption `yaml:"labels" figtree:",merge=set"`
+		Tags   []string         `yaml:"tags" figtree:",merge=set"`
+	}
+
+	top, err := SourceFromString("top.yml", "labels: [zeta, alpha, \"!beta\"]\ntags: [b, a]\n")
+	require.NoError(t, err)
+	bottom, err := SourceFromString("bottom.yml", "labels:\n  - beta\n  - alpha\n  - gamma\n  - !zeta\ntags: [c, a]\n")
+	require.NoError(t, err)
+
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{top, bottom}, &opts)
+	require.NoError(t, err)
+
+	expected := config{
+		Labels: ListStringOption{
+			StringOption{tSrc("top.yml", 1, 16), true, "alpha"},
+			StringOption{tSrc("bottom.yml", 4, 5), true, "gamma"},
+			StringOption{tSrc("top.yml", 1, 10), true, "zeta"},
+		},
+		Tags: []string{"a", "b", "c"},
+	}
+	assert.Equal(t, expected, opts)
+
+	type notStrings struct {
+		Values ListIntOption `yaml:"values" figtree:",merge=set"`
+	}
+	src, err := SourceFromString("figtree.yml", "values: [1]\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &notStrings{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "values: merge=set requires a string list")
+}
+
+func TestMergeListDedup(t *testing.T) {
+	type config struct {
+		Strings ListStringOption `yaml:"strings"`
+		Ints    []int            `yaml:"ints"`
+		Any     []any            `yaml:"any"`
+	}
+	top, err := SourceFromString("top.yml", "strings: [a, b]\nints: [1, 2]\nany: [1, x, {k: v}]\n")
+	require.NoError(t, err)
+	bottom, err := SourceFromString("bottom.yml", "strings: [b, c, c]\nints: [2, 3]\nany: [x, {k: v}, {k: w}]\n")
+	require.NoError(t, err)
+
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{top, bottom}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, opts.Strings.Slice())
+	assert.Equal(t, []int{1, 2, 3}, opts.Ints)
+	assert.Equal(t, []any{1, "x", map[string]any{"k": "v"}, map[string]any{"k": "w"}}, opts.Any)
+}
+
+func largeListSources(b *testing.B, n int) []ConfigSource {
+	b.Helper()
+	sources := []ConfigSource{}
+	for s := 0; s < 2; s++ {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = fmt.Sprintf("item-%d", i+s*n/2)
+		}
+		source, err := SourceFromMap(fmt.Sprintf("source%d.yml", s), map[string]any{
+			"arr1": items,
+		})
+		require.NoError(b, err)
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+func BenchmarkMergeLargeLists(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		sources := largeListSources(b, n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			fig := newFigTreeFromEnv()
+			for i := 0; i < b.N; i++ {
+				opts := TestOptions{}
+				if err := fig.LoadAllConfigSources(sources, &opts); err != nil {
+					b.Fatal(err)
+				}
+				if len(opts.Array1) != n+n/2 {
+					b.Fatalf("expected %d items, got %d", n+n/2, len(opts.Array1))
+				}
+			}
+		})
+	}
+}
+
+func TestMergeWithIgnoreKeys(t *testing.T) {
+	type config struct {
+		Name        StringOption            `yaml:"name"`
+		Credentials map[string]StringOption `yaml:"credentials"`
+		Servers     map[string]struct {
+			Host     StringOption `yaml:"host"`
+			Password StringOption `yaml:"password"`
+		} `yaml:"servers"`
+	}
+
+	src, err := SourceFromString("config.yml", `
+name: example
+credentials:
+  token: secret
+  user: bob
+servers:
+  prod:
+    host: prod.example.com
+    password: hunter2
+  test:
+    host: test.example.com
+`)
+	require.NoError(t, err)
+
+	opts := config{}
+	fig := newFigTreeFromEnv(WithMergeOptions(WithIgnoreKeys("credentials.*", "servers.*.password")))
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, StringOption{tSrc("config.yml", 2, 7), true, "example"}, opts.Name)
+	assert.Empty(t, opts.Credentials)
+	assert.Equal(t, StringOption{tSrc("config.yml", 8, 11), true, "prod.example.com"}, opts.Servers["prod"].Host)
+	assert.False(t, opts.Servers["prod"].Password.Defined)
+	assert.Equal(t, StringOption{tSrc("config.yml", 11, 11), true, "test.example.com"}, opts.Servers["test"].Host)
+
+	// ignoring a key ignores everything nested under it
+	dst := map[string]any{}
+	err = Merge(&dst, map[string]any{
+		"name":        "example",
+		"credentials": map[string]any{"token": "secret"},
+	}, WithIgnoreKeys("credentials"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "example"}, dst)
+}
+
+func TestMergeAnyListElements(t *testing.T) {
+	type listConfig struct {
+		List ListStringOption `yaml:"list"`
+	}
+	type anyConfig struct {
+		List []any `yaml:"list"`
+	}
+	merge := func(mode AnyListElements) []any {
+		dst := anyConfig{}
+		err := Merge(&dst, listConfig{
+			List: ListStringOption{{NewSource("first"), true, "a"}},
+		}, WithAnyListElements(mode))
+		require.NoError(t, err)
+		err = Merge(&dst, anyConfig{List: []any{"b", 1}}, WithAnyListElements(mode))
+		require.NoError(t, err)
+		return dst.List
+	}
+
+	assert.Equal(t, []any{StringOption{NewSource("first"), true, "a"}, "b", 1}, merge(AnyListElementsAsIs))
+	assert.Equal(t, []any{"a", "b", 1}, merge(AnyListElementsUnwrapped))
+	assert.Equal(t, []any{
+		Option[any]{NewSource("first"), true, "a"},
+		Option[any]{NewSource("merge"), true, "b"},
+		Option[any]{NewSource("merge"), true, 1},
+	}, merge(AnyListElementsWrapped))
+}
+
+func TestMergerOverlay(t *testing.T) {
+	type config struct {
+		Name   StringOption            `yaml:"name"`
+		Labels map[string]StringOption `yaml:"labels"`
+		Hosts  []StringOption          `yaml:"hosts"`
+		Extra  any                     `yaml:"extra"`
+	}
+	base := &config{
+		Name:   NewStringOption("base"),
+		Labels: map[string]StringOption{"env": NewStringOption("prod"), "team": NewStringOption("core")},
+		Hosts:  []StringOption{NewStringOption("a")},
+		Extra:  map[string]any{"base": 1},
+	}
+	overrides := config{
+		Labels: map[string]StringOption{"env": NewStringOption("canary")},
+		Extra:  map[string]any{"request": 2},
+	}
+	baseCopy, overridesCopy := DeepCopy(base), DeepCopy(overrides)
+
+	m := NewMerger()
+	merged, err := m.Overlay(base, overrides)
+	require.NoError(t, err)
+	assert.Equal(t, &config{
+		Name:   NewStringOption("base"),
+		Labels: map[string]StringOption{"env": NewStringOption("canary"), "team": NewStringOption("core")},
+		Hosts:  []StringOption{NewStringOption("a")},
+		Extra:  map[string]any{"base": 1, "request": 2},
+	}, merged)
+
+	// the inputs are not modified
+	assert.Equal(t, baseCopy, base)
+	assert.Equal(t, overridesCopy, overrides)
+
+	// struct values return struct values
+	merged, err = m.Overlay(*base, nil)
+	require.NoError(t, err)
+	assert.Equal(t, *base, merged)
+}
+
+func TestMergerProvenance(t *testing.T) {
+	local, err := SourceFromString("local.yml", `
+str1: local
+map1:
+  key1: local
+`)
+	require.NoError(t, err)
+	global, err := SourceFromString("global.yml", `
+str1: global
+int1: 2
+map1:
+  key1: global
+  key2: global
+arr1: [a, b]
+`)
+	require.NoError(t, err)
+
+	opts := TestBuiltin{}
+	m := NewMerger()
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSourcesWithMerger(m, []ConfigSource{local, global}, &opts)
+	require.NoError(t, err)
+
+	provenance := m.Provenance()
+	assert.Equal(t, tSrc("local.yml", 2, 7), provenance["str1"])
+	assert.Equal(t, tSrc("global.yml", 3, 7), provenance["int1"])
+	assert.Equal(t, tSrc("local.yml", 4, 9), provenance["map1.key1"])
+	assert.Equal(t, tSrc("global.yml", 6, 9), provenance["map1.key2"])
+	assert.Equal(t, tSrc("global.yml", 7, 8), provenance["arr1"])
+	assert.NotContains(t, provenance, "leave-empty")
+
+	// Option sources are reported as-is
+	dst := TestBuiltin{}
+	m = NewMerger()
+	_, err = m.mergeStructs(reflect.ValueOf(&dst), newMergeSource(reflect.ValueOf(TestOptions{
+		String1: StringOption{tSrc("opts.yml", 1, 2), true, "value"},
+	})), false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]SourceLocation{"str1": tSrc("opts.yml", 1, 2)}, m.Provenance())
+}
+
+func BenchmarkMergerOverlay(b *testing.B) {
+	base := TestOptions{
+		String1: NewStringOption("base"),
+		Map1:    MapStringOption{"a": NewStringOption("1"), "b": NewStringOption("2")},
+		Array1:  ListStringOption{NewStringOption("x")},
+		Int1:    NewIntOption(1),
+	}
+	overrides := map[string]any{"str1": "request", "map1": map[string]any{"a": "3"}}
+	m := NewMerger()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.Overlay(&base, overrides); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package mergetest is a conformance suite for the documented merge
+// behavior of figtree.Merger, so custom Merger configurations and merge
+// extensions can be checked against the core invariants:
+//
+//   - higher precedence sources take precedence over lower precedence
+//     sources, and Options record the source of the value that won
+//   - zero values of plain fields, and undefined Options, do not take
+//     precedence over values from lower precedence sources
+//   - lists are appended in precedence order without duplicates
+//   - maps are merged key by key
+//   - the overwrite config pragma replaces the values from higher
+//     precedence sources and prevents lower precedence sources from
+//     changing them
+package mergetest
+
+import (
+	"testing"
+
+	"github.com/coryb/figtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MergerFactory returns a new Merger to test, each subtest uses a new
+// Merger.
+type MergerFactory func() *figtree.Merger
+
+// Options are the options loaded by the conformance tests.
+type Options struct {
+	Name   figtree.StringOption     `yaml:"name"`
+	Count  figtree.IntOption        `yaml:"count"`
+	Ratio  figtree.Float64Option    `yaml:"ratio"`
+	Debug  figtree.BoolOption       `yaml:"debug"`
+	Tags   figtree.ListStringOption `yaml:"tags"`
+	Labels figtree.MapStringOption  `yaml:"labels"`
+	Plain  Plain                    `yaml:"plain"`
+}
+
+// Plain are fields that are not Options.
+type Plain struct {
+	Name   string            `yaml:"name"`
+	Count  int               `yaml:"count"`
+	Tags   []string          `yaml:"tags"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+// Run runs the conformance tests as subtests of t, using Mergers created by
+// newMerger.
+func Run(t *testing.T, newMerger MergerFactory) {
+	t.Run("Precedence", func(t *testing.T) {
+		opts := load(t, newMerger(), Options{},
+			"high.yml", "name: high\ncount: 1\nplain:\n  name: high\n",
+			"low.yml", "name: low\ncount: 2\nratio: 0.5\nplain:\n  name: low\n  count: 2\n",
+		)
+		assert.Equal(t, "high", opts.Name.Value)
+		assert.Equal(t, "high.yml:1:7", opts.Name.Source.String())
+		assert.Equal(t, 1, opts.Count.Value)
+		assert.Equal(t, 0.5, opts.Ratio.Value)
+		assert.Equal(t, "low.yml:3:8", opts.Ratio.Source.String())
+		assert.Equal(t, Plain{Name: "high", Count: 2}, opts.Plain)
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		defaults := Options{
+			Name:  figtree.NewStringOption("default"),
+			Count: figtree.NewIntOption(10),
+		}
+		opts := load(t, newMerger(), defaults,
+			"config.yml", "count: 3\n",
+		)
+		assert.Equal(t, "default", opts.Name.Value)
+		assert.True(t, opts.Name.IsDefault())
+		assert.Equal(t, 3, opts.Count.Value)
+		assert.Equal(t, "config.yml:1:8", opts.Count.Source.String())
+	})
+
+	t.Run("ZeroValues", func(t *testing.T) {
+		opts := load(t, newMerger(), Options{},
+			"high.yml", "name: \"\"\ndebug: false\nplain:\n  name: \"\"\n  count: 0\n",
+			"low.yml", "name: low\ndebug: true\nplain:\n  name: low\n  count: 2\n",
+		)
+		// defined Options with zero values take precedence
+		assert.Equal(t, "", opts.Name.Value)
+		assert.True(t, opts.Name.Defined)
+		assert.Equal(t, "high.yml:1:7", opts.Name.Source.String())
+		assert.False(t, opts.Debug.Value)
+		assert.Equal(t, "high.yml:2:8", opts.Debug.Source.String())
+		// zero values of plain fields do not
+		assert.Equal(t, Plain{Name: "low", Count: 2}, opts.Plain)
+		// undefined Options are not set
+		assert.False(t, opts.Ratio.Defined)
+	})
+
+	t.Run("Lists", func(t *testing.T) {
+		opts := load(t, newMerger(), Options{},
+			"high.yml", "tags: [a, b]\nplain:\n  tags: [a, b]\n",
+			"low.yml", "tags: [b, c, c]\nplain:\n  tags: [c, a]\n",
+		)
+		assert.Equal(t, []string{"a", "b", "c"}, opts.Tags.Slice())
+		require.Len(t, opts.Tags, 3)
+		assert.Equal(t, "high.yml:1:11", opts.Tags[1].Source.String())
+		assert.Equal(t, "low.yml:1:11", opts.Tags[2].Source.String())
+		assert.Equal(t, []string{"a", "b", "c"}, opts.Plain.Tags)
+	})
+
+	t.Run("Maps", func(t *testing.T) {
+		opts := load(t, newMerger(), Options{},
+			"high.yml", "labels:\n  a: high\nplain:\n  labels:\n    a: high\n",
+			"low.yml", "labels:\n  a: low\n  b: low\nplain:\n  labels:\n    a: low\n    b: low\n",
+		)
+		assert.Equal(t, map[string]string{"a": "high", "b": "low"}, opts.Labels.Map())
+		assert.Equal(t, "high.yml:2:6", opts.Labels["a"].Source.String())
+		assert.Equal(t, "low.yml:3:6", opts.Labels["b"].Source.String())
+		assert.Equal(t, map[string]string{"a": "high", "b": "low"}, opts.Plain.Labels)
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		opts := load(t, newMerger(), Options{},
+			"high.yml", "name: high\ntags: [a]\nlabels:\n  a: high\n",
+			"middle.yml", "config:\n  overwrite: [name, tags, labels]\nname: middle\ntags: [b]\nlabels:\n  b: middle\n",
+			"low.yml", "name: low\ntags: [c]\nlabels:\n  c: low\ncount: 3\n",
+		)
+		assert.Equal(t, "middle", opts.Name.Value)
+		assert.Equal(t, "middle.yml:3:7", opts.Name.Source.String())
+		assert.Equal(t, []string{"b"}, opts.Tags.Slice())
+		assert.Equal(t, map[string]string{"b": "middle"}, opts.Labels.Map())
+		// fields that were not overwritten are merged as usual
+		assert.Equal(t, 3, opts.Count.Value)
+	})
+}
+
+// load merges the named yaml documents, in precedence order, into a copy of
+// defaults with m.
+func load(t *testing.T, m *figtree.Merger, defaults Options, nameAndData ...string) Options {
+	t.Helper()
+	sources := []figtree.ConfigSource{}
+	for i := 0; i+1 < len(nameAndData); i += 2 {
+		source, err := figtree.SourceFromString(nameAndData[i], nameAndData[i+1])
+		require.NoError(t, err)
+		sources = append(sources, source)
+	}
+	fig := figtree.NewFigTree(
+		figtree.WithEnviron(func(string) string { return "" }),
+		figtree.WithApplyChangeSet(func(map[string]*string) error { return nil }),
+	)
+	opts := figtree.DeepCopy(defaults)
+	require.NoError(t, fig.LoadAllConfigSourcesWithMerger(m, sources, &opts))
+	return opts
+}
@@ -0,0 +1,19 @@
+package mergetest
+
+import (
+	"testing"
+
+	"github.com/coryb/figtree"
+)
+
+func TestRun(t *testing.T) {
+	Run(t, func() *figtree.Merger {
+		return figtree.NewMerger()
+	})
+}
+
+func TestRunDeepCopyValues(t *testing.T) {
+	Run(t, func() *figtree.Merger {
+		return figtree.NewMerger(figtree.DeepCopyValues())
+	})
+}
@@ -0,0 +1,63 @@
+package figtree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	first, err := SourceFromString("first", "str1: first\n")
+	require.NoError(t, err)
+	second, err := SourceFromString("second", "str1: second\nint1: 2\n")
+	require.NoError(t, err)
+	skipped, err := SourceFromString("skipped", "bool1: true\n")
+	require.NoError(t, err)
+
+	calls := []string{}
+	record := func(name string) Middleware {
+		return func(next LoadFunc) LoadFunc {
+			return func(source ConfigSource, options any) error {
+				calls = append(calls, name+" "+source.Filename)
+				return next(source, options)
+			}
+		}
+	}
+	skip := func(next LoadFunc) LoadFunc {
+		return func(source ConfigSource, options any) error {
+			if source.Filename == "skipped" {
+				return nil
+			}
+			return next(source, options)
+		}
+	}
+	fig := newFigTreeFromEnv(
+		WithMiddleware(record("outer")),
+		WithMiddleware(skip),
+		WithMiddleware(record("inner")),
+	)
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{first, skipped, second}, &opts))
+	assert.Equal(t, []string{
+		"outer first",
+		"inner first",
+		"outer skipped",
+		"outer second",
+		"inner second",
+	}, calls)
+	assert.Equal(t, StringOption{tSrc("first", 1, 7), true, "first"}, opts.String1)
+	assert.Equal(t, IntOption{tSrc("second", 2, 7), true, 2}, opts.Int1)
+	assert.False(t, opts.Bool1.IsDefined())
+
+	// errors stop the load
+	failure := errors.New("denied")
+	fig.WithMiddleware(func(next LoadFunc) LoadFunc {
+		return func(source ConfigSource, options any) error {
+			return failure
+		}
+	})
+	err = fig.LoadAllConfigSources([]ConfigSource{first}, &TestOptions{})
+	assert.True(t, errors.Is(err, failure))
+}
@@ -0,0 +1,143 @@
+package figtree
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Normalize converts v into plain Go values suitable for templating or JSON
+// APIs.  Options are replaced by their values, yaml.Nodes are decoded, maps
+// become map[string]any, slices and arrays become []any and structs become
+// map[string]any keyed by their yaml field names.  URLs and regular
+// expressions become strings.  Scalars and structs without exported fields,
+// like time.Time, are returned as-is.
+func Normalize(v any) any {
+	n := normalizer{visiting: map[uintptr]bool{}}
+	return n.normalize(reflect.ValueOf(v))
+}
+
+// normalizeDefined is Normalize with undefined Options omitted.
+func normalizeDefined(v any) any {
+	n := normalizer{visiting: map[uintptr]bool{}, definedOnly: true}
+	if value := n.normalize(reflect.ValueOf(v)); value != (undefinedOption{}) {
+		return value
+	}
+	return nil
+}
+
+// normalizer tracks the pointers being normalized so cyclic references
+// terminate, a cycle is normalized to nil.
+type normalizer struct {
+	visiting map[uintptr]bool
+	// secret, when set, replaces the normalized value of Options with a
+	// secret source.
+	secret func(value any) any
+	// definedOnly omits undefined Options from structs, maps and lists.
+	definedOnly bool
+}
+
+// undefinedOption is the normalized value of undefined Options with
+// definedOnly, it is omitted from structs, maps and lists.
+type undefinedOption struct{}
+
+func (n *normalizer) normalize(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	switch t := v.Interface().(type) {
+	case yaml.Node:
+		return n.normalizeNode(&t)
+	case *yaml.Node:
+		if t == nil {
+			return nil
+		}
+		return n.normalizeNode(t)
+	case *url.URL, *regexp.Regexp:
+		if s, ok := stringValue(t); ok {
+			return s
+		}
+		return nil
+	}
+	if option := toOption(v); option != nil {
+		if n.definedOnly && !option.IsDefined() {
+			return undefinedOption{}
+		}
+		value := n.normalize(reflect.ValueOf(option.GetValue()))
+		if n.secret != nil && option.GetSource().Secret {
+			return n.secret(value)
+		}
+		return value
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		if n.visiting[v.Pointer()] {
+			return nil
+		}
+		n.visiting[v.Pointer()] = true
+		defer delete(n.visiting, v.Pointer())
+		return n.normalize(v.Elem())
+	case reflect.Interface:
+		return n.normalize(v.Elem())
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		result := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if value := n.normalize(iter.Value()); value != (undefinedOption{}) {
+				result[fmt.Sprint(iter.Key().Interface())] = value
+			}
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// leave []byte as a scalar
+			return v.Interface()
+		}
+		result := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if value := n.normalize(v.Index(i)); value != (undefinedOption{}) {
+				result = append(result, value)
+			}
+		}
+		return result
+	case reflect.Struct:
+		result := map[string]any{}
+		fields := 0
+		for name, field := range populateYAMLMaps(v) {
+			if field.StructField.PkgPath != "" || field.StructField.Anonymous || field.StructField.Tag.Get("yaml") == "-" {
+				continue
+			}
+			fields++
+			if value := n.normalize(field.Value); value != (undefinedOption{}) {
+				result[name] = value
+			}
+		}
+		if fields == 0 {
+			return v.Interface()
+		}
+		return result
+	}
+	return v.Interface()
+}
+
+// normalizeNode decodes node and normalizes the result, nodes that cannot be
+// decoded are normalized to nil.
+func (n *normalizer) normalizeNode(node *yaml.Node) any {
+	var decoded any
+	if err := node.Decode(&decoded); err != nil {
+		return nil
+	}
+	return n.normalize(reflect.ValueOf(decoded))
+}
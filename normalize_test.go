@@ -0,0 +1,55 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNormalize(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("a: [1, {b: c}]\n"), &node))
+
+	type inner struct {
+		Port IntOption `yaml:"port"`
+	}
+	type options struct {
+		inner  `yaml:",inline"`
+		Name   StringOption     `yaml:"name"`
+		Hosts  ListStringOption `yaml:"hosts"`
+		Any    any              `yaml:"any"`
+		Nested *inner           `yaml:"nested"`
+		Node   *yaml.Node       `yaml:"node"`
+		Skip   string           `yaml:"-"`
+		hidden string
+	}
+	opts := options{
+		inner: inner{Port: NewIntOption(80)},
+		Name:  NewStringOption("name"),
+		Hosts: ListStringOption{NewStringOption("a"), NewStringOption("b")},
+		Any: map[string]any{
+			"list": []any{NewBoolOption(true), "x", map[int]StringOption{1: NewStringOption("one")}},
+		},
+		Node:   &node,
+		Skip:   "skip",
+		hidden: "hidden",
+	}
+
+	expected := map[string]any{
+		"port":  80,
+		"name":  "name",
+		"hosts": []any{"a", "b"},
+		"any": map[string]any{
+			"list": []any{true, "x", map[string]any{"1": "one"}},
+		},
+		"nested": nil,
+		"node": map[string]any{
+			"a": []any{1, map[string]any{"b": "c"}},
+		},
+	}
+	assert.Equal(t, expected, Normalize(&opts))
+	assert.Equal(t, "scalar", Normalize("scalar"))
+	assert.Nil(t, Normalize(nil))
+}
@@ -4,7 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"regexp"
+	"strconv"
+	"strings"
 
 	"emperror.dev/errors"
 	"github.com/coryb/walky"
@@ -17,6 +18,11 @@ const (
 	promptSource   = "prompt"
 	yamlSource     = "yaml"
 	jsonSource     = "json"
+	envSource      = "env"
+
+	flagSourcePrefix    = "flag:"
+	envSourcePrefix     = "env:"
+	defaultSourcePrefix = "default:"
 )
 
 type option interface {
@@ -33,11 +39,11 @@ type option interface {
 // serialized as just the value (when value is true) or if the entire Option
 // struct should be serialized.  This is a hack, and not recommended for general
 // usage, but can be useful for debugging.
+//
+// Deprecated: the global is not safe for concurrent use, use Marshal or
+// MarshalJSON, optionally WithSources, to control serialization per call.
 var StringifyValue = true
 
-// stringMapRegex is used in option parsing for map types Set routines
-var stringMapRegex = regexp.MustCompile("[:=]")
-
 // FileCoordinate represents the line/column of an option
 type FileCoordinate struct {
 	Line   int
@@ -59,13 +65,162 @@ var (
 type SourceLocation struct {
 	Name     string
 	Location *FileCoordinate
+	// IncludeChain is the sources that caused this source to be loaded,
+	// outermost first and separated by ` -> `, when the source was included
+	// from another source, see Includes.  It is a string so SourceLocation,
+	// and so Options, can be compared with ==.
+	IncludeChain string
+	// Secret is set when the value was decrypted from an encrypted config
+	// value, see WithAgeDecrypter.
+	Secret bool
+	// Transforms are the transforms that ran on the value separated by `|`,
+	// like `trim|lower`, reported by Merger.Provenance, see
+	// RegisterTransform.
+	Transforms string
+	// usage is the read state of the option when it was loaded with usage
+	// tracking, see WithUsageTracking.
+	usage *usageField
 }
 
 func (s SourceLocation) String() string {
+	name := s.Name
+	if s.Location != nil {
+		name = fmt.Sprintf("%s:%d:%d", s.Name, s.Location.Line, s.Location.Column)
+	}
+	if s.IncludeChain != "" {
+		return s.IncludeChain + includeChainSeparator + name
+	}
+	return name
+}
+
+// includeChainSeparator separates the sources in SourceLocation.IncludeChain.
+const includeChainSeparator = " -> "
+
+// Includes returns the sources in the IncludeChain, outermost first.
+func (s SourceLocation) Includes() []string {
+	if s.IncludeChain == "" {
+		return nil
+	}
+	return strings.Split(s.IncludeChain, includeChainSeparator)
+}
+
+// ParseSourceLocation parses the String form of a SourceLocation, like
+// `file.yml:3:4` or `parent.yml -> file.yml:3:4`, back into a
+// SourceLocation.  Names without a trailing `:line:column` are returned
+// without a Location.
+func ParseSourceLocation(s string) (SourceLocation, error) {
+	if s == "" {
+		return SourceLocation{}, errors.New("empty source location")
+	}
+	parts := strings.Split(s, includeChainSeparator)
+	name := parts[len(parts)-1]
+	source := SourceLocation{Name: name}
+	if len(parts) > 1 {
+		source.IncludeChain = strings.Join(parts[:len(parts)-1], includeChainSeparator)
+	}
+	colIx := strings.LastIndex(name, ":")
+	if colIx < 0 {
+		return source, nil
+	}
+	lineIx := strings.LastIndex(name[:colIx], ":")
+	if lineIx < 0 {
+		return source, nil
+	}
+	line, err := strconv.Atoi(name[lineIx+1 : colIx])
+	if err != nil {
+		return source, nil
+	}
+	col, err := strconv.Atoi(name[colIx+1:])
+	if err != nil {
+		return source, nil
+	}
+	if name[:lineIx] == "" {
+		return SourceLocation{}, errors.Errorf("missing name in source location %q", s)
+	}
+	source.Name = name[:lineIx]
+	source.Location = &FileCoordinate{Line: line, Column: col}
+	return source, nil
+}
+
+// encodedSource is the serialized form of a SourceLocation.
+type encodedSource struct {
+	Name         string   `json:"name" yaml:"name"`
+	Line         int      `json:"line,omitempty" yaml:"line,omitempty"`
+	Column       int      `json:"column,omitempty" yaml:"column,omitempty"`
+	IncludeChain []string `json:"include-chain,omitempty" yaml:"include-chain,omitempty"`
+	Secret       bool     `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+func newEncodedSource(s SourceLocation) *encodedSource {
+	es := &encodedSource{Name: s.Name, IncludeChain: s.Includes(), Secret: s.Secret}
 	if s.Location != nil {
-		return fmt.Sprintf("%s:%d:%d", s.Name, s.Location.Line, s.Location.Column)
+		es.Line = s.Location.Line
+		es.Column = s.Location.Column
+	}
+	return es
+}
+
+func (es encodedSource) sourceLocation() SourceLocation {
+	source := SourceLocation{Name: es.Name, IncludeChain: strings.Join(es.IncludeChain, includeChainSeparator), Secret: es.Secret}
+	if es.Line != 0 || es.Column != 0 {
+		source.Location = &FileCoordinate{Line: es.Line, Column: es.Column}
+	}
+	return source
+}
+
+// MarshalYAML implements the Marshaler interface used by the yaml library.
+// The SourceLocation is serialized as a mapping with `name`, `line`,
+// `column`, `include-chain` and `secret` keys.
+func (s SourceLocation) MarshalYAML() (any, error) {
+	return newEncodedSource(s), nil
+}
+
+// UnmarshalYAML implements the Unmarshaler interface used by the yaml
+// library.  Both the mapping form written by MarshalYAML and the String form
+// are accepted.
+func (s *SourceLocation) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		source, err := ParseSourceLocation(node.Value)
+		if err != nil {
+			return err
+		}
+		*s = source
+		return nil
+	}
+	es := encodedSource{}
+	if err := node.Decode(&es); err != nil {
+		return err
+	}
+	*s = es.sourceLocation()
+	return nil
+}
+
+// MarshalJSON implements the Marshaler interface as defined by json.  The
+// SourceLocation is serialized as an object with `name`, `line`, `column`,
+// `include-chain` and `secret` keys.
+func (s SourceLocation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newEncodedSource(s))
+}
+
+// UnmarshalJSON implements the Unmarshaler interface as defined by json.
+// Both the object form written by MarshalJSON and the String form are
+// accepted.
+func (s *SourceLocation) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		source, err := ParseSourceLocation(str)
+		if err != nil {
+			return err
+		}
+		*s = source
+		return nil
+	}
+	es := encodedSource{}
+	if err := json.Unmarshal(b, &es); err != nil {
+		return err
 	}
-	return s.Name
+	*s = es.sourceLocation()
+	return nil
 }
 
 type SourceOption func(*SourceLocation) *SourceLocation
@@ -77,6 +232,36 @@ func WithLocation(location *FileCoordinate) SourceOption {
 	}
 }
 
+// WithIncludeChain sets the list of sources that included this source.
+func WithIncludeChain(chain []string) SourceOption {
+	return func(s *SourceLocation) *SourceLocation {
+		s.IncludeChain = strings.Join(chain, includeChainSeparator)
+		return s
+	}
+}
+
+// IncludeCycleError is returned when a source includes itself, directly or
+// indirectly.
+type IncludeCycleError struct {
+	Chain []string
+}
+
+func (e IncludeCycleError) Error() string {
+	return fmt.Sprintf("include cycle detected: %s", strings.Join(e.Chain, " -> "))
+}
+
+// CheckIncludeCycle will return an IncludeCycleError if name is already
+// present in the include chain.
+func CheckIncludeCycle(chain []string, name string) error {
+	for i, included := range chain {
+		if included == name {
+			cycle := append(append([]string{}, chain[i:]...), name)
+			return errors.WithStack(IncludeCycleError{Chain: cycle})
+		}
+	}
+	return nil
+}
+
 func NewSource(name string, opts ...SourceOption) SourceLocation {
 	l := SourceLocation{
 		Name: name,
@@ -114,7 +299,7 @@ func (o *Option[T]) GetSource() SourceLocation {
 }
 
 func (o *Option[T]) IsDefault() bool {
-	return o.Source.Name == defaultSource
+	return o.Source.Name == defaultSource || strings.HasPrefix(o.Source.Name, defaultSourcePrefix)
 }
 
 func (o *Option[T]) IsOverride() bool {
@@ -125,6 +310,13 @@ func (o Option[T]) GetValue() any {
 	return o.Value
 }
 
+// Get returns the option value, and records that the option was used when
+// usage tracking is enabled, see WithUsageTracking.
+func (o Option[T]) Get() T {
+	o.Source.usage.markRead()
+	return o.Value
+}
+
 // WriteAnswer implements the Settable interface as defined by the
 // survey prompting library:
 // https://github.com/AlecAivazis/survey/blob/v2.3.5/core/write.go#L15-L18
@@ -156,7 +348,7 @@ func (o *Option[T]) Set(s string) error {
 // https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
 func (o Option[T]) String() string {
 	if StringifyValue {
-		return fmt.Sprint(o.Value)
+		return formatScalar(o.Value)
 	}
 	return fmt.Sprintf("{Source:%s Defined:%t Value:%v}", o.Source, o.Defined, o.Value)
 }
@@ -191,7 +383,7 @@ func (o *Option[T]) SetValue(v any) error {
 // yaml library:
 // https://github.com/go-yaml/yaml/blob/v3.0.1/yaml.go#L36-L38
 func (o *Option[T]) UnmarshalYAML(node *yaml.Node) error {
-	if err := node.Decode(&o.Value); err != nil {
+	if err := decodeYAML(node, &o.Value); err != nil {
 		return walky.NewYAMLError(err, node)
 	}
 	var loc *FileCoordinate
@@ -220,15 +412,22 @@ func (o Option[T]) MarshalYAML() (any, error) {
 		if marshaler, ok := q.(yaml.Marshaler); ok {
 			return marshaler.MarshalYAML()
 		}
+		if s, ok := stringValue(q); ok {
+			return s, nil
+		}
 		return o.Value, nil
 	}
+	var value any = o.Value
+	if s, ok := stringValue(value); ok {
+		value = s
+	}
 	// need a copy of this struct without the MarshalYAML interface attached
 	return struct {
-		Value   T
+		Value   any
 		Source  string
 		Defined bool
 	}{
-		Value:   o.Value,
+		Value:   value,
 		Source:  o.Source.String(),
 		Defined: o.Defined,
 	}, nil
@@ -237,7 +436,21 @@ func (o Option[T]) MarshalYAML() (any, error) {
 // UnmarshalJSON implements the Unmarshaler interface as defined by json:
 // https://cs.opensource.google/go/go/+/refs/tags/go1.18.3:src/encoding/json/decode.go;l=118-120
 func (o *Option[T]) UnmarshalJSON(b []byte) error {
-	if err := json.Unmarshal(b, &o.Value); err != nil {
+	if needsStringConversion(&o.Value) {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			// numbers are converted from their literal form, like
+			// integer seconds for durations
+			var n json.Number
+			if json.Unmarshal(b, &n) != nil {
+				return err
+			}
+			s = n.String()
+		}
+		if err := convertString(s, &o.Value); err != nil {
+			return err
+		}
+	} else if err := json.Unmarshal(b, &o.Value); err != nil {
 		return err
 	}
 	o.Source = NewSource(jsonSource)
@@ -248,16 +461,20 @@ func (o *Option[T]) UnmarshalJSON(b []byte) error {
 // MarshalJSON implements the Marshaler interface as defined by json:
 // https://cs.opensource.google/go/go/+/refs/tags/go1.18.3:src/encoding/json/encode.go;l=225-227
 func (o Option[T]) MarshalJSON() ([]byte, error) {
+	var value any = o.Value
+	if s, ok := stringValue(value); ok {
+		value = s
+	}
 	if StringifyValue {
-		return json.Marshal(o.Value)
+		return json.Marshal(value)
 	}
 	// need a copy of this struct without the MarshalJSON interface attached
 	return json.Marshal(struct {
-		Value   T
+		Value   any
 		Source  string
 		Defined bool
 	}{
-		Value:   o.Value,
+		Value:   value,
 		Source:  o.Source.String(),
 		Defined: o.Defined,
 	})
@@ -277,22 +494,33 @@ func (o Option[T]) IsBoolFlag() bool {
 	return false
 }
 
+// Type implements part of the Value interface as defined by the pflag
+// command line option library:
+// https://github.com/spf13/pflag/blob/v1.0.5/flag.go#L187-L191
+func (o Option[T]) Type() string {
+	return valueTypeName(reflect.TypeOf(&o.Value).Elem())
+}
+
+// valueTypeName returns the name of typ used in command line help, like
+// "string", "int" or "duration".
+func valueTypeName(typ reflect.Type) string {
+	if typ == durationType {
+		return "duration"
+	}
+	if typ.Kind() == reflect.Interface {
+		return "value"
+	}
+	return typ.String()
+}
+
 type MapOption[T any] map[string]Option[T]
 
 // Set implements part of the Value interface as defined by the kingpin command
 // line option library:
 // https://github.com/alecthomas/kingpin/blob/v1.3.4/values.go#L26-L29
+// Use NewMapFlag for alternate delimiters or nested keys.
 func (o *MapOption[T]) Set(value string) error {
-	parts := stringMapRegex.Split(value, 2)
-	if len(parts) != 2 {
-		return errors.Errorf("expected KEY=VALUE got '%s'", value)
-	}
-	val := Option[T]{}
-	if err := val.Set(parts[1]); err != nil {
-		return err
-	}
-	(*o)[parts[0]] = val
-	return nil
+	return NewMapFlag(o).Set(value)
 }
 
 // IsCumulative implements part of the remainderArg interface as defined by the
@@ -309,10 +537,17 @@ func (o MapOption[T]) String() string {
 	return fmt.Sprint(map[string]Option[T](o))
 }
 
+// Type implements part of the Value interface as defined by the pflag
+// command line option library:
+// https://github.com/spf13/pflag/blob/v1.0.5/flag.go#L187-L191
+func (o MapOption[T]) Type() string {
+	return "map"
+}
+
 func (o MapOption[T]) Map() map[string]T {
 	tmp := map[string]T{}
 	for k, v := range o {
-		tmp[k] = v.Value
+		tmp[k] = v.Get()
 	}
 	return tmp
 }
@@ -380,6 +615,13 @@ func (o ListOption[T]) String() string {
 	return fmt.Sprint([]Option[T](o))
 }
 
+// Type implements part of the Value interface as defined by the pflag
+// command line option library:
+// https://github.com/spf13/pflag/blob/v1.0.5/flag.go#L187-L191
+func (o ListOption[T]) Type() string {
+	return "list"
+}
+
 func (o ListOption[T]) Append(values ...T) ListOption[T] {
 	results := o
 	for _, val := range values {
@@ -391,7 +633,7 @@ func (o ListOption[T]) Append(values ...T) ListOption[T] {
 func (o ListOption[T]) Slice() []T {
 	tmp := []T{}
 	for _, elem := range o {
-		tmp = append(tmp, elem.Value)
+		tmp = append(tmp, elem.Get())
 	}
 	return tmp
 }
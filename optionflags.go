@@ -0,0 +1,127 @@
+package figtree
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// FlagValue is a command line flag value.  It is implemented by the Option
+// types and is the same as the pflag.Value interface, so options can be
+// registered directly with pflag and cobra.
+type FlagValue interface {
+	Set(string) error
+	String() string
+	Type() string
+}
+
+// OptionFlag is a command line flag for an option field, see OptionFlags.
+type OptionFlag struct {
+	// Name is the key path of the option, like `server.port`.
+	Name string
+	// Usage is the `help` struct tag of the field.
+	Usage string
+	// NoOptDefVal is the value to use when the flag is given without a
+	// value, it is "true" for BoolOptions and "+1" for CountOptions.
+	NoOptDefVal string
+	// Value is the option, values set from the command line have the
+	// override source.
+	Value FlagValue
+	// Field is the struct field of the option.
+	Field reflect.StructField
+}
+
+// OptionFlags returns a command line flag for each option field in the
+// struct pointed to by options, in field order.  Nested structs are
+// walked, so `Server.Port` is named `server.port`, and embedded or inline
+// structs share the name of their parent.  Fields tagged
+// `figtree:",noflag"` or `yaml:"-"` are skipped.  For example with cobra:
+//
+//	flags, err := figtree.OptionFlags(&opts)
+//	...
+//	for _, flag := range flags {
+//		f := cmd.Flags().VarPF(flag.Value, flag.Name, "", flag.Usage)
+//		f.NoOptDefVal = flag.NoOptDefVal
+//	}
+//
+// The flags should be registered after the options are loaded, so the
+// loaded values are shown as the flag defaults, and the command line
+// values then replace the loaded values.
+func OptionFlags(options any) ([]OptionFlag, error) {
+	v := reflect.ValueOf(options)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.Errorf("options must be a pointer to a struct, got %T", options)
+	}
+	return optionFlags(v.Elem(), nil, nil), nil
+}
+
+// BindFlagSet registers a flag on fs for each option field in the struct
+// pointed to by options, named as described for OptionFlags.  The current
+// option values are used as the flag defaults, so options should be loaded
+// before the flags are bound, and the values given on the command line
+// replace the loaded values with the override source:
+//
+//	fig.LoadAllConfigs("config.yml", &opts)
+//	figtree.BindFlagSet(flag.CommandLine, &opts)
+//	flag.Parse()
+//
+// Bool and count options can be given without a value, like `-debug`.
+func BindFlagSet(fs *flag.FlagSet, options any) error {
+	flags, err := OptionFlags(options)
+	if err != nil {
+		return err
+	}
+	for _, f := range flags {
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	return nil
+}
+
+func optionFlags(v reflect.Value, path []string, flags []OptionFlag) []OptionFlag {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if sf.PkgPath != "" || sf.Tag.Get("yaml") == "-" || figtreeTagFlag(sf, "noflag") {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		fieldPath := append(append([]string{}, path...), yamlFieldName(sf))
+		if value, ok := field.Addr().Interface().(FlagValue); ok {
+			flags = append(flags, OptionFlag{
+				Name:        strings.Join(fieldPath, "."),
+				Usage:       sf.Tag.Get("help"),
+				NoOptDefVal: noOptDefVal(value),
+				Value:       value,
+				Field:       sf,
+			})
+			continue
+		}
+		if field.Kind() != reflect.Struct {
+			continue
+		}
+		if sf.Anonymous || inlineField(sf) {
+			flags = optionFlags(field, path, flags)
+		} else {
+			flags = optionFlags(field, fieldPath, flags)
+		}
+	}
+	return flags
+}
+
+// noOptDefVal returns the value for flags given without a value.
+func noOptDefVal(value FlagValue) string {
+	if _, ok := value.(*CountOption); ok {
+		return "+1"
+	}
+	if b, ok := value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return "true"
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package figtree
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionFlags(t *testing.T) {
+	type Server struct {
+		Host StringOption `yaml:"host" help:"server host name"`
+		Port IntOption    `yaml:"port"`
+	}
+	type Common struct {
+		Verbose CountOption `yaml:"verbose"`
+	}
+	type config struct {
+		Common  `yaml:",inline"`
+		Debug   BoolOption       `yaml:"debug" help:"enable debugging"`
+		Timeout DurationOption   `yaml:"timeout"`
+		Tags    ListStringOption `yaml:"tags"`
+		Labels  MapStringOption  `yaml:"labels"`
+		Server  Server           `yaml:"server"`
+		Backup  *Server          `yaml:"backup"`
+		Secret  StringOption     `yaml:"secret" figtree:",noflag"`
+		Skipped StringOption     `yaml:"-"`
+		Plain   string           `yaml:"plain"`
+		hidden  StringOption
+	}
+
+	src, err := SourceFromString("config.yml", "debug: true\nserver:\n  port: 80\n")
+	require.NoError(t, err)
+	opts := config{Backup: &Server{}}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	flags, err := OptionFlags(&opts)
+	require.NoError(t, err)
+	names := []string{}
+	for _, flag := range flags {
+		names = append(names, flag.Name)
+	}
+	assert.Equal(t, []string{"verbose", "debug", "timeout", "tags", "labels", "server.host", "server.port", "backup.host", "backup.port"}, names)
+
+	assert.Equal(t, "+1", flags[0].NoOptDefVal)
+	assert.Equal(t, "count", flags[0].Value.Type())
+	assert.Equal(t, "enable debugging", flags[1].Usage)
+	assert.Equal(t, "true", flags[1].NoOptDefVal)
+	assert.Equal(t, "bool", flags[1].Value.Type())
+	assert.Equal(t, "duration", flags[2].Value.Type())
+	assert.Equal(t, "", flags[2].NoOptDefVal)
+	assert.Equal(t, "list", flags[3].Value.Type())
+	assert.Equal(t, "map", flags[4].Value.Type())
+	assert.Equal(t, "server host name", flags[5].Usage)
+	assert.Equal(t, "int", flags[6].Value.Type())
+
+	// values set from the command line replace the loaded values
+	require.NoError(t, flags[0].Value.Set(flags[0].NoOptDefVal))
+	require.NoError(t, flags[1].Value.Set("false"))
+	require.NoError(t, flags[2].Value.Set("90s"))
+	require.NoError(t, flags[3].Value.Set("a"))
+	require.NoError(t, flags[4].Value.Set("k=v"))
+	require.NoError(t, flags[6].Value.Set("8080"))
+	require.NoError(t, flags[8].Value.Set("9090"))
+	assert.Equal(t, IntOption{NewSource("override"), true, 1}, opts.Verbose.Option)
+	assert.Equal(t, BoolOption{NewSource("override"), true, false}, opts.Debug)
+	assert.Equal(t, 90*time.Second, opts.Timeout.Value)
+	assert.Equal(t, []string{"a"}, opts.Tags.Slice())
+	assert.Equal(t, map[string]string{"k": "v"}, opts.Labels.Map())
+	assert.Equal(t, IntOption{NewSource("override"), true, 8080}, opts.Server.Port)
+	assert.Equal(t, 9090, opts.Backup.Port.Value)
+
+	_, err = OptionFlags(opts)
+	require.Error(t, err)
+}
+
+func TestBindFlagSet(t *testing.T) {
+	type Server struct {
+		Port IntOption `yaml:"port" help:"server port"`
+	}
+	type config struct {
+		Name    StringOption     `yaml:"name"`
+		Debug   BoolOption       `yaml:"debug"`
+		Verbose CountOption      `yaml:"verbose"`
+		Tags    ListStringOption `yaml:"tags"`
+		Server  Server           `yaml:"server"`
+	}
+
+	src, err := SourceFromString("config.yml", "name: loaded\nserver:\n  port: 80\n")
+	require.NoError(t, err)
+	opts := config{}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	StringifyValue = true
+	defer func() {
+		StringifyValue = false
+	}()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	require.NoError(t, BindFlagSet(fs, &opts))
+	assert.Equal(t, "loaded", fs.Lookup("name").DefValue)
+	assert.Equal(t, "80", fs.Lookup("server.port").DefValue)
+	assert.Equal(t, "server port", fs.Lookup("server.port").Usage)
+
+	err = fs.Parse([]string{"-debug", "-verbose", "-verbose", "-tags", "a", "-tags", "b", "-server.port", "8080", "arg"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"arg"}, fs.Args())
+	assert.Equal(t, StringOption{tSrc("config.yml", 1, 7), true, "loaded"}, opts.Name)
+	assert.Equal(t, BoolOption{NewSource("override"), true, true}, opts.Debug)
+	assert.Equal(t, 2, opts.Verbose.Value)
+	assert.Equal(t, []string{"a", "b"}, opts.Tags.Slice())
+	assert.Equal(t, IntOption{NewSource("override"), true, 8080}, opts.Server.Port)
+
+	require.Error(t, BindFlagSet(fs, opts))
+}
@@ -0,0 +1,35 @@
+package figtree
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllConfigsWithOverrides(t *testing.T) {
+	opts := TestOptions{}
+	require.NoError(t, os.Chdir("d1/d2/d3"))
+	t.Cleanup(func() {
+		_ = os.Chdir("../../..")
+	})
+
+	fig := newFigTreeFromEnv(WithOverrides(map[string]any{
+		"str1": "overridden",
+		"map1": map[string]any{
+			"key2": "override-key2",
+		},
+		"arr1": []string{"override-arr1"},
+	}))
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, StringOption{NewSource("override"), true, "overridden"}, opts.String1)
+	assert.True(t, opts.String1.IsOverride())
+	assert.Equal(t, StringOption{NewSource("override"), true, "override-key2"}, opts.Map1["key2"])
+	assert.Equal(t, StringOption{tSrc("figtree.yml", 8, 9), true, "d3map1val3"}, opts.Map1["key3"])
+	assert.Equal(t, StringOption{NewSource("override"), true, "override-arr1"}, opts.Array1[0])
+	assert.Equal(t, StringOption{tSrc("figtree.yml", 3, 5), true, "d3arr1val1"}, opts.Array1[1])
+	assert.Equal(t, IntOption{tSrc("figtree.yml", 10, 7), true, 333}, opts.Int1)
+}
@@ -285,3 +285,63 @@ my-struct:
 	require.NoError(t, err)
 	require.Equal(t, expected, got)
 }
+
+func TestLoadAllConfigSourcesWithMerger(t *testing.T) {
+	first, err := SourceFromString("first", "config: {overwrite: [arr1]}\narr1: [a]\n")
+	require.NoError(t, err)
+	second, err := SourceFromString("second", "arr1: [b]\nint1: 2\n")
+	require.NoError(t, err)
+
+	fig := newFigTreeFromEnv()
+	m := fig.NewMerger()
+
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSourcesWithMerger(m, []ConfigSource{first}, &opts))
+	// arr1 was overwritten by the first source, so the second source
+	// loaded with the same merger does not append to it
+	require.NoError(t, fig.LoadAllConfigSourcesWithMerger(m, []ConfigSource{second}, &opts))
+	assert.Equal(t, ListStringOption{{tSrc("first", 2, 8), true, "a"}}, opts.Array1)
+	assert.Equal(t, IntOption{tSrc("second", 2, 7), true, 2}, opts.Int1)
+
+	// after a reset the overwrite no longer applies
+	m.Reset()
+	require.NoError(t, fig.LoadAllConfigSourcesWithMerger(m, []ConfigSource{second}, &opts))
+	assert.Equal(t, ListStringOption{
+		{tSrc("first", 2, 8), true, "a"},
+		{tSrc("second", 1, 8), true, "b"},
+	}, opts.Array1)
+}
+
+func TestMergerAdvanceHook(t *testing.T) {
+	type advanced struct {
+		source      string
+		overwritten []string
+	}
+	got := []advanced{}
+	fig := newFigTreeFromEnv()
+	m := fig.NewMerger(WithAdvanceHook(func(source string, overwritten []string) {
+		got = append(got, advanced{source, overwritten})
+	}))
+
+	// feed documents one at a time, like a watcher would
+	docs := []string{
+		"config: {overwrite: [arr1]}\narr1: [a]\n",
+		"arr1: [b]\nstr1: second\n",
+	}
+	opts := TestOptions{}
+	for i, doc := range docs {
+		var node yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte(doc), &node))
+		source := []string{"first", "second"}[i]
+		require.NoError(t, fig.LoadConfigSourceWithMerger(m, &node, source, &opts))
+		m.Advance()
+	}
+
+	assert.Equal(t, ListStringOption{{tSrc("first", 2, 8), true, "a"}}, opts.Array1)
+	assert.Equal(t, StringOption{tSrc("second", 2, 7), true, "second"}, opts.String1)
+	assert.Equal(t, []string{"arr1"}, m.Ignored())
+	assert.Equal(t, []advanced{
+		{"first", []string{"arr1"}},
+		{"second", nil},
+	}, got)
+}
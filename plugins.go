@@ -0,0 +1,147 @@
+package figtree
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"emperror.dev/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// PluginConfig is the raw config for a single plugin.  The config is not
+// decoded while loading, instead the yaml from each source is retained so the
+// plugin can later decode it into its own struct with Decode.
+type PluginConfig struct {
+	// Sources are the raw plugin config from each source that defined it,
+	// in precedence order.
+	Sources []ConfigSource
+}
+
+// Decode will merge the plugin config from all sources into cfg, which
+// must be a pointer.  Options in cfg will have their sources set to the
+// locations within the original config files.
+func (p PluginConfig) Decode(cfg any, options ...MergeOption) error {
+	if reflect.ValueOf(cfg).Kind() != reflect.Pointer {
+		return errors.Errorf("plugin config must be decoded into a pointer, got %T", cfg)
+	}
+	m := NewMerger(options...)
+	for _, source := range p.Sources {
+		m.sourceFile = source.Filename
+		m.includeChain = source.IncludeChain
+		_, err := m.mergeStructs(reflect.ValueOf(cfg), newMergeSource(source.Config), false)
+		if err != nil {
+			return err
+		}
+		m.Advance()
+	}
+	return nil
+}
+
+// MarshalYAML implements the Marshaler interface used by the yaml library,
+// the merged plugin config is serialized.
+func (p PluginConfig) MarshalYAML() (any, error) {
+	cfg := map[string]any{}
+	if err := p.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// MarshalJSON implements the Marshaler interface as defined by json, the
+// merged plugin config is serialized.
+func (p PluginConfig) MarshalJSON() ([]byte, error) {
+	cfg := map[string]any{}
+	if err := p.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return json.Marshal(cfg)
+}
+
+// PluginsOption is a map of plugin name to the raw config for the plugin,
+// typically used for a `plugins` section in config files:
+//
+//	type Config struct {
+//		Plugins figtree.PluginsOption `yaml:"plugins"`
+//	}
+//
+// The config for each plugin is opaque to figtree, it is retained from every
+// source so that plugins can decode their config on demand with
+// DecodePlugin.
+type PluginsOption map[string]PluginConfig
+
+var pluginsType = reflect.TypeOf(PluginsOption{})
+
+// DecodePlugin will merge the config for the plugin name from all sources
+// into cfg, which must be a pointer.  If no config was found for the plugin
+// then cfg is not modified.
+func (p PluginsOption) DecodePlugin(name string, cfg any, options ...MergeOption) error {
+	return p[name].Decode(cfg, options...)
+}
+
+func (p PluginsOption) IsDefined() bool {
+	return len(p) > 0
+}
+
+// mergePlugins will append the raw plugin config from src to the dst
+// PluginsOption.
+func (m *Merger) mergePlugins(dst reflect.Value, src mergeSource) (bool, error) {
+	plugins := dst.Interface().(PluginsOption)
+	if plugins == nil {
+		plugins = PluginsOption{}
+	}
+	changed := false
+	appendSource := func(name string, source ConfigSource) {
+		plugin := plugins[name]
+		plugin.Sources = append(plugin.Sources, source)
+		plugins[name] = plugin
+		changed = true
+	}
+	if src.node != nil {
+		if src.node.Kind != yaml.MappingNode {
+			if src.isZero() {
+				return false, nil
+			}
+			return false, errors.Errorf("plugins must be a map at line %d, column %d", src.node.Line, src.node.Column)
+		}
+		for i := 0; i+1 < len(src.node.Content); i += 2 {
+			appendSource(src.node.Content[i].Value, ConfigSource{
+				Config:       src.node.Content[i+1],
+				Filename:     m.sourceFile,
+				IncludeChain: m.includeChain,
+			})
+		}
+	} else {
+		val, _, err := src.reflect()
+		if err != nil {
+			return false, err
+		}
+		if !val.IsValid() {
+			return false, nil
+		}
+		if srcPlugins, ok := val.Interface().(PluginsOption); ok {
+			for name, plugin := range srcPlugins {
+				for _, source := range plugin.Sources {
+					appendSource(name, source)
+				}
+			}
+		} else if val.Kind() == reflect.Map {
+			for _, key := range val.MapKeys() {
+				var node yaml.Node
+				if err := node.Encode(val.MapIndex(key).Interface()); err != nil {
+					return false, errors.Wrapf(err, "failed to encode plugin %v", key)
+				}
+				appendSource(key.String(), ConfigSource{
+					Config:       &node,
+					Filename:     m.sourceFile,
+					IncludeChain: m.includeChain,
+				})
+			}
+		} else {
+			return false, errors.Errorf("plugins must be a map, got %s", val.Type())
+		}
+	}
+	if changed {
+		dst.Set(reflect.ValueOf(plugins))
+	}
+	return changed, nil
+}
@@ -0,0 +1,74 @@
+package figtree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginsOption(t *testing.T) {
+	type config struct {
+		Name    StringOption  `yaml:"name"`
+		Plugins PluginsOption `yaml:"plugins"`
+	}
+	type pluginConfig struct {
+		Endpoint StringOption     `yaml:"endpoint"`
+		Retries  IntOption        `yaml:"retries"`
+		Tags     ListStringOption `yaml:"tags"`
+	}
+
+	top, err := SourceFromString("top.yml", `
+name: top
+plugins:
+  cache:
+    endpoint: top.example.com
+    retries: 3
+    tags: [top]
+  audit:
+    anything: [goes, here]
+`)
+	require.NoError(t, err)
+	bottom, err := SourceFromString("bottom.yml", `
+plugins:
+  cache:
+    endpoint: bottom.example.com
+    tags: [bottom]
+`)
+	require.NoError(t, err)
+
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{bottom, top}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{tSrc("top.yml", 2, 7), true, "top"}, opts.Name)
+	require.Len(t, opts.Plugins, 2)
+	assert.Len(t, opts.Plugins["cache"].Sources, 2)
+	assert.Len(t, opts.Plugins["audit"].Sources, 1)
+
+	cache := pluginConfig{}
+	err = opts.Plugins.DecodePlugin("cache", &cache)
+	require.NoError(t, err)
+	expected := pluginConfig{
+		Endpoint: StringOption{tSrc("bottom.yml", 4, 15), true, "bottom.example.com"},
+		Retries:  IntOption{tSrc("top.yml", 6, 14), true, 3},
+		Tags: ListStringOption{
+			StringOption{tSrc("bottom.yml", 5, 12), true, "bottom"},
+			StringOption{tSrc("top.yml", 7, 12), true, "top"},
+		},
+	}
+	assert.Equal(t, expected, cache)
+
+	// missing plugins leave the config untouched
+	missing := pluginConfig{Retries: NewIntOption(1)}
+	err = opts.Plugins.DecodePlugin("missing", &missing)
+	require.NoError(t, err)
+	assert.Equal(t, pluginConfig{Retries: NewIntOption(1)}, missing)
+
+	assert.Error(t, opts.Plugins.DecodePlugin("cache", cache))
+
+	got, err := json.Marshal(opts.Plugins["audit"])
+	require.NoError(t, err)
+	assert.Equal(t, `{"anything":["goes","here"]}`, string(got))
+}
@@ -0,0 +1,107 @@
+package figtree
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// WithHomeFirst makes the config in the home directory take precedence over
+// the configs found in the working directory and its parents, the inverse of
+// the default precedence.  This is intended for user preference style
+// options, like colors, where the user's choice should beat the project
+// defaults.  When groups are given only fields tagged with one of the groups,
+// like `figtree:",group=ui"`, use the home-first precedence, all other
+// fields use the default precedence.  Fields nested under a tagged field are
+// in the same group.  Overrides, flags and sources with a higher Priority
+// still take precedence over the home config.
+func WithHomeFirst(groups ...string) CreateOption {
+	return func(f *FigTree) {
+		f.homeFirst = true
+		f.homeFirstGroups = append([]string{}, groups...)
+	}
+}
+
+func (f *FigTree) WithHomeFirst(groups ...string) {
+	WithHomeFirst(groups...)(f)
+}
+
+// isHomeConfig returns true if absFile is a config file directly in the home
+// directory.
+func (f *FigTree) isHomeConfig(absFile string) bool {
+	return f.home != "" && filepath.Dir(absFile) == filepath.Clean(f.home)
+}
+
+// homeFirstKeys returns the key paths in options that use the home-first
+// precedence, or nil if all keys do.
+func (f *FigTree) homeFirstKeys(options any) [][]string {
+	if len(f.homeFirstGroups) == 0 {
+		return nil
+	}
+	keys := [][]string{}
+	collectGroupKeys(reflect.ValueOf(options), nil, f.homeFirstGroups, &keys)
+	return keys
+}
+
+// collectGroupKeys adds the key paths of the fields in v tagged with any of
+// the groups to keys.
+func collectGroupKeys(v reflect.Value, path []string, groups []string, keys *[][]string) {
+	v = uninterface(indirect(v))
+	if !v.IsValid() || toOption(v) != nil {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for name, field := range populateYAMLMaps(v) {
+			if field.StructField.PkgPath != "" || field.StructField.Anonymous {
+				continue
+			}
+			fieldPath := append(append([]string{}, path...), name)
+			if inGroups(field.StructField, groups) {
+				*keys = append(*keys, fieldPath)
+				continue
+			}
+			collectGroupKeys(field.Value, fieldPath, groups, keys)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			entryPath := append(append([]string{}, path...), fmt.Sprint(key.Interface()))
+			collectGroupKeys(v.MapIndex(key), entryPath, groups, keys)
+		}
+	}
+}
+
+// inGroups returns true if the field is tagged with any of the groups.
+func inGroups(sf reflect.StructField, groups []string) bool {
+	tag, ok := figtreeTagValue(sf, "group")
+	if !ok {
+		return false
+	}
+	for _, group := range strings.Split(tag, "|") {
+		for _, g := range groups {
+			if group == g {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadHomeFirst merges the keys from the home config source that use the
+// home-first precedence, all keys when keys is nil.
+func (f *FigTree) loadHomeFirst(m *Merger, source ConfigSource, keys [][]string, options any) error {
+	if keys != nil && len(keys) == 0 {
+		// no fields in the home-first groups
+		return nil
+	}
+	m.onlyKeys = keys
+	defer func() {
+		m.onlyKeys = nil
+	}()
+	if err := f.loadSource(m, source, options); err != nil {
+		return err
+	}
+	m.Advance()
+	return nil
+}
@@ -0,0 +1,85 @@
+package figtree
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllConfigsWithHomeFirst(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"home/figtree.yml":     "theme: dark\nui: {color: always, width: 80}\nname: home\ntags: [home]\n",
+		"repo/figtree.yml":     "theme: light\nui: {color: never}\nname: repo\ntags: [repo]\n",
+		"repo/sub/figtree.yml": "name: sub\n",
+	})
+	type UI struct {
+		Color StringOption `yaml:"color"`
+		Width IntOption    `yaml:"width"`
+	}
+	type Options struct {
+		Theme StringOption     `yaml:"theme" figtree:",group=cosmetic"`
+		UI    UI               `yaml:"ui" figtree:",group=cosmetic|layout"`
+		Name  StringOption     `yaml:"name"`
+		Tags  ListStringOption `yaml:"tags"`
+	}
+	home := filepath.Join(dir, "home")
+	abs := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	newFig := func(opts ...CreateOption) *FigTree {
+		return newFigTreeFromEnv(append([]CreateOption{
+			WithHome(home),
+			WithCwd(abs("repo/sub")),
+			WithSourceNames(SourceNameAbsolute),
+		}, opts...)...)
+	}
+
+	t.Run("default", func(t *testing.T) {
+		opts := Options{}
+		require.NoError(t, newFig().LoadAllConfigs("figtree.yml", &opts))
+		assert.Equal(t, "light", opts.Theme.Value)
+		assert.Equal(t, "never", opts.UI.Color.Value)
+		assert.Equal(t, "sub", opts.Name.Value)
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		opts := Options{}
+		require.NoError(t, newFig(WithHomeFirst("cosmetic")).LoadAllConfigs("figtree.yml", &opts))
+		assert.Equal(t, StringOption{tSrc(abs("home/figtree.yml"), 1, 8), true, "dark"}, opts.Theme)
+		assert.Equal(t, StringOption{tSrc(abs("home/figtree.yml"), 2, 13), true, "always"}, opts.UI.Color)
+		assert.Equal(t, IntOption{tSrc(abs("home/figtree.yml"), 2, 28), true, 80}, opts.UI.Width)
+		// ungrouped fields retain the default precedence
+		assert.Equal(t, StringOption{tSrc(abs("repo/sub/figtree.yml"), 1, 7), true, "sub"}, opts.Name)
+		assert.Equal(t, ListStringOption{
+			{tSrc(abs("repo/figtree.yml"), 4, 8), true, "repo"},
+			{tSrc(abs("home/figtree.yml"), 4, 8), true, "home"},
+		}, opts.Tags)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		opts := Options{}
+		require.NoError(t, newFig(WithHomeFirst()).LoadAllConfigs("figtree.yml", &opts))
+		assert.Equal(t, "dark", opts.Theme.Value)
+		assert.Equal(t, "always", opts.UI.Color.Value)
+		assert.Equal(t, "home", opts.Name.Value)
+		// the home config is only merged once
+		assert.Equal(t, ListStringOption{
+			{tSrc(abs("home/figtree.yml"), 4, 8), true, "home"},
+			{tSrc(abs("repo/figtree.yml"), 4, 8), true, "repo"},
+		}, opts.Tags)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		opts := Options{}
+		fig := newFig(
+			WithHomeFirst("layout"),
+			WithOverrides(map[string]any{"ui": map[string]any{"color": "auto"}}),
+		)
+		require.NoError(t, fig.LoadAllConfigs("figtree.yml", &opts))
+		assert.Equal(t, "light", opts.Theme.Value)
+		assert.Equal(t, "auto", opts.UI.Color.Value)
+		assert.Equal(t, 80, opts.UI.Width.Value)
+	})
+}
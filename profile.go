@@ -0,0 +1,48 @@
+package figtree
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WithProfile selects a config profile, like "prod", for LoadAllConfigs and
+// LoadFirstConfig.  For each config file found, like `figtree.yml`, the
+// profile file next to it, `figtree.prod.yml`, is also loaded and takes
+// precedence over the config file.  Documents may also have a section for
+// each profile:
+//
+//	region: us-west-2
+//	profiles:
+//	  prod:
+//	    replicas: 3
+//	  dev:
+//	    replicas: 1
+//
+// The section for the profile takes precedence over the rest of its
+// document, and over the WithCommand section.  The `profiles` key is not
+// merged into the options when a profile is selected.  Profile files are
+// only loaded next to config files that exist.
+func WithProfile(profile string) CreateOption {
+	return func(f *FigTree) {
+		f.profile = profile
+	}
+}
+
+func (f *FigTree) WithProfile(profile string) {
+	WithProfile(profile)(f)
+}
+
+// profileFileName returns the name of the profile file for file, like
+// `figtree.prod.yml` for `figtree.yml`.
+func profileFileName(file, profile string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "." + profile + ext
+}
+
+// profileSection returns the section of the document root for the
+// WithProfile profile, if any, and root without the profiles key.
+func (f *FigTree) profileSection(file string, root *yaml.Node) (*yaml.Node, *yaml.Node, error) {
+	return namedSection(file, root, "profiles", f.profile)
+}
@@ -0,0 +1,79 @@
+package figtree
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllConfigsWithProfile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml": `str1: root
+int1: 1
+profiles:
+  prod:
+    int1: 10
+  dev:
+    int1: 0
+commands:
+  build:
+    int1: 20
+    bool1: true
+`,
+		"figtree.prod.yml":     "float1: 1.5\n",
+		"app/figtree.yml":      "str1: app\nmap1:\n  k: app\n",
+		"app/figtree.prod.yml": "map1:\n  k: app-prod\n",
+	})
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "app")), WithProfile("prod"), WithCommand("build"))
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	expected := TestOptions{
+		String1: StringOption{tSrc("figtree.yml", 1, 7), true, "app"},
+		// the profile section takes precedence over the command section
+		Int1:   IntOption{tSrc("../figtree.yml", 5, 11), true, 10},
+		Float1: Float32Option{tSrc("../figtree.prod.yml", 1, 9), true, 1.5},
+		Bool1:  BoolOption{tSrc("../figtree.yml", 11, 12), true, true},
+		Map1: MapStringOption{
+			"k": StringOption{tSrc("figtree.prod.yml", 2, 6), true, "app-prod"},
+		},
+	}
+	assert.Exactly(t, expected, opts)
+
+	opts = TestOptions{}
+	fig = newFigTreeFromEnv(WithHome(dir), WithCwd(filepath.Join(dir, "app")), WithProfile("dev"), WithMergeOptions(WithStrictDecode()))
+	err = fig.LoadFirstConfig("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "app", opts.Map1["k"].Value)
+	assert.False(t, opts.Int1.Defined)
+
+	opts = TestOptions{}
+	fig.WithCwd(dir)
+	err = fig.LoadFirstConfig("figtree.yml", &opts)
+	require.Error(t, err)
+	// without WithCommand the commands key is unknown
+	assert.Contains(t, err.Error(), `unknown key "commands"`)
+
+	fig = newFigTreeFromEnv(WithHome(dir), WithCwd(dir))
+	fig.WithProfile("dev")
+	opts = TestOptions{}
+	require.NoError(t, fig.LoadAllConfigs("figtree.yml", &opts))
+	assert.Equal(t, IntOption{tSrc("figtree.yml", 7, 11), true, 0}, opts.Int1)
+	assert.False(t, opts.Float1.Defined)
+}
+
+func TestProfileSectionErrors(t *testing.T) {
+	fig := newFigTreeFromEnv(WithProfile("prod"))
+	src, err := SourceFromString("config.yml", "profiles: [prod]\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &TestOptions{})
+	assert.EqualError(t, err, "config.yml:1:11: profiles must be a map")
+
+	src, err = SourceFromString("config.yml", "profiles:\n  prod: [a]\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &TestOptions{})
+	assert.EqualError(t, err, "config.yml:2:9: profiles prod must be a map")
+}
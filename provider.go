@@ -0,0 +1,199 @@
+package figtree
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigProvider reads config sources that are not local files, like
+// configs served over HTTP.
+type ConfigProvider interface {
+	// ReadConfigs returns the config sources, in precedence order.
+	ReadConfigs(ctx context.Context) ([]ConfigSource, error)
+}
+
+// ConfigWatcher is a ConfigProvider that can tell Watch when its configs
+// change, so they are loaded again without waiting for a config file to
+// change.
+type ConfigWatcher interface {
+	ConfigProvider
+	// WatchConfigs calls notify whenever the configs returned by ReadConfigs
+	// may have changed, until ctx is done.
+	WatchConfigs(ctx context.Context, notify func()) error
+}
+
+// WithConfigProviders adds providers whose config sources are read by
+// ReadAllConfigs and LoadAllConfigs after the config files, so they have a
+// lower precedence than the config files unless the sources have a higher
+// Priority.  Sources from the providers are read in the order the
+// providers were added.
+func WithConfigProviders(providers ...ConfigProvider) CreateOption {
+	return func(f *FigTree) {
+		f.providers = append(f.providers, providers...)
+	}
+}
+
+func (f *FigTree) WithConfigProviders(providers ...ConfigProvider) {
+	WithConfigProviders(providers...)(f)
+}
+
+// readProviderConfigs returns the config sources from all the providers.
+func (f *FigTree) readProviderConfigs(ctx context.Context) ([]ConfigSource, error) {
+	configSources := []ConfigSource{}
+	for _, provider := range f.providers {
+		sources, err := provider.ReadConfigs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		configSources = append(configSources, sources...)
+	}
+	return configSources, nil
+}
+
+// HTTPProvider is a ConfigProvider for YAML configs fetched from http and
+// https URLs.  The sources are named with their URL, so option locations are
+// reported like `https://config.example.com/app.yml:3:7`.  Responses with
+// an ETag are cached, and later requests for the URL are conditional so
+// unchanged configs are not downloaded again.
+type HTTPProvider struct {
+	urls     []string
+	client   *http.Client
+	header   http.Header
+	timeout  time.Duration
+	priority int
+
+	mu    sync.Mutex
+	cache map[string]httpCacheEntry
+}
+
+type httpCacheEntry struct {
+	etag string
+	data []byte
+}
+
+// HTTPProviderOption configures an HTTPProvider.
+type HTTPProviderOption func(*HTTPProvider)
+
+// WithHTTPClient sets the http.Client used for requests, otherwise
+// http.DefaultClient is used.
+func WithHTTPClient(client *http.Client) HTTPProviderOption {
+	return func(p *HTTPProvider) {
+		p.client = client
+	}
+}
+
+// WithHTTPHeader adds a header sent with every request, for example an
+// Authorization header.
+func WithHTTPHeader(key, value string) HTTPProviderOption {
+	return func(p *HTTPProvider) {
+		p.header.Add(key, value)
+	}
+}
+
+// WithHTTPTimeout sets the time limit for each request, the default is 30s.
+// A timeout of 0 means requests are only limited by the context.
+func WithHTTPTimeout(timeout time.Duration) HTTPProviderOption {
+	return func(p *HTTPProvider) {
+		p.timeout = timeout
+	}
+}
+
+// WithHTTPPriority sets the Priority of the sources, see
+// ConfigSource.Priority.
+func WithHTTPPriority(priority int) HTTPProviderOption {
+	return func(p *HTTPProvider) {
+		p.priority = priority
+	}
+}
+
+// NewHTTPProvider returns an HTTPProvider for the configs at urls, in
+// precedence order.  All the configs are required, an error is returned if
+// any of them can not be fetched.
+func NewHTTPProvider(urls []string, options ...HTTPProviderOption) *HTTPProvider {
+	p := &HTTPProvider{
+		urls:    urls,
+		client:  http.DefaultClient,
+		header:  http.Header{},
+		timeout: 30 * time.Second,
+		cache:   map[string]httpCacheEntry{},
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// ReadConfigs implements ConfigProvider.
+func (p *HTTPProvider) ReadConfigs(ctx context.Context) ([]ConfigSource, error) {
+	configSources := []ConfigSource{}
+	for _, u := range p.urls {
+		data, err := p.fetch(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		var node yaml.Node
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		if err := decoder.Decode(&node); err != nil && !errors.Is(err, io.EOF) {
+			return nil, errors.Wrapf(err, "failed to parse %s", u)
+		}
+		configSources = append(configSources, ConfigSource{
+			Config:   &node,
+			Filename: u,
+			Priority: p.priority,
+			ReadOnly: true,
+		})
+	}
+	return configSources, nil
+}
+
+// fetch returns the body for u, or the cached body if the server reports
+// it has not changed.
+func (p *HTTPProvider) fetch(ctx context.Context, u string) ([]byte, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid config URL %s", u)
+	}
+	for key, values := range p.header {
+		req.Header[key] = append([]string{}, values...)
+	}
+	req.Header.Set("Accept", "application/yaml")
+	p.mu.Lock()
+	cached, ok := p.cache[u]
+	p.mu.Unlock()
+	if ok {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch %s", u)
+	}
+	defer resp.Body.Close()
+	if ok && resp.StatusCode == http.StatusNotModified {
+		return cached.data, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to fetch %s: %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", u)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		p.mu.Lock()
+		p.cache[u] = httpCacheEntry{etag: etag, data: data}
+		p.mu.Unlock()
+	}
+	return data, nil
+}
@@ -0,0 +1,83 @@
+package figtree
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	requests := 0
+	notModified := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests++
+		switch r.URL.Path {
+		case "/app.yml":
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = w.Write([]byte("str1: remote\nint1: 2\nmap1:\n  k: remote\n"))
+		case "/base.yml":
+			_, _ = w.Write([]byte("int1: 3\nbool1: true\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml": "str1: local\n",
+	})
+	provider := NewHTTPProvider(
+		[]string{srv.URL + "/app.yml", srv.URL + "/base.yml"},
+		WithHTTPHeader("Authorization", "Bearer secret"),
+	)
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(dir), WithConfigProviders(provider))
+
+	for i := 0; i < 2; i++ {
+		opts := TestOptions{}
+		err := fig.LoadAllConfigs("figtree.yml", &opts)
+		require.NoError(t, err)
+		expected := TestOptions{
+			String1: StringOption{tSrc("figtree.yml", 1, 7), true, "local"},
+			Int1:    IntOption{tSrc(srv.URL+"/app.yml", 2, 7), true, 2},
+			Bool1:   BoolOption{tSrc(srv.URL+"/base.yml", 2, 8), true, true},
+			Map1: MapStringOption{
+				"k": StringOption{tSrc(srv.URL+"/app.yml", 4, 6), true, "remote"},
+			},
+		}
+		assert.Exactly(t, expected, opts)
+	}
+	assert.Equal(t, 4, requests)
+	// the second request for app.yml was answered from the cache
+	assert.Equal(t, 1, notModified)
+
+	// remote sources with a higher priority take precedence over files
+	fig = newFigTreeFromEnv(WithHome(dir), WithCwd(dir), WithConfigProviders(
+		NewHTTPProvider([]string{srv.URL + "/app.yml"}, WithHTTPHeader("Authorization", "Bearer secret"), WithHTTPPriority(1)),
+	))
+	opts := TestOptions{}
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "remote", opts.String1.Value)
+	assert.Equal(t, srv.URL+"/app.yml:1:7", opts.String1.Source.String())
+
+	// failed requests are errors
+	_, err = NewHTTPProvider([]string{srv.URL + "/missing.yml"}, WithHTTPHeader("Authorization", "Bearer secret")).ReadConfigs(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to fetch "+srv.URL+"/missing.yml: 404 Not Found")
+	_, err = NewHTTPProvider([]string{srv.URL + "/app.yml"}).ReadConfigs(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401 Unauthorized")
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"testing"
 
+	"emperror.dev/errors"
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOptionInterface(t *testing.T) {
@@ -95,3 +97,75 @@ func TestBoolOptionYAML(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, BoolOption{Source: tSrc("yaml", 1, 7), Value: false, Defined: true}, tt.Bool)
 }
+
+type scalarOptions struct {
+	Complex64  Complex64Option  `json:"complex64,omitempty" yaml:"complex64,omitempty"`
+	Complex128 Complex128Option `json:"complex128,omitempty" yaml:"complex128,omitempty"`
+	Error      ErrorOption      `json:"error,omitempty" yaml:"error,omitempty"`
+	Uintptr    UintptrOption    `json:"uintptr,omitempty" yaml:"uintptr,omitempty"`
+}
+
+func TestScalarOptionsSet(t *testing.T) {
+	opts := scalarOptions{}
+	require.NoError(t, opts.Complex64.Set("1+2i"))
+	require.NoError(t, opts.Complex128.Set("3-4i"))
+	require.NoError(t, opts.Error.Set("boom"))
+	require.NoError(t, opts.Uintptr.Set("42"))
+
+	assert.Equal(t, Complex64Option{NewSource("override"), true, 1 + 2i}, opts.Complex64)
+	assert.Equal(t, Complex128Option{NewSource("override"), true, 3 - 4i}, opts.Complex128)
+	assert.Equal(t, ErrorOption{NewSource("override"), true, errors.NewPlain("boom")}, opts.Error)
+	assert.Equal(t, UintptrOption{NewSource("override"), true, 42}, opts.Uintptr)
+
+	assert.Error(t, opts.Complex128.Set("bogus"))
+}
+
+func TestScalarOptionsYAML(t *testing.T) {
+	config := `complex64: 1+2i
+complex128: 5
+error: boom
+uintptr: 42
+`
+	opts := scalarOptions{}
+	err := yaml.Unmarshal([]byte(config), &opts)
+	require.NoError(t, err)
+	expected := scalarOptions{
+		Complex64:  Complex64Option{tSrc("yaml", 1, 12), true, 1 + 2i},
+		Complex128: Complex128Option{tSrc("yaml", 2, 13), true, 5},
+		Error:      ErrorOption{tSrc("yaml", 3, 8), true, errors.NewPlain("boom")},
+		Uintptr:    UintptrOption{tSrc("yaml", 4, 10), true, 42},
+	}
+	assert.Equal(t, expected, opts)
+
+	// now merge via figtree to ensure the options behave the same
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(config), &node))
+	opts = scalarOptions{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadConfigSource(&node, "figtree.yml", &opts)
+	require.NoError(t, err)
+	expected = scalarOptions{
+		Complex64:  Complex64Option{tSrc("figtree.yml", 1, 12), true, 1 + 2i},
+		Complex128: Complex128Option{tSrc("figtree.yml", 2, 13), true, 5},
+		Error:      ErrorOption{tSrc("figtree.yml", 3, 8), true, errors.NewPlain("boom")},
+		Uintptr:    UintptrOption{tSrc("figtree.yml", 4, 10), true, 42},
+	}
+	assert.Equal(t, expected, opts)
+
+	StringifyValue = true
+	defer func() {
+		StringifyValue = false
+	}()
+	got, err := yaml.Marshal(&opts)
+	require.NoError(t, err)
+	assert.Equal(t, "complex64: (1+2i)\ncomplex128: (5+0i)\nerror: boom\nuintptr: 42\n", string(got))
+
+	got, err = json.Marshal(&opts)
+	require.NoError(t, err)
+	assert.Equal(t, `{"complex64":"(1+2i)","complex128":"(5+0i)","error":"boom","uintptr":42}`, string(got))
+
+	roundTrip := scalarOptions{}
+	require.NoError(t, json.Unmarshal(got, &roundTrip))
+	assert.Equal(t, Complex128Option{NewSource("json"), true, 5}, roundTrip.Complex128)
+	assert.Equal(t, ErrorOption{NewSource("json"), true, errors.NewPlain("boom")}, roundTrip.Error)
+}
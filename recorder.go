@@ -0,0 +1,65 @@
+package figtree
+
+// MergeChange is the kind of change in a MergeEvent.
+type MergeChange string
+
+const (
+	// MergeChangeSet is for key paths that were set for the first time.
+	MergeChangeSet MergeChange = "set"
+	// MergeChangeOverridden is for key paths where the value was
+	// replaced, for example with the overwrite config pragma.
+	MergeChangeOverridden MergeChange = "overridden"
+	// MergeChangeAppended is for lists where items were appended.
+	MergeChangeAppended MergeChange = "appended"
+	// MergeChangeShadowed is for key paths where the value was not used
+	// because a higher precedence source already set the key path.
+	MergeChangeShadowed MergeChange = "shadowed"
+)
+
+// MergeEvent is a change to the options merged from a source, see
+// WithMergeRecorder.
+type MergeEvent struct {
+	// Key is the dot separated path of the value, list items use the path
+	// of the list.
+	Key    string
+	Change MergeChange
+	// Source is the source of the value being merged.
+	Source SourceLocation
+	// Previous is the source of the value that was overridden, or that
+	// shadowed the value being merged.
+	Previous *SourceLocation
+}
+
+// MergeRecorder is called for each MergeEvent, see WithMergeRecorder.
+type MergeRecorder func(event MergeEvent)
+
+// WithMergeRecorder sets a recorder called as each document is merged with
+// the changes it made to the options, at most one event for each key path
+// in a document, so CLIs can explain the loaded values, like:
+//
+//	figtree.WithMergeOptions(figtree.WithMergeRecorder(func(e figtree.MergeEvent) {
+//		if e.Change == figtree.MergeChangeShadowed {
+//			fmt.Printf("%s from %s overridden by %s\n", e.Key, e.Source, e.Previous)
+//		}
+//	}))
+//
+// Documents are merged in precedence order, so the values from lower
+// precedence documents are shadowed rather than overridden.
+func WithMergeRecorder(recorder MergeRecorder) MergeOption {
+	return func(m *Merger) {
+		m.recorder = recorder
+	}
+}
+
+// recordChange calls the recorder with event, unless there already was an
+// event for the key path in the current document.
+func (m *Merger) recordChange(event MergeEvent) {
+	if m.recorder == nil || m.recorded[event.Key] {
+		return
+	}
+	if m.recorded == nil {
+		m.recorded = map[string]bool{}
+	}
+	m.recorded[event.Key] = true
+	m.recorder(event)
+}
@@ -0,0 +1,53 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMergeRecorder(t *testing.T) {
+	top, err := SourceFromString("top.yml", "str1: top\narr1: [a]\nmap1:\n  k1: top\n")
+	require.NoError(t, err)
+	base, err := SourceFromString("base.yml", "str1: base\narr1: [b, c]\nmap1:\n  k1: base\n  k2: base\nint1: 1\n")
+	require.NoError(t, err)
+
+	type event struct {
+		key      string
+		change   MergeChange
+		source   string
+		previous string
+	}
+	events := []event{}
+	fig := newFigTreeFromEnv(WithMergeOptions(WithMergeRecorder(func(e MergeEvent) {
+		ev := event{key: e.Key, change: e.Change, source: e.Source.String()}
+		if e.Previous != nil {
+			ev.previous = e.Previous.String()
+		}
+		events = append(events, ev)
+	})))
+	opts := TestOptions{}
+	err = fig.LoadAllConfigSources([]ConfigSource{top, base}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, []event{
+		{"str1", MergeChangeSet, "top.yml:1:7", ""},
+		{"arr1", MergeChangeSet, "top.yml:2:8", ""},
+		{"map1.k1", MergeChangeSet, "top.yml:4:7", ""},
+		{"str1", MergeChangeShadowed, "base.yml:1:7", "top.yml:1:7"},
+		{"arr1", MergeChangeAppended, "base.yml:2:8", ""},
+		{"map1.k1", MergeChangeShadowed, "base.yml:4:7", "top.yml:4:7"},
+		{"map1.k2", MergeChangeSet, "base.yml:5:7", ""},
+		{"int1", MergeChangeSet, "base.yml:6:7", ""},
+	}, events)
+
+	// the overwrite config pragma overrides higher precedence values
+	overwrite, err := SourceFromString("overwrite.yml", "config:\n  overwrite: [str1]\nstr1: overwrite\n")
+	require.NoError(t, err)
+	events = events[:0]
+	opts = TestOptions{}
+	err = fig.LoadAllConfigSources([]ConfigSource{top, overwrite}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, event{"str1", MergeChangeOverridden, "overwrite.yml:3:7", "top.yml:1:7"}, events[len(events)-1])
+	assert.Equal(t, "overwrite", opts.String1.Value)
+}
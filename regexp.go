@@ -0,0 +1,22 @@
+package figtree
+
+import (
+	"regexp"
+
+	"emperror.dev/errors"
+)
+
+// RegexpOption is an option for a regular expression, in config files the
+// expression uses the regexp syntax, like "^v[0-9]+$".  Expressions are
+// compiled when loaded, invalid expressions are reported with the location
+// of the value in the config file.
+type RegexpOption = Option[*regexp.Regexp]
+
+// ParseRegexp returns the compiled regular expression for s.
+func ParseRegexp(s string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, errors.Errorf("invalid regexp %q: %s", s, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,266 @@
+package figtree
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/coryb/walky"
+	"gopkg.in/yaml.v3"
+)
+
+// Status of a key in a LoadReport.
+const (
+	// ReportUsed is for keys where the value from the source is the
+	// effective value.
+	ReportUsed = "used"
+	// ReportShadowed is for keys where a higher precedence source
+	// provided the effective value.
+	ReportShadowed = "shadowed"
+	// ReportUntracked is for keys that are not loaded into an Option, so
+	// the source of the effective value is unknown.
+	ReportUntracked = "untracked"
+)
+
+// LoadReport describes what each config source contributed to the loaded
+// options, see NewLoadReport.
+type LoadReport struct {
+	Sources []ReportSource `json:"sources" yaml:"sources"`
+	// Findings are problems found in the sources, they are not set by
+	// NewLoadReport, for example use:
+	//
+	//	report.Findings = fig.Lint(sources)
+	Findings []LintFinding `json:"findings,omitempty" yaml:"findings,omitempty"`
+}
+
+// ReportSource is the report for a single config source.
+type ReportSource struct {
+	Name string `json:"name" yaml:"name"`
+	// Aliases are other paths to the same file, see ConfigSource.Aliases.
+	Aliases []string    `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Keys    []ReportKey `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// ReportKey is the report for a value in a config source.
+type ReportKey struct {
+	// Key is the dot separated path to the value, list items use the path
+	// of the list.
+	Key      string          `json:"key" yaml:"key"`
+	Value    string          `json:"value" yaml:"value"`
+	Location *FileCoordinate `json:"location,omitempty" yaml:"location,omitempty"`
+	Status   string          `json:"status" yaml:"status"`
+	// ShadowedBy is the source of the effective value when Status is
+	// ReportShadowed.
+	ShadowedBy *SourceLocation `json:"shadowed-by,omitempty" yaml:"shadowed-by,omitempty"`
+}
+
+// redactedValue is reported in place of secret values.
+const redactedValue = "<secret>"
+
+// NewLoadReport returns a LoadReport for the sources after they have been
+// loaded into options, for example with LoadAllConfigSources.  Each scalar
+// value in the sources is compared with the Source of the Option that was
+// loaded for the same key to determine if the value was used or shadowed by
+// another source.  Encrypted values, and values for keys with a secret
+// effective value, are redacted.
+func NewLoadReport(sources []ConfigSource, options any) *LoadReport {
+	effective := map[string][]SourceLocation{}
+	collectSources(reflect.ValueOf(options), nil, effective)
+
+	report := &LoadReport{}
+	for _, source := range sources {
+		rs := ReportSource{Name: source.Filename, Aliases: source.Aliases}
+		if source.Config != nil {
+			reportNode(&rs, source, walky.UnwrapDocument(source.Config), nil, effective)
+		}
+		report.Sources = append(report.Sources, rs)
+	}
+	return report
+}
+
+// collectSources records the sources of all the options in v by key path.
+func collectSources(v reflect.Value, path []string, effective map[string][]SourceLocation) {
+	v = uninterface(indirect(v))
+	if !v.IsValid() {
+		return
+	}
+	if option := toOption(v); option != nil {
+		if option.IsDefined() {
+			key := strings.Join(path, ".")
+			effective[key] = append(effective[key], option.GetSource())
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for name, field := range populateYAMLMaps(v) {
+			if field.StructField.PkgPath != "" || field.StructField.Anonymous {
+				continue
+			}
+			collectSources(field.Value, append(path, name), effective)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			collectSources(v.MapIndex(key), append(path, fmt.Sprint(key.Interface())), effective)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectSources(v.Index(i), path, effective)
+		}
+	}
+}
+
+func reportNode(rs *ReportSource, source ConfigSource, node *yaml.Node, path []string, effective map[string][]SourceLocation) {
+	if node == nil {
+		return
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		reportNode(rs, source, node.Alias, path, effective)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			reportNode(rs, source, node.Content[i+1], append(path, node.Content[i].Value), effective)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			reportNode(rs, source, item, path, effective)
+		}
+	case yaml.ScalarNode:
+		key := strings.Join(path, ".")
+		rk := ReportKey{
+			Key:      key,
+			Value:    node.Value,
+			Location: &FileCoordinate{Line: node.Line, Column: node.Column},
+			Status:   ReportUntracked,
+		}
+		if isEncrypted(node) {
+			rk.Value = redactedValue
+		}
+		sources, ok := effective[key]
+		if ok {
+			rk.Status = ReportShadowed
+			for _, s := range sources {
+				if s.Name == source.Filename && s.Location != nil && *s.Location == *rk.Location {
+					rk.Status = ReportUsed
+				}
+				if s.Secret {
+					rk.Value = redactedValue
+				}
+			}
+			if rk.Status == ReportShadowed {
+				rk.ShadowedBy = &sources[0]
+			}
+		}
+		rs.Keys = append(rs.Keys, rk)
+	}
+}
+
+// reportDirs groups the report sources by directory, in order of first
+// appearance.
+func reportDirs(report *LoadReport) ([]string, map[string][]ReportSource) {
+	dirs := []string{}
+	byDir := map[string][]ReportSource{}
+	for _, source := range report.Sources {
+		dir, _ := splitSourceName(source.Name)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], source)
+	}
+	return dirs, byDir
+}
+
+// splitSourceName returns the directory and file name of the source name,
+// for remote sources the directory is the URL up to the last path element.
+func splitSourceName(name string) (string, string) {
+	if isRemoteSource(name) {
+		host := strings.Index(name, "://") + len("://")
+		if ix := strings.LastIndex(name, "/"); ix >= host {
+			return name[:ix], name[ix+1:]
+		}
+		return name, ""
+	}
+	return filepath.Dir(name), filepath.Base(name)
+}
+
+// aliasesNote returns a note listing the aliases of the source, if any.
+func aliasesNote(source ReportSource) string {
+	if len(source.Aliases) == 0 {
+		return ""
+	}
+	return " (also found as " + strings.Join(source.Aliases, ", ") + ")"
+}
+
+func (k ReportKey) describe() string {
+	switch k.Status {
+	case ReportShadowed:
+		return "shadowed by " + k.ShadowedBy.String()
+	case ReportUntracked:
+		return "not tracked"
+	}
+	return "used"
+}
+
+var reportMarkers = map[string]string{
+	ReportUsed:      "+",
+	ReportShadowed:  "-",
+	ReportUntracked: "?",
+}
+
+// RenderSourceTree renders the report as a text tree of directories, files
+// and keys.  Keys are prefixed with `+` when the value is used, `-` when it
+// is shadowed by another source and `?` when it is not tracked:
+//
+//	/etc
+//	  figtree.yml
+//	    - name: "admin" (line 1, shadowed by /home/bob/figtree.yml:1:7)
+//	/home/bob
+//	  figtree.yml
+//	    + name: "bob" (line 1, used)
+func RenderSourceTree(report *LoadReport) string {
+	var buf strings.Builder
+	dirs, byDir := reportDirs(report)
+	for _, dir := range dirs {
+		fmt.Fprintln(&buf, dir)
+		for _, source := range byDir[dir] {
+			_, base := splitSourceName(source.Name)
+			fmt.Fprintf(&buf, "  %s%s\n", base, aliasesNote(source))
+			for _, key := range source.Keys {
+				fmt.Fprintf(&buf, "    %s %s: %s (line %d, %s)\n", reportMarkers[key.Status], key.Key, strconv.Quote(key.Value), key.Location.Line, key.describe())
+			}
+		}
+	}
+	return buf.String()
+}
+
+// RenderSourceTreeHTML renders the report as nested html lists, see
+// RenderSourceTree.  Each key is a list item with the `used`, `shadowed` or
+// `untracked` class so the tree can be styled.
+func RenderSourceTreeHTML(report *LoadReport) string {
+	var buf strings.Builder
+	dirs, byDir := reportDirs(report)
+	buf.WriteString("<ul class=\"figtree-sources\">\n")
+	for _, dir := range dirs {
+		fmt.Fprintf(&buf, "<li>%s\n<ul>\n", html.EscapeString(dir))
+		for _, source := range byDir[dir] {
+			_, base := splitSourceName(source.Name)
+			fmt.Fprintf(&buf, "<li>%s\n<ul>\n", html.EscapeString(base+aliasesNote(source)))
+			for _, key := range source.Keys {
+				fmt.Fprintf(&buf, "<li class=%q><code>%s</code>: <code>%s</code> <span>line %d, %s</span></li>\n",
+					key.Status,
+					html.EscapeString(key.Key),
+					html.EscapeString(key.Value),
+					key.Location.Line,
+					html.EscapeString(key.describe()),
+				)
+			}
+			buf.WriteString("</ul>\n</li>\n")
+		}
+		buf.WriteString("</ul>\n</li>\n")
+	}
+	buf.WriteString("</ul>\n")
+	return buf.String()
+}
@@ -0,0 +1,82 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSourceTree(t *testing.T) {
+	type config struct {
+		Name   StringOption     `yaml:"name"`
+		Tags   ListStringOption `yaml:"tags"`
+		Token  StringOption     `yaml:"token"`
+		Server struct {
+			Port IntOption `yaml:"port"`
+		} `yaml:"server"`
+		Plain string `yaml:"plain"`
+	}
+
+	home, err := SourceFromString("home/bob/figtree.yml", "name: bob\ntags: [a]\ntoken: !age terces\n")
+	require.NoError(t, err)
+	etc, err := SourceFromString("etc/figtree.yml", "name: <admin>\ntags: [b]\nserver:\n  port: 80\nplain: x\nextra: !age:str 4321\n")
+	require.NoError(t, err)
+	sources := []ConfigSource{home, etc}
+
+	opts := config{}
+	fig := newFigTreeFromEnv(WithAgeDecrypter(reverseDecrypter))
+	err = fig.LoadAllConfigSources(sources, &opts)
+	require.NoError(t, err)
+
+	report := NewLoadReport(sources, &opts)
+	require.Len(t, report.Sources, 2)
+	assert.Equal(t, ReportKey{
+		Key:        "name",
+		Value:      "<admin>",
+		Location:   &FileCoordinate{Line: 1, Column: 7},
+		Status:     ReportShadowed,
+		ShadowedBy: &opts.Name.Source,
+	}, report.Sources[1].Keys[0])
+
+	expected := `home/bob
+  figtree.yml
+    + name: "bob" (line 1, used)
+    + tags: "a" (line 2, used)
+    + token: "<secret>" (line 3, used)
+etc
+  figtree.yml
+    - name: "<admin>" (line 1, shadowed by home/bob/figtree.yml:1:7)
+    + tags: "b" (line 2, used)
+    + server.port: "80" (line 4, used)
+    ? plain: "x" (line 5, not tracked)
+    ? extra: "<secret>" (line 6, not tracked)
+`
+	assert.Equal(t, expected, RenderSourceTree(report))
+
+	html := RenderSourceTreeHTML(report)
+	assert.Contains(t, html, `<li class="shadowed"><code>name</code>: <code>&lt;admin&gt;</code> <span>line 1, shadowed by home/bob/figtree.yml:1:7</span></li>`)
+	assert.Contains(t, html, `<li class="used"><code>token</code>: <code>&lt;secret&gt;</code> <span>line 3, used</span></li>`)
+}
+
+func TestRenderSourceTreeRemote(t *testing.T) {
+	type config struct {
+		Name StringOption `yaml:"name"`
+	}
+	remote, err := SourceFromString("https://config.example.com/v1/app.yml", "name: remote\n")
+	require.NoError(t, err)
+	local, err := SourceFromString("app/figtree.yml", "name: local\n")
+	require.NoError(t, err)
+	sources := []ConfigSource{local, remote}
+	opts := config{}
+	require.NoError(t, newFigTreeFromEnv().LoadAllConfigSources(sources, &opts))
+
+	expected := `app
+  figtree.yml
+    + name: "local" (line 1, used)
+https://config.example.com/v1
+  app.yml
+    - name: "remote" (line 1, shadowed by app/figtree.yml:1:7)
+`
+	assert.Equal(t, expected, RenderSourceTree(NewLoadReport(sources, &opts)))
+}
@@ -0,0 +1,213 @@
+package figtree
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"emperror.dev/errors"
+)
+
+// RenderReportJSON renders the report as indented JSON.
+func RenderReportJSON(report *LoadReport) ([]byte, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(data, '\n'), nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// RenderReportJUnit renders the report as JUnit style XML for CI systems.
+// Each source is a test suite with a passing test case for each key, the
+// status of the key is in the test case output.  Each finding is a failed
+// test case in the suite for its source, named `<rule>: <key>`.
+func RenderReportJUnit(report *LoadReport) ([]byte, error) {
+	suites := junitTestSuites{Name: "figtree"}
+	index := map[string]int{}
+	suite := func(name string) *junitTestSuite {
+		i, ok := index[name]
+		if !ok {
+			i = len(suites.Suites)
+			index[name] = i
+			suites.Suites = append(suites.Suites, junitTestSuite{Name: name})
+		}
+		return &suites.Suites[i]
+	}
+	for _, source := range report.Sources {
+		s := suite(source.Name)
+		for _, key := range source.Keys {
+			s.Cases = append(s.Cases, junitTestCase{
+				Name:      key.Key,
+				ClassName: source.Name,
+				File:      source.Name,
+				Line:      key.Location.Line,
+				SystemOut: fmt.Sprintf("%s: %s (%s)", key.Key, strconv.Quote(key.Value), key.describe()),
+			})
+		}
+	}
+	for _, finding := range report.Findings {
+		s := suite(finding.Source.Name)
+		tc := junitTestCase{
+			Name:      finding.Rule + ": " + finding.Key,
+			ClassName: finding.Source.Name,
+			File:      finding.Source.Name,
+			Failure: &junitFailure{
+				Message: finding.Message,
+				Type:    finding.Rule,
+				Text:    finding.String(),
+			},
+		}
+		if finding.Source.Location != nil {
+			tc.Line = finding.Source.Location.Line
+		}
+		s.Cases = append(s.Cases, tc)
+		s.Failures++
+	}
+	for i := range suites.Suites {
+		suites.Suites[i].Tests = len(suites.Suites[i].Cases)
+		suites.Tests += suites.Suites[i].Tests
+		suites.Failures += suites.Suites[i].Failures
+	}
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(append([]byte(xml.Header), data...), '\n'), nil
+}
+
+// SARIF rule for shadowed keys in RenderReportSARIF.
+const sarifShadowedRule = "shadowed"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// RenderReportSARIF renders the report as a SARIF 2.1.0 log, so CI systems
+// can annotate the config files.  Findings are reported as warnings and
+// shadowed keys as notes, at the line and column of the value.
+func RenderReportSARIF(report *LoadReport) ([]byte, error) {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "figtree"}},
+		Results: []sarifResult{},
+	}
+	rules := map[string]bool{}
+	addResult := func(rule, level, message, file string, location *FileCoordinate) {
+		if !rules[rule] {
+			rules[rule] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: rule})
+		}
+		loc := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file)},
+		}
+		if location != nil && location.Line > 0 {
+			loc.Region = &sarifRegion{StartLine: location.Line, StartColumn: location.Column}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    rule,
+			Level:     level,
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{{PhysicalLocation: loc}},
+		})
+	}
+	for _, finding := range report.Findings {
+		addResult(finding.Rule, "warning", finding.Message, finding.Source.Name, finding.Source.Location)
+	}
+	for _, source := range report.Sources {
+		for _, key := range source.Keys {
+			if key.Status == ReportShadowed {
+				addResult(sarifShadowedRule, "note", key.Key+" is "+key.describe(), source.Name, key.Location)
+			}
+		}
+	}
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(data, '\n'), nil
+}
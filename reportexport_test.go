@@ -0,0 +1,83 @@
+package figtree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderReportFormats(t *testing.T) {
+	type config struct {
+		Name StringOption `yaml:"name"`
+		URL  StringOption `yaml:"url"`
+	}
+
+	home, err := SourceFromString("home/figtree.yml", "name: bob\n")
+	require.NoError(t, err)
+	etc, err := SourceFromString("etc/figtree.yml", "name: admin\nurl: ${URL}\n")
+	require.NoError(t, err)
+	sources := []ConfigSource{home, etc}
+
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources(sources, &opts)
+	require.NoError(t, err)
+	report := NewLoadReport(sources, &opts)
+	report.Findings = fig.Lint(sources)
+	require.Len(t, report.Findings, 1)
+
+	data, err := RenderReportJSON(report)
+	require.NoError(t, err)
+	decoded := LoadReport{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, report.Sources[1].Keys[1].Key, decoded.Sources[1].Keys[1].Key)
+	assert.Equal(t, LintUnexpandedTemplate, decoded.Findings[0].Rule)
+
+	data, err = RenderReportJUnit(report)
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="figtree" tests="4" failures="1">
+  <testsuite name="home/figtree.yml" tests="1" failures="0">
+    <testcase name="name" classname="home/figtree.yml" file="home/figtree.yml" line="1">
+      <system-out>name: &#34;bob&#34; (used)</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="etc/figtree.yml" tests="3" failures="1">
+    <testcase name="name" classname="etc/figtree.yml" file="etc/figtree.yml" line="1">
+      <system-out>name: &#34;admin&#34; (shadowed by home/figtree.yml:1:7)</system-out>
+    </testcase>
+    <testcase name="url" classname="etc/figtree.yml" file="etc/figtree.yml" line="2">
+      <system-out>url: &#34;${URL}&#34; (used)</system-out>
+    </testcase>
+    <testcase name="unexpanded-template: url" classname="etc/figtree.yml" file="etc/figtree.yml" line="2">
+      <failure message="value contains &#34;${URL}&#34; which will not be expanded" type="unexpanded-template">etc/figtree.yml:2:6: value contains &#34;${URL}&#34; which will not be expanded [unexpanded-template]</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, string(data))
+
+	data, err = RenderReportSARIF(report)
+	require.NoError(t, err)
+	sarif := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &sarif))
+	assert.Equal(t, "2.1.0", sarif["version"])
+	run := sarif["runs"].([]any)[0].(map[string]any)
+	results := run["results"].([]any)
+	require.Len(t, results, 2)
+	assert.Equal(t, map[string]any{
+		"ruleId":  "unexpanded-template",
+		"level":   "warning",
+		"message": map[string]any{"text": report.Findings[0].Message},
+		"locations": []any{map[string]any{
+			"physicalLocation": map[string]any{
+				"artifactLocation": map[string]any{"uri": "etc/figtree.yml"},
+				"region":           map[string]any{"startLine": 2.0, "startColumn": 6.0},
+			},
+		}},
+	}, results[0])
+	assert.Equal(t, "shadowed", results[1].(map[string]any)["ruleId"])
+	assert.Equal(t, "note", results[1].(map[string]any)["level"])
+	assert.Equal(t, "name is shadowed by home/figtree.yml:1:7", results[1].(map[string]any)["message"].(map[string]any)["text"])
+}
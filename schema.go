@@ -0,0 +1,226 @@
+package figtree
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Schema kinds used in SchemaType.Kind.
+const (
+	SchemaStruct = "struct"
+	SchemaMap    = "map"
+	SchemaList   = "list"
+	SchemaOption = "option"
+	SchemaAny    = "any"
+	SchemaString = "string"
+	SchemaBool   = "bool"
+	SchemaInt    = "int"
+	SchemaUint   = "uint"
+	SchemaFloat  = "float"
+)
+
+// SchemaType describes a type in the struct built by MakeMergeStruct.  It is
+// plain data, so it can be serialized with json or yaml and consumed by
+// other processes.
+type SchemaType struct {
+	// Kind is one of the Schema* kinds, or the Go kind name for other types.
+	Kind string `json:"kind" yaml:"kind"`
+	// Name is the Go type name for named types, like "figtree.Option[string]".
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Key is the key type for maps.
+	Key *SchemaType `json:"key,omitempty" yaml:"key,omitempty"`
+	// Elem is the element type for lists and maps, and the value type
+	// for options.
+	Elem *SchemaType `json:"elem,omitempty" yaml:"elem,omitempty"`
+	// Fields are the fields for structs, sorted by name.
+	Fields []SchemaField `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// SchemaField describes a field in a struct SchemaType.
+type SchemaField struct {
+	// Name is the Go field name.
+	Name string `json:"name" yaml:"name"`
+	// Key is the name of the field in config files.
+	Key string `json:"key" yaml:"key"`
+	// Tag is the Go struct tag for the field.
+	Tag  string     `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Type SchemaType `json:"type" yaml:"type"`
+	// Embedded is true for anonymous struct fields, the loader also reads
+	// their fields from the parent.
+	Embedded bool `json:"embedded,omitempty" yaml:"embedded,omitempty"`
+}
+
+// MakeMergeSchema returns a descriptor for the struct that MakeMergeStruct
+// would return for the same structs.  The schema is built without creating
+// any new reflect types.
+func MakeMergeSchema(structs ...any) SchemaType {
+	m := NewMerger()
+	return m.MakeMergeSchema(structs...)
+}
+
+func (m *Merger) MakeMergeSchema(structs ...any) SchemaType {
+	values := []reflect.Value{}
+	for _, data := range structs {
+		values = append(values, reflect.ValueOf(data))
+	}
+	b := schemaBuilder{m: m, visiting: map[reflect.Type]bool{}}
+	return b.mergeSchema(values...)
+}
+
+type schemaBuilder struct {
+	m *Merger
+	// visiting tracks the struct types being described so recursive types
+	// terminate.
+	visiting map[reflect.Type]bool
+}
+
+// mergeSchema follows the same rules as Merger.makeMergeStruct to describe
+// the merged struct for values.
+func (b *schemaBuilder) mergeSchema(values ...reflect.Value) SchemaType {
+	foundFields := map[string]SchemaField{}
+	addField := func(field SchemaField) {
+		if f, ok := foundFields[field.Name]; ok {
+			if f.Type.Kind == SchemaStruct && field.Type.Kind == SchemaStruct {
+				if f.Type.Name == "" || field.Type.Name == "" || f.Type.Name != field.Type.Name {
+					f.Type = mergeSchemaStructs(f.Type, field.Type)
+					foundFields[field.Name] = f
+				}
+			}
+			// field already found, skip
+			return
+		}
+		foundFields[field.Name] = field
+	}
+	for i := 0; i < len(values); i++ {
+		v := values[i]
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			continue
+		}
+		typ := v.Type()
+		if typ.Kind() == reflect.Struct {
+			for j := 0; j < typ.NumField(); j++ {
+				field := typ.Field(j)
+				if field.PkgPath != "" {
+					// unexported field, skip
+					continue
+				}
+				name := CanonicalFieldName(field)
+				if _, ok := foundFields[name]; !ok && inlineField(field) {
+					values = append(values[:i+1], append([]reflect.Value{v.Field(j)}, values[i+1:]...)...)
+					continue
+				}
+				addField(SchemaField{
+					Name:     name,
+					Key:      yamlFieldName(field),
+					Tag:      string(field.Tag),
+					Type:     b.typeSchema(field.Type),
+					Embedded: field.Anonymous,
+				})
+			}
+		} else if typ.Kind() == reflect.Map {
+			for _, key := range v.MapKeys() {
+				keyval := reflect.ValueOf(v.MapIndex(key).Interface())
+				var t SchemaType
+				_, preserve := b.m.preserveMap[key.String()]
+				switch {
+				case !keyval.IsValid():
+					t = SchemaType{Kind: SchemaAny}
+				case !preserve && keyval.Kind() == reflect.Ptr && keyval.Elem().Kind() == reflect.Map,
+					!preserve && keyval.Kind() == reflect.Map:
+					t = b.mergeSchema(keyval)
+				default:
+					t = b.typeSchema(keyval.Type())
+				}
+				addField(SchemaField{
+					Name: camelCase(key.String()),
+					Key:  key.String(),
+					Tag:  string(b.m.mapFieldTag(key.String())),
+					Type: t,
+				})
+			}
+		}
+	}
+	return SchemaType{Kind: SchemaStruct, Fields: sortedSchemaFields(foundFields)}
+}
+
+// mergeSchemaStructs combines the fields of two struct schemas, fields in a
+// take precedence.
+func mergeSchemaStructs(a, b SchemaType) SchemaType {
+	fields := map[string]SchemaField{}
+	for _, f := range b.Fields {
+		fields[f.Name] = f
+	}
+	for _, f := range a.Fields {
+		if existing, ok := fields[f.Name]; ok && f.Type.Kind == SchemaStruct && existing.Type.Kind == SchemaStruct {
+			f.Type = mergeSchemaStructs(f.Type, existing.Type)
+		}
+		fields[f.Name] = f
+	}
+	return SchemaType{Kind: SchemaStruct, Fields: sortedSchemaFields(fields)}
+}
+
+func sortedSchemaFields(found map[string]SchemaField) []SchemaField {
+	fields := make([]SchemaField, 0, len(found))
+	for _, f := range found {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+// typeSchema returns the schema for an existing Go type.
+func (b *schemaBuilder) typeSchema(t reflect.Type) SchemaType {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := SchemaType{Kind: t.Kind().String()}
+	if t.Name() != "" {
+		s.Name = t.String()
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		s.Kind = SchemaAny
+	case reflect.String:
+		s.Kind = SchemaString
+	case reflect.Bool:
+		s.Kind = SchemaBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s.Kind = SchemaInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.Kind = SchemaUint
+	case reflect.Float32, reflect.Float64:
+		s.Kind = SchemaFloat
+	case reflect.Slice, reflect.Array:
+		s.Kind = SchemaList
+		elem := b.typeSchema(t.Elem())
+		s.Elem = &elem
+	case reflect.Map:
+		s.Kind = SchemaMap
+		key, elem := b.typeSchema(t.Key()), b.typeSchema(t.Elem())
+		s.Key, s.Elem = &key, &elem
+	case reflect.Struct:
+		if isOptionType(t) {
+			s.Kind = SchemaOption
+			if field, ok := t.FieldByName("Value"); ok {
+				elem := b.typeSchema(field.Type)
+				s.Elem = &elem
+			}
+			return s
+		}
+		s.Kind = SchemaStruct
+		if b.visiting[t] {
+			// recursive type, the fields are described by the
+			// outer schema with the same name.
+			return s
+		}
+		b.visiting[t] = true
+		s.Fields = b.mergeSchema(reflect.New(t).Elem()).Fields
+		delete(b.visiting, t)
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package figtree
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaNode struct {
+	Name StringOption `yaml:"name"`
+	Next *schemaNode  `yaml:"next"`
+}
+
+func TestMakeMergeSchema(t *testing.T) {
+	type Inner struct {
+		Port IntOption `yaml:"port"`
+	}
+	type Base struct {
+		Name    StringOption     `yaml:"name"`
+		Tags    ListStringOption `yaml:"tags"`
+		Server  Inner            `yaml:"server"`
+		Labels  map[string]int   `yaml:"labels"`
+		private string
+	}
+	extra := map[string]any{
+		"server": map[string]any{"host": "localhost"},
+		"count":  1,
+		"nested": &schemaNode{},
+		"null":   nil,
+	}
+
+	schema := MakeMergeSchema(&Base{}, extra)
+
+	// the schema must describe the same fields as MakeMergeStruct
+	var checkFields func(s SchemaType, typ reflect.Type)
+	checkFields = func(s SchemaType, typ reflect.Type) {
+		require.Equal(t, SchemaStruct, s.Kind)
+		require.Len(t, s.Fields, typ.NumField())
+		for i, f := range s.Fields {
+			sf := typ.Field(i)
+			assert.Equal(t, sf.Name, f.Name)
+			assert.Equal(t, string(sf.Tag), f.Tag)
+			if f.Type.Kind == SchemaStruct && f.Type.Name == "" {
+				checkFields(f.Type, sf.Type)
+			}
+		}
+	}
+	checkFields(schema, reflect.TypeOf(MakeMergeStruct(&Base{}, extra)).Elem())
+
+	byName := func(s SchemaType, name string) SchemaField {
+		for _, f := range s.Fields {
+			if f.Name == name {
+				return f
+			}
+		}
+		t.Fatalf("field %s not found", name)
+		return SchemaField{}
+	}
+	name := byName(schema, "Name")
+	assert.Equal(t, "name", name.Key)
+	assert.Equal(t, SchemaOption, name.Type.Kind)
+	assert.Equal(t, &SchemaType{Kind: SchemaString, Name: "string"}, name.Type.Elem)
+
+	tags := byName(schema, "Tags")
+	assert.Equal(t, SchemaList, tags.Type.Kind)
+	assert.Equal(t, SchemaOption, tags.Type.Elem.Kind)
+
+	labels := byName(schema, "Labels")
+	assert.Equal(t, SchemaMap, labels.Type.Kind)
+	assert.Equal(t, SchemaString, labels.Type.Key.Kind)
+	assert.Equal(t, SchemaInt, labels.Type.Elem.Kind)
+
+	server := byName(schema, "Server")
+	assert.Equal(t, "host", byName(server.Type, "Host").Key)
+	assert.Equal(t, "port", byName(server.Type, "Port").Key)
+
+	assert.Equal(t, SchemaInt, byName(schema, "Count").Type.Kind)
+	assert.Equal(t, SchemaAny, byName(schema, "Null").Type.Kind)
+
+	// recursive types terminate
+	next := byName(byName(schema, "Nested").Type, "Next")
+	assert.Equal(t, SchemaStruct, next.Type.Kind)
+	assert.Equal(t, "figtree.schemaNode", next.Type.Name)
+	assert.Empty(t, next.Type.Fields)
+
+	// and the schema can be serialized
+	data, err := json.Marshal(byName(schema, "Count"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Count","key":"count","tag":"json:\"count\" yaml:\"count\"","type":{"kind":"int","name":"int"}}`, string(data))
+}
@@ -0,0 +1,101 @@
+package figtree
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// SetFields sets fields in the struct pointed to by options from string
+// values keyed by the dotted yaml key path of the field, like `server.port`,
+// for example to apply overrides received over an API.  Values are converted
+// as they are for command line flags: Options are Set, so they have the
+// override source, ListOptions have the value appended and MapOptions take
+// `key=value`.  Plain fields are converted to their type, like flag values,
+// plain slices have the value appended and plain maps take `key=value`.
+// Map entries can be set directly with the key in the path, like
+// `labels.env`, pointers to structs are allocated as needed.
+//
+// Every value is set even when some fail, the errors are returned together
+// in path order, each prefixed with its path.
+func SetFields(options any, values map[string]string) error {
+	v := reflect.ValueOf(options)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("options must be a pointer to a struct, got %T", options)
+	}
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var errs []error
+	for _, path := range paths {
+		if err := setFieldPath(v.Elem(), strings.Split(path, "."), values[path]); err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s", path))
+		}
+	}
+	return errors.Combine(errs...)
+}
+
+// SetField is like SetFields for a single field.
+func SetField(options any, path, value string) error {
+	return SetFields(options, map[string]string{path: value})
+}
+
+// setFieldPath sets the value at path within v to value.
+func setFieldPath(v reflect.Value, path []string, value string) error {
+	if v.Kind() == reflect.Pointer && (len(path) > 0 || !needsStringConversion(v.Addr().Interface())) {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if len(path) == 0 {
+		if _, ok := v.Addr().Interface().(interface{ Set(string) error }); ok {
+			return convertString(value, v.Addr().Interface())
+		}
+		switch {
+		case v.Kind() == reflect.Slice:
+			// append like ListOption
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setFieldPath(elem, nil, value); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, elem))
+			return nil
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			// key=value like MapOption
+			ix := strings.IndexAny(value, "=:")
+			if ix < 0 {
+				return errors.Errorf("expected KEY=VALUE got '%s'", value)
+			}
+			return setFieldPath(v, []string{value[:ix]}, value[ix+1:])
+		}
+		return convertString(value, v.Addr().Interface())
+	}
+	switch {
+	case v.Kind() == reflect.Struct && !isOptionType(v.Type()):
+		field, ok := populateYAMLMaps(v)[path[0]]
+		if !ok || field.StructField.PkgPath != "" {
+			return errors.Errorf("unknown field %q", path[0])
+		}
+		return setFieldPath(field.Value, path[1:], value)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		key := reflect.ValueOf(path[0]).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setFieldPath(elem, path[1:], value); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+	return errors.Errorf("cannot set %q in %s", path[0], v.Type())
+}
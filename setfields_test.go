@@ -0,0 +1,91 @@
+package figtree
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFields(t *testing.T) {
+	type server struct {
+		Port    IntOption      `yaml:"port"`
+		Timeout time.Duration  `yaml:"timeout"`
+		URL     *url.URL       `yaml:"url"`
+		Labels  map[string]int `yaml:"labels"`
+	}
+	type config struct {
+		TestOptions `yaml:",inline"`
+		Server      *server `yaml:"server"`
+		Name        string  `yaml:"name"`
+		Enabled     *bool   `yaml:"enabled"`
+	}
+
+	opts := config{}
+	err := SetFields(&opts, map[string]string{
+		"str1":            "value",
+		"arr1":            "a",
+		"map1":            "k1=v1",
+		"map1.k2":         "v2",
+		"bool1":           "true",
+		"server.port":     "8080",
+		"server.timeout":  "90s",
+		"server.url":      "https://example.com/api",
+		"server.labels.a": "1",
+		"name":            "app",
+		"enabled":         "false",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{OverrideSource, true, "value"}, opts.String1)
+	assert.Equal(t, []string{"a"}, opts.Array1.Slice())
+	assert.Equal(t, map[string]string{"k1": "v1", "k2": "v2"}, opts.Map1.Map())
+	assert.Equal(t, OverrideSource, opts.Map1["k2"].Source)
+	assert.True(t, opts.Bool1.Value)
+	require.NotNil(t, opts.Server)
+	assert.Equal(t, IntOption{OverrideSource, true, 8080}, opts.Server.Port)
+	assert.Equal(t, 90*time.Second, opts.Server.Timeout)
+	assert.Equal(t, "https://example.com/api", opts.Server.URL.String())
+	assert.Equal(t, map[string]int{"a": 1}, opts.Server.Labels)
+	assert.Equal(t, "app", opts.Name)
+	require.NotNil(t, opts.Enabled)
+	assert.False(t, *opts.Enabled)
+
+	// lists are appended to
+	require.NoError(t, SetField(&opts, "arr1", "b"))
+	assert.Equal(t, []string{"a", "b"}, opts.Array1.Slice())
+
+	// plain slices are appended to and plain maps take key=value
+	type plain struct {
+		Tags   []string       `yaml:"tags"`
+		Ports  []uint16       `yaml:"ports"`
+		Counts map[string]int `yaml:"counts"`
+	}
+	p := plain{}
+	require.NoError(t, SetFields(&p, map[string]string{"tags": "a", "ports": "80", "counts": "a=1"}))
+	require.NoError(t, SetFields(&p, map[string]string{"tags": "b", "counts": "b:2"}))
+	assert.Equal(t, plain{Tags: []string{"a", "b"}, Ports: []uint16{80}, Counts: map[string]int{"a": 1, "b": 2}}, p)
+	err = SetFields(&p, map[string]string{"ports": "x", "counts": "c"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `counts: expected KEY=VALUE got 'c'`)
+	assert.Contains(t, err.Error(), `ports: strconv.ParseUint: parsing "x": invalid syntax`)
+
+	// all the errors are returned with their paths, valid values are set
+	err = SetFields(&opts, map[string]string{
+		"int1":         "one",
+		"server.port":  "x",
+		"server.hosts": "y",
+		"str1.value":   "z",
+		"name":         "updated",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `int1: strconv.ParseInt: parsing "one": invalid syntax`)
+	assert.Contains(t, err.Error(), `server.hosts: unknown field "hosts"`)
+	assert.Contains(t, err.Error(), `server.port: strconv.ParseInt: parsing "x": invalid syntax`)
+	assert.Contains(t, err.Error(), `str1.value: cannot set "value" in figtree.Option[string]`)
+	assert.Equal(t, "updated", opts.Name)
+
+	err = SetFields(opts, nil)
+	assert.EqualError(t, err, "options must be a pointer to a struct, got figtree.config")
+}
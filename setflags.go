@@ -0,0 +1,210 @@
+package figtree
+
+import (
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// maxSetIndex limits list indexes used in ParseSetFlags so a typo cannot
+// allocate an enormous list.
+const maxSetIndex = 65536
+
+// ParseSetFlags parses helm style `--set` values into a map suitable for
+// WithOverrides.  Each value is a comma separated list of assignments where
+// keys are dotted paths that may include list indexes, and values may be
+// lists, for example:
+//
+//	a.b[0].c=1,list={x,y},name=value
+//
+// Values of `true` and `false` are parsed as bools, integers as int64 and
+// `null` as nil, everything else is a string.  A backslash escapes the next
+// character so keys and values may contain `.`, `,`, `=` etc.  Later
+// assignments to the same key replace earlier ones.
+func ParseSetFlags(values []string) (map[string]any, error) {
+	result := map[string]any{}
+	for _, value := range values {
+		p := &setParser{input: []rune(value)}
+		if err := p.parse(result); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %q", value)
+		}
+	}
+	return result, nil
+}
+
+type setParser struct {
+	input []rune
+	pos   int
+}
+
+type setPathElem struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// readUntil reads runes until one of stops is found, the stop rune is
+// consumed and returned, or 0 is returned at the end of the input.
+func (p *setParser) readUntil(stops string) (string, rune) {
+	buf := strings.Builder{}
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		p.pos++
+		if r == '\\' && p.pos < len(p.input) {
+			buf.WriteRune(p.input[p.pos])
+			p.pos++
+			continue
+		}
+		if strings.ContainsRune(stops, r) {
+			return buf.String(), r
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String(), 0
+}
+
+func (p *setParser) next() rune {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	r := p.input[p.pos]
+	p.pos++
+	return r
+}
+
+func (p *setParser) peek() rune {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *setParser) parse(result map[string]any) error {
+	for p.pos < len(p.input) {
+		path, err := p.parsePath()
+		if err != nil {
+			return err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		if _, err := setPathValue(result, path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *setParser) parsePath() ([]setPathElem, error) {
+	path := []setPathElem{}
+	for {
+		name, stop := p.readUntil(".[=,")
+		if name == "" {
+			return nil, errors.Errorf("empty key at position %d", p.pos)
+		}
+		path = append(path, setPathElem{key: name})
+		for stop == '[' {
+			index, end := p.readUntil("]")
+			if end != ']' {
+				return nil, errors.Errorf("missing ] for key %q", name)
+			}
+			ix, err := strconv.Atoi(index)
+			if err != nil || ix < 0 {
+				return nil, errors.Errorf("invalid list index %q for key %q", index, name)
+			}
+			if ix > maxSetIndex {
+				return nil, errors.Errorf("list index %d for key %q exceeds maximum of %d", ix, name, maxSetIndex)
+			}
+			path = append(path, setPathElem{index: ix, isIndex: true})
+			stop = p.next()
+		}
+		switch stop {
+		case '.':
+			continue
+		case '=':
+			return path, nil
+		default:
+			return nil, errors.Errorf("key %q has no value", name)
+		}
+	}
+}
+
+func (p *setParser) parseValue() (any, error) {
+	if p.peek() != '{' {
+		value, _ := p.readUntil(",")
+		return typedSetValue(value), nil
+	}
+	p.next()
+	list := []any{}
+	for {
+		item, stop := p.readUntil(",}")
+		if stop == 0 {
+			return nil, errors.New("missing } for list value")
+		}
+		if item != "" || stop == ',' {
+			list = append(list, typedSetValue(item))
+		}
+		if stop == '}' {
+			break
+		}
+	}
+	switch p.next() {
+	case ',', 0:
+		return list, nil
+	}
+	return nil, errors.Errorf("unexpected data after list value at position %d", p.pos)
+}
+
+func typedSetValue(value string) any {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	return value
+}
+
+// setPathValue will set value at path within container, creating maps and
+// lists as required.  The updated container is returned.
+func setPathValue(container any, path []setPathElem, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	elem := path[0]
+	if elem.isIndex {
+		list, ok := container.([]any)
+		if !ok && container != nil {
+			return nil, errors.Errorf("cannot set index %d on non-list value %T", elem.index, container)
+		}
+		for len(list) <= elem.index {
+			list = append(list, nil)
+		}
+		child, err := setPathValue(list[elem.index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		list[elem.index] = child
+		return list, nil
+	}
+	m, ok := container.(map[string]any)
+	if !ok {
+		if container != nil {
+			return nil, errors.Errorf("cannot set key %q on non-map value %T", elem.key, container)
+		}
+		m = map[string]any{}
+	}
+	child, err := setPathValue(m[elem.key], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	m[elem.key] = child
+	return m, nil
+}
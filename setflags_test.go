@@ -0,0 +1,97 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSetFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected map[string]any
+	}{{
+		name:     "scalars",
+		input:    []string{"name=value,count=3,enabled=true,off=false,empty=,none=null"},
+		expected: map[string]any{"name": "value", "count": int64(3), "enabled": true, "off": false, "empty": "", "none": nil},
+	}, {
+		name:  "nested",
+		input: []string{"a.b.c=1", "a.b.d=two"},
+		expected: map[string]any{
+			"a": map[string]any{"b": map[string]any{"c": int64(1), "d": "two"}},
+		},
+	}, {
+		name:  "lists",
+		input: []string{"list={x,y,3},empty={},a.b[1].c=1"},
+		expected: map[string]any{
+			"list":  []any{"x", "y", int64(3)},
+			"empty": []any{},
+			"a": map[string]any{
+				"b": []any{nil, map[string]any{"c": int64(1)}},
+			},
+		},
+	}, {
+		name:  "nested lists",
+		input: []string{"matrix[0][1]=x,matrix[1][0]=y"},
+		expected: map[string]any{
+			"matrix": []any{[]any{nil, "x"}, []any{"y"}},
+		},
+	}, {
+		name:     "escapes",
+		input:    []string{`a\.b=c\,d,url=http://x?y=z`},
+		expected: map[string]any{"a.b": "c,d", "url": "http://x?y=z"},
+	}, {
+		name:     "override",
+		input:    []string{"a=1", "a=2"},
+		expected: map[string]any{"a": int64(2)},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSetFlags(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestParseSetFlagsErrors(t *testing.T) {
+	for _, input := range []string{
+		"novalue",
+		"=value",
+		"a..b=c",
+		"a[x]=1",
+		"a[1=1",
+		"a[100000]=1",
+		"list={x,y",
+		"list={x}y",
+		"a=1,a.b=2",
+		"a[0]=1,a.b=2",
+	} {
+		_, err := ParseSetFlags([]string{input})
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseSetFlagsOverrides(t *testing.T) {
+	overrides, err := ParseSetFlags([]string{"str1=set,map1.key=value,arr1={a,b}"})
+	require.NoError(t, err)
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithOverrides(overrides))
+	err = fig.LoadAllConfigSources(nil, &opts)
+	require.NoError(t, err)
+
+	expected := TestOptions{
+		String1: StringOption{NewSource("override"), true, "set"},
+		Map1: MapStringOption{
+			"key": StringOption{NewSource("override"), true, "value"},
+		},
+		Array1: ListStringOption{
+			StringOption{NewSource("override"), true, "a"},
+			StringOption{NewSource("override"), true, "b"},
+		},
+	}
+	assert.Equal(t, expected, opts)
+}
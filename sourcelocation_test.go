@@ -0,0 +1,88 @@
+package figtree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestParseSourceLocation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected SourceLocation
+	}{
+		{"file.yml:3:4", tSrc("file.yml", 3, 4)},
+		{"override", NewSource("override")},
+		{"flag:str1", NewSource("flag:str1")},
+		{"dir/file:name.yml:10:2", tSrc("dir/file:name.yml", 10, 2)},
+		{"file.yml:x:4", NewSource("file.yml:x:4")},
+		{
+			"top.yml -> mid.yml -> file.yml:3:4",
+			NewSource("file.yml",
+				WithLocation(&FileCoordinate{Line: 3, Column: 4}),
+				WithIncludeChain([]string{"top.yml", "mid.yml"}),
+			),
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseSourceLocation(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, got, tt.input)
+		assert.Equal(t, tt.input, got.String())
+	}
+	included, err := ParseSourceLocation("top.yml -> mid.yml -> file.yml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"top.yml", "mid.yml"}, included.Includes())
+	assert.Nil(t, NewSource("file.yml").Includes())
+
+	_, err = ParseSourceLocation("")
+	assert.Error(t, err)
+	_, err = ParseSourceLocation(":3:4")
+	assert.Error(t, err)
+}
+
+func TestSourceLocationMarshal(t *testing.T) {
+	source := NewSource("file.yml",
+		WithLocation(&FileCoordinate{Line: 3, Column: 4}),
+		WithIncludeChain([]string{"top.yml"}),
+	)
+
+	got, err := json.Marshal(source)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"file.yml","line":3,"column":4,"include-chain":["top.yml"]}`, string(got))
+	roundTrip := SourceLocation{}
+	require.NoError(t, json.Unmarshal(got, &roundTrip))
+	assert.Equal(t, source, roundTrip)
+
+	got, err = yaml.Marshal(source)
+	require.NoError(t, err)
+	assert.Equal(t, "name: file.yml\nline: 3\ncolumn: 4\ninclude-chain:\n    - top.yml\n", string(got))
+	roundTrip = SourceLocation{}
+	require.NoError(t, yaml.Unmarshal(got, &roundTrip))
+	assert.Equal(t, source, roundTrip)
+
+	// the String form is also accepted
+	roundTrip = SourceLocation{}
+	require.NoError(t, json.Unmarshal([]byte(`"top.yml -> file.yml:3:4"`), &roundTrip))
+	assert.Equal(t, source, roundTrip)
+	roundTrip = SourceLocation{}
+	require.NoError(t, yaml.Unmarshal([]byte(`top.yml -> file.yml:3:4`), &roundTrip))
+	assert.Equal(t, source, roundTrip)
+
+	got, err = json.Marshal(NewSource("override"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"override"}`, string(got))
+}
+
+func TestSourceLocationComparable(t *testing.T) {
+	chain := WithIncludeChain([]string{"top.yml"})
+	a := StringOption{NewSource("file.yml", chain), true, "value"}
+	b := StringOption{NewSource("file.yml", chain), true, "value"}
+	assert.True(t, a == b)
+	seen := map[SourceLocation]bool{a.Source: true}
+	assert.True(t, seen[b.Source])
+	assert.False(t, seen[NewSource("file.yml")])
+}
@@ -0,0 +1,207 @@
+package figtree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestAllowedSources(t *testing.T) {
+	type options struct {
+		Name   StringOption `yaml:"name"`
+		Secret StringOption `yaml:"secret" figtree:",sources=env|override"`
+	}
+
+	var config yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("name: name\n"), &config))
+
+	opts := options{
+		Secret: NewStringOption("default-is-allowed"),
+	}
+	fig := newFigTreeFromEnv(WithOverrides(map[string]any{"secret": "shh"}))
+	err := fig.LoadAllConfigSources([]ConfigSource{{Config: &config, Filename: "figtree.yml"}}, &opts)
+	require.NoError(t, err)
+	expected := options{
+		Name:   StringOption{tSrc("figtree.yml", 1, 7), true, "name"},
+		Secret: StringOption{NewSource("override"), true, "shh"},
+	}
+	assert.Equal(t, expected, opts)
+
+	require.NoError(t, yaml.Unmarshal([]byte("name: name\nsecret: checked-in\n"), &config))
+	opts = options{}
+	fig = newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{{Config: &config, Filename: "figtree.yml"}}, &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "figtree.yml:2:9: secret cannot be set from file source, allowed sources: env, override")
+
+	// sources are also enforced for Options merged from other structs
+	src := options{
+		Secret: StringOption{tSrc("other.yml", 1, 2), true, "value"},
+	}
+	err = Merge(&opts, &src)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "other.yml:1:2: secret cannot be set from file source")
+
+	// sources read from URLs are remote
+	opts = options{}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{{Config: &config, Filename: "https://config.example.com/app.yml"}}, &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "https://config.example.com/app.yml:2:9: secret cannot be set from remote source")
+	type remoteOptions struct {
+		Secret StringOption `yaml:"secret" figtree:",sources=remote"`
+	}
+	remote := remoteOptions{}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{{Config: &config, Filename: "https://config.example.com/app.yml"}}, &remote)
+	require.NoError(t, err)
+	assert.Equal(t, "checked-in", remote.Secret.Value)
+	assert.Equal(t, "file", sourceKind(`C://figtree.yml`))
+}
+
+func TestConfigSourceMetadata(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"etc/figtree.yml":  "str1: admin\nint1: 1\n",
+		"home/figtree.yml": "str1: user\nint1: 2\nbool1: true\n",
+	})
+
+	sources := []ConfigSource{}
+	fig := newFigTreeFromEnv(WithSourceNames(SourceNameAbsolute), WithSourceMetadata(func(file string, cs *ConfigSource) {
+		if filepath.Dir(file) == filepath.Join(dir, "etc") {
+			cs.Labels = map[string]string{"managed-by": "admin"}
+			cs.Priority = 10
+			cs.ReadOnly = true
+		}
+	}))
+	for _, file := range []string{"home/figtree.yml", "etc/figtree.yml"} {
+		cs, err := fig.ReadFile(filepath.Join(dir, file))
+		require.NoError(t, err)
+		sources = append(sources, *cs)
+	}
+	assert.Nil(t, sources[0].Labels)
+	assert.False(t, sources[0].ReadOnly)
+	assert.Equal(t, map[string]string{"managed-by": "admin"}, sources[1].Labels)
+	assert.True(t, sources[1].ReadOnly)
+
+	// the higher priority admin source takes precedence even though it
+	// was loaded last
+	opts := TestOptions{}
+	err := fig.LoadAllConfigSources(sources, &opts)
+	require.NoError(t, err)
+	etc := filepath.Join(dir, "etc/figtree.yml")
+	home := filepath.Join(dir, "home/figtree.yml")
+	assert.Equal(t, StringOption{tSrc(etc, 1, 7), true, "admin"}, opts.String1)
+	assert.Equal(t, IntOption{tSrc(etc, 2, 7), true, 1}, opts.Int1)
+	assert.Equal(t, BoolOption{tSrc(home, 3, 8), true, true}, opts.Bool1)
+
+	// the caller's sources are not reordered
+	assert.Equal(t, home, sources[0].Filename)
+}
+
+func TestConfigSourceVersion(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml": "str1: a\n",
+	})
+	file := filepath.Join(dir, "figtree.yml")
+	fig := newFigTreeFromEnv(WithCwd(dir))
+	cs, err := fig.ReadFile("figtree.yml")
+	require.NoError(t, err)
+	require.NotNil(t, cs.Version)
+	assert.Equal(t, file, cs.Version.Path)
+	assert.Equal(t, int64(8), cs.Version.Size)
+	assert.Equal(t, "7d8443f8f24c0711a92bf151b67c3d78d6ff8bd55cf00d328690a52e8adc8abb", cs.Version.SHA256)
+	modified, err := cs.Version.Modified()
+	require.NoError(t, err)
+	assert.False(t, modified)
+
+	require.NoError(t, os.WriteFile(file, []byte("str1: bb\n"), 0o644))
+	modified, err = cs.Version.Modified()
+	require.NoError(t, err)
+	assert.True(t, modified)
+
+	require.NoError(t, os.Remove(file))
+	modified, err = cs.Version.Modified()
+	require.NoError(t, err)
+	assert.True(t, modified)
+
+	// sources not read from files have no version
+	cs2, err := SourceFromString("test", "str1: a\n")
+	require.NoError(t, err)
+	assert.Nil(t, cs2.Version)
+
+	// files read with WithFS are checked in that file system
+	fsys := fstest.MapFS{"app/figtree.yml": {Data: []byte("str1: a\n")}}
+	fig = newFigTreeFromEnv(WithFS(fsys), WithCwd("/app"))
+	cs, err = fig.ReadFile("figtree.yml")
+	require.NoError(t, err)
+	modified, err = cs.Version.Modified()
+	require.NoError(t, err)
+	assert.False(t, modified)
+
+	fsys["app/figtree.yml"] = &fstest.MapFile{Data: []byte("str1: bb\n")}
+	modified, err = cs.Version.Modified()
+	require.NoError(t, err)
+	assert.True(t, modified)
+}
+
+func TestNewValueSource(t *testing.T) {
+	type row struct {
+		Name    string         `yaml:"str1"`
+		Count   int            `yaml:"int1"`
+		Labels  map[string]any `yaml:"map1"`
+		Timeout time.Duration  `yaml:"timeout"`
+	}
+	type config struct {
+		TestOptions `yaml:",inline"`
+		Timeout     DurationOption `yaml:"timeout"`
+	}
+
+	api, err := NewValueSource(map[string]any{
+		"str1": "api",
+		"arr1": []string{"a", "b"},
+	}, NewSource("https://api.example.com/config"))
+	require.NoError(t, err)
+	db, err := NewValueSource(row{
+		Name:    "db",
+		Count:   3,
+		Labels:  map[string]any{"env": "prod"},
+		Timeout: 90 * time.Second,
+	}, NewSource("settings", WithLocation(&FileCoordinate{Line: 7}), WithIncludeChain([]string{"db"})))
+	require.NoError(t, err)
+
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{api, db}, &opts)
+	require.NoError(t, err)
+
+	apiSrc := NewSource("https://api.example.com/config")
+	dbSrc := NewSource("settings", WithLocation(&FileCoordinate{Line: 7}), WithIncludeChain([]string{"db"}))
+	assert.Equal(t, StringOption{apiSrc, true, "api"}, opts.String1)
+	assert.Equal(t, ListStringOption{{apiSrc, true, "a"}, {apiSrc, true, "b"}}, opts.Array1)
+	assert.Equal(t, IntOption{dbSrc, true, 3}, opts.Int1)
+	assert.Equal(t, MapStringOption{"env": {dbSrc, true, "prod"}}, opts.Map1)
+	assert.Equal(t, DurationOption{dbSrc, true, 90 * time.Second}, opts.Timeout)
+	assert.Equal(t, "db -> settings:7:0", opts.Int1.Source.String())
+
+	// Options are loaded by value with the new source
+	src, err := NewValueSource(&TestOptions{String1: StringOption{tSrc("old.yml", 1, 1), true, "value"}}, NewSource("cache"))
+	require.NoError(t, err)
+	got := TestOptions{}
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &got)
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{NewSource("cache"), true, "value"}, got.String1)
+
+	// undefined Options do not hide values from other sources
+	file, err := SourceFromString("config.yml", "int1: 9000\nbool1: true\n")
+	require.NoError(t, err)
+	got = TestOptions{}
+	err = fig.LoadAllConfigSources([]ConfigSource{src, file}, &got)
+	require.NoError(t, err)
+	assert.Equal(t, StringOption{NewSource("cache"), true, "value"}, got.String1)
+	assert.Equal(t, IntOption{tSrc("config.yml", 1, 7), true, 9000}, got.Int1)
+	assert.Equal(t, BoolOption{tSrc("config.yml", 2, 8), true, true}, got.Bool1)
+}
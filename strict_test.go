@@ -0,0 +1,66 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWithStrictDecode(t *testing.T) {
+	type server struct {
+		Host StringOption `yaml:"host"`
+		Port IntOption    `yaml:"port"`
+	}
+	type config struct {
+		Name   StringOption    `yaml:"name"`
+		Server server          `yaml:"server"`
+		Labels MapStringOption `yaml:"labels"`
+		Extra  any             `yaml:"extra"`
+	}
+
+	data := `config:
+  stop: true
+name: app
+server:
+  host: localhost
+  port: 8080
+labels:
+  anything: goes
+extra:
+  nested: [1, 2]
+`
+	src, err := SourceFromString("config.yml", data)
+	require.NoError(t, err)
+	fig := newFigTreeFromEnv(WithMergeOptions(WithStrictDecode()))
+	opts := config{}
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "app", opts.Name.Value)
+	assert.Equal(t, 8080, opts.Server.Port.Value)
+	assert.Equal(t, map[string]string{"anything": "goes"}, opts.Labels.Map())
+
+	for _, tt := range []struct {
+		data     string
+		expected string
+	}{{
+		data:     "stirng1: value\n",
+		expected: `config.yml:1:1 at "stirng1": unknown key "stirng1"`,
+	}, {
+		data:     "name: app\nserver:\n  hots: localhost\n",
+		expected: `config.yml:3:3 at "hots": unknown key "server.hots"`,
+	}, {
+		data:     "server:\n  config: value\n",
+		expected: `config.yml:2:3 at "config": unknown key "server.config"`,
+	}} {
+		src, err := SourceFromString("config.yml", tt.data)
+		require.NoError(t, err)
+		err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tt.expected)
+
+		// unknown keys are ignored by default
+		err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, &config{})
+		require.NoError(t, err)
+	}
+}
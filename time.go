@@ -0,0 +1,53 @@
+package figtree
+
+import (
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// TimeOption is an option for a time.Time, in config files, env vars and
+// flags the time is RFC3339, like "2024-12-01T09:30:00Z", or one of the
+// layouts added with RegisterTimeLayouts.
+type TimeOption = Option[time.Time]
+
+var timeLayouts = struct {
+	sync.RWMutex
+	layouts []string
+}{layouts: []string{time.RFC3339Nano}}
+
+// RegisterTimeLayouts adds time.Parse layouts, like "2006-01-02", that are
+// accepted for time values after RFC3339.  Layouts are tried in the order
+// they are registered, times without a zone are UTC.  Layouts are usually
+// registered from an init function since they apply to all time values
+// loaded afterwards.
+func RegisterTimeLayouts(layouts ...string) {
+	timeLayouts.Lock()
+	defer timeLayouts.Unlock()
+	for _, layout := range layouts {
+		found := false
+		for _, existing := range timeLayouts.layouts {
+			if existing == layout {
+				found = true
+				break
+			}
+		}
+		if !found {
+			timeLayouts.layouts = append(timeLayouts.layouts, layout)
+		}
+	}
+}
+
+// ParseTime returns the time for s, which is RFC3339 or one of the layouts
+// added with RegisterTimeLayouts.
+func ParseTime(s string) (time.Time, error) {
+	timeLayouts.RLock()
+	defer timeLayouts.RUnlock()
+	for _, layout := range timeLayouts.layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid time %q, expected RFC3339 or a registered layout", s)
+}
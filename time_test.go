@@ -0,0 +1,68 @@
+package figtree
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTime(t *testing.T) {
+	RegisterTimeLayouts("02 Jan 2006 15:04")
+
+	type config struct {
+		Start   TimeOption   `yaml:"start"`
+		End     TimeOption   `yaml:"end"`
+		Release TimeOption   `yaml:"release"`
+		Created time.Time    `yaml:"created"`
+		Windows []TimeOption `yaml:"windows"`
+	}
+
+	local, err := SourceFromString("local.yml", `start: 2024-12-01T09:30:00Z
+end: "2024-12-24T18:00:00.5-08:00"
+release: 01 Feb 2025 12:00
+windows: [2025-01-01T00:00:00Z]
+`)
+	require.NoError(t, err)
+	global, err := SourceFromString("global.yml", "start: 2020-01-01T00:00:00Z\ncreated: 2019-06-01T12:00:00Z\n")
+	require.NoError(t, err)
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{local, global}, &opts)
+	require.NoError(t, err)
+
+	pst := time.FixedZone("", -8*60*60)
+	assert.Equal(t, TimeOption{tSrc("local.yml", 1, 8), true, time.Date(2024, 12, 1, 9, 30, 0, 0, time.UTC)}, opts.Start)
+	assert.True(t, time.Date(2024, 12, 24, 18, 0, 0, 500000000, pst).Equal(opts.End.Value))
+	assert.Equal(t, tSrc("local.yml", 2, 6), opts.End.Source)
+	assert.Equal(t, TimeOption{tSrc("local.yml", 3, 10), true, time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)}, opts.Release)
+	assert.Equal(t, time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC), opts.Created)
+	assert.Equal(t, []TimeOption{{tSrc("local.yml", 4, 11), true, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}}, opts.Windows)
+
+	StringifyValue = true
+	defer func() {
+		StringifyValue = false
+	}()
+	data, err := json.Marshal(opts.Start)
+	require.NoError(t, err)
+	assert.Equal(t, `"2024-12-01T09:30:00Z"`, string(data))
+	var fromJSON TimeOption
+	require.NoError(t, json.Unmarshal(data, &fromJSON))
+	assert.Equal(t, opts.Start.Value, fromJSON.Value)
+
+	// invalid values are reported with their location
+	src, err := SourceFromString("config.yml", "start: tomorrow\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:8")
+	assert.Contains(t, err.Error(), `invalid time "tomorrow"`)
+
+	// and from the command line
+	opts = config{}
+	require.NoError(t, opts.Start.Set("03 Mar 2025 08:15"))
+	assert.Equal(t, time.Date(2025, 3, 3, 8, 15, 0, 0, time.UTC), opts.Start.Value)
+	require.Error(t, opts.End.Set("2025-03-03 08:15"))
+}
@@ -0,0 +1,94 @@
+package figtree
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+)
+
+// TransformFunc transforms a string value before it is assigned to a field
+// tagged with `figtree:",transform=<name>"`, see RegisterTransform.
+type TransformFunc func(string) (string, error)
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]TransformFunc{
+		"trim": func(s string) (string, error) {
+			return strings.TrimSpace(s), nil
+		},
+		"lower": func(s string) (string, error) {
+			return strings.ToLower(s), nil
+		},
+		"upper": func(s string) (string, error) {
+			return strings.ToUpper(s), nil
+		},
+		"expandenv": func(s string) (string, error) {
+			return os.ExpandEnv(s), nil
+		},
+	}
+)
+
+// RegisterTransform registers a named transform for string values.  Fields
+// tagged with `figtree:",transform=<name>"` have their string values, and
+// the string items of lists and maps, transformed as they are assigned.
+// Multiple transforms are separated with `|` and run in order, like:
+//
+//	Token StringOption `yaml:"token" figtree:",transform=expandenv|trim"`
+//
+// The builtin transforms are trim, lower, upper and expandenv.  The
+// transforms that ran are recorded in the Merger Provenance.
+// RegisterTransform panics if a transform is registered twice with the
+// same name.
+func RegisterTransform(name string, fn TransformFunc) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	if fn == nil {
+		panic("figtree: RegisterTransform fn is nil")
+	}
+	if _, ok := transforms[name]; ok {
+		panic(fmt.Sprintf("figtree: RegisterTransform called twice for transform %q", name))
+	}
+	transforms[name] = fn
+}
+
+// fieldTransforms returns the names of the transforms for the struct field.
+func fieldTransforms(sf reflect.StructField) []string {
+	value, ok := figtreeTagValue(sf, "transform")
+	if !ok || value == "" {
+		return nil
+	}
+	return strings.Split(value, "|")
+}
+
+// enterTransforms sets the transforms for the values assigned to the struct
+// field, the returned function will restore the previous transforms.
+func (m *Merger) enterTransforms(sf reflect.StructField) func() {
+	names, transformed := m.transforms, m.transformed
+	m.transforms, m.transformed = fieldTransforms(sf), false
+	return func() {
+		m.transforms, m.transformed = names, transformed
+	}
+}
+
+// transform runs the current transforms on s.
+func (m *Merger) transform(s string) (string, error) {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	for _, name := range m.transforms {
+		fn, ok := transforms[name]
+		if !ok {
+			return "", errors.Errorf("unknown transform %q", name)
+		}
+		var err error
+		s, err = fn(s)
+		if err != nil {
+			return "", errors.Wrapf(err, "transform %s failed", name)
+		}
+	}
+	m.transformed = true
+	return s, nil
+}
@@ -0,0 +1,81 @@
+package figtree
+
+import (
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWithTransforms(t *testing.T) {
+	RegisterTransform("test-dashes", func(s string) (string, error) {
+		if strings.Contains(s, " ") {
+			return "", errors.New("spaces are not allowed")
+		}
+		return strings.ReplaceAll(s, "_", "-"), nil
+	})
+	assert.Panics(t, func() {
+		RegisterTransform("trim", func(s string) (string, error) { return s, nil })
+	})
+
+	type config struct {
+		Token  StringOption      `yaml:"token" figtree:",transform=trim"`
+		Name   string            `yaml:"name" figtree:",transform=trim|lower|test-dashes"`
+		Home   StringOption      `yaml:"home" figtree:",transform=expandenv"`
+		Tags   ListStringOption  `yaml:"tags" figtree:",transform=lower"`
+		Labels map[string]string `yaml:"labels" figtree:",transform=upper"`
+		Raw    StringOption      `yaml:"raw"`
+		Port   IntOption         `yaml:"port" figtree:",transform=trim"`
+	}
+	t.Setenv("FIGTREE_TRANSFORM_HOME", "/home/bob")
+
+	data := `token: "  secret \n"
+name: " My_App "
+home: ${FIGTREE_TRANSFORM_HOME}/app
+tags: [A, b]
+labels:
+  env: prod
+raw: " raw "
+port: 8080
+`
+	src, err := SourceFromString("config.yml", data)
+	require.NoError(t, err)
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	m := fig.NewMerger()
+	err = fig.LoadAllConfigSourcesWithMerger(m, []ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, StringOption{tSrc("config.yml", 1, 8), true, "secret"}, opts.Token)
+	assert.Equal(t, "my-app", opts.Name)
+	assert.Equal(t, "/home/bob/app", opts.Home.Value)
+	assert.Equal(t, []string{"a", "b"}, opts.Tags.Slice())
+	assert.Equal(t, map[string]string{"env": "PROD"}, opts.Labels)
+	assert.Equal(t, " raw ", opts.Raw.Value)
+	assert.Equal(t, 8080, opts.Port.Value)
+
+	provenance := m.Provenance()
+	assert.Equal(t, "trim", provenance["token"].Transforms)
+	assert.Equal(t, "trim|lower|test-dashes", provenance["name"].Transforms)
+	assert.Equal(t, "lower", provenance["tags"].Transforms)
+	assert.Equal(t, "upper", provenance["labels.env"].Transforms)
+	assert.Empty(t, provenance["raw"].Transforms)
+	assert.Empty(t, provenance["port"].Transforms)
+
+	// transform errors have the location of the value
+	src, err = SourceFromString("config.yml", "name: my app\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:7")
+	assert.Contains(t, err.Error(), "transform test-dashes failed: spaces are not allowed")
+
+	type unknown struct {
+		Name string `yaml:"name" figtree:",transform=missing"`
+	}
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &unknown{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown transform "missing"`)
+}
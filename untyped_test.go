@@ -0,0 +1,81 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWithoutImplicitTyping(t *testing.T) {
+	type config struct {
+		Version  StringOption     `yaml:"version"`
+		Country  string           `yaml:"country"`
+		Code     IntOption        `yaml:"code"`
+		Port     uint16           `yaml:"port"`
+		Ratio    Float64Option    `yaml:"ratio"`
+		Enabled  BoolOption       `yaml:"enabled"`
+		Explicit any              `yaml:"explicit"`
+		Empty    any              `yaml:"empty"`
+		Extra    map[string]any   `yaml:"extra"`
+		Labels   MapStringOption  `yaml:"labels"`
+		Values   []any            `yaml:"values"`
+		Aliased  ListStringOption `yaml:"aliased"`
+	}
+
+	data := `version: 1.10
+country: no
+code: 012
+port: 8080
+ratio: 1.5
+enabled: true
+explicit: !!int 12
+empty: ~
+extra:
+  on: off
+  id: 0x1F
+labels:
+  a: &anchor 007
+values: [1, true, 2021-01-01]
+aliased: [*anchor]
+`
+	src, err := SourceFromString("config.yml", data)
+	require.NoError(t, err)
+	opts := config{}
+	fig := newFigTreeFromEnv(WithMergeOptions(WithoutImplicitTyping()))
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, StringOption{tSrc("config.yml", 1, 10), true, "1.10"}, opts.Version)
+	assert.Equal(t, "no", opts.Country)
+	assert.Equal(t, IntOption{tSrc("config.yml", 3, 7), true, 12}, opts.Code)
+	assert.Equal(t, uint16(8080), opts.Port)
+	assert.Equal(t, 1.5, opts.Ratio.Value)
+	assert.True(t, opts.Enabled.Value)
+	assert.Equal(t, 12, opts.Explicit)
+	assert.Nil(t, opts.Empty)
+	assert.Equal(t, map[string]any{"on": "off", "id": "0x1F"}, opts.Extra)
+	assert.Equal(t, map[string]string{"a": "007"}, opts.Labels.Map())
+	assert.Equal(t, []any{"1", "true", "2021-01-01"}, opts.Values)
+	assert.Equal(t, []string{"007"}, opts.Aliased.Slice())
+
+	// the source is not modified
+	opts = config{}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, []any{1, true}, opts.Values[:2])
+
+	// values are only converted to the option type
+	src, err = SourceFromString("config.yml", "code: 0x1F\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:7")
+	assert.Contains(t, err.Error(), `invalid value "0x1F"`)
+
+	src, err = SourceFromString("config.yml", "enabled: no\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid bool value "no"`)
+}
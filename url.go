@@ -0,0 +1,26 @@
+package figtree
+
+import (
+	"net/url"
+
+	"emperror.dev/errors"
+)
+
+// URLOption is an option for an absolute URL, like
+// "https://example.com/api".  Values are parsed with url.Parse when loaded,
+// values that cannot be parsed or have no scheme are reported with the
+// location of the value in the config file.
+type URLOption = Option[*url.URL]
+
+// ParseURL returns the URL for s, which must be an absolute URL with a
+// scheme.
+func ParseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, errors.Errorf("invalid URL %q: %s", s, errors.Unwrap(err))
+	}
+	if u.Scheme == "" {
+		return nil, errors.Errorf("invalid URL %q: missing scheme", s)
+	}
+	return u, nil
+}
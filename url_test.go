@@ -0,0 +1,128 @@
+package figtree
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadURLAndRegexp(t *testing.T) {
+	type config struct {
+		Endpoint URLOption      `yaml:"endpoint"`
+		Mirrors  []URLOption    `yaml:"mirrors"`
+		Pattern  RegexpOption   `yaml:"pattern"`
+		Exclude  *regexp.Regexp `yaml:"exclude"`
+	}
+
+	src, err := SourceFromString("config.yml", `endpoint: https://api.example.com/v1?debug=1
+mirrors: [https://a.example.com, ftp://b.example.com/pub]
+pattern: ^v[0-9]+$
+exclude: \.tmp$
+`)
+	require.NoError(t, err)
+	opts := config{}
+	fig := newFigTreeFromEnv()
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	endpoint, err := url.Parse("https://api.example.com/v1?debug=1")
+	require.NoError(t, err)
+	assert.Equal(t, URLOption{tSrc("config.yml", 1, 11), true, endpoint}, opts.Endpoint)
+	require.Len(t, opts.Mirrors, 2)
+	assert.Equal(t, "ftp://b.example.com/pub", opts.Mirrors[1].Value.String())
+	assert.Equal(t, tSrc("config.yml", 2, 34), opts.Mirrors[1].Source)
+	assert.Equal(t, tSrc("config.yml", 3, 10), opts.Pattern.Source)
+	assert.True(t, opts.Pattern.Value.MatchString("v12"))
+	assert.False(t, opts.Pattern.Value.MatchString("v1.2"))
+	assert.Equal(t, `\.tmp$`, opts.Exclude.String())
+
+	StringifyValue = true
+	defer func() {
+		StringifyValue = false
+	}()
+	got, err := yaml.Marshal(map[string]any{"endpoint": opts.Endpoint, "pattern": opts.Pattern})
+	require.NoError(t, err)
+	assert.Equal(t, "endpoint: https://api.example.com/v1?debug=1\npattern: ^v[0-9]+$\n", string(got))
+	assert.Equal(t, map[string]any{
+		"endpoint": "https://api.example.com/v1?debug=1",
+		"mirrors":  []any{"https://a.example.com", "ftp://b.example.com/pub"},
+		"pattern":  "^v[0-9]+$",
+		"exclude":  `\.tmp$`,
+	}, Normalize(opts))
+
+	// invalid values are reported with their location
+	src, err = SourceFromString("config.yml", "endpoint: api.example.com\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:11")
+	assert.Contains(t, err.Error(), `invalid URL "api.example.com": missing scheme`)
+
+	src, err = SourceFromString("config.yml", "mirrors:\n  - https://ok.example.com\n  - \"http://bad host/\"\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:3:5")
+	assert.Contains(t, err.Error(), `invalid URL "http://bad host/"`)
+
+	src, err = SourceFromString("config.yml", "pattern: \"v[0-9\"\n")
+	require.NoError(t, err)
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.yml:1:10")
+	assert.Contains(t, err.Error(), `invalid regexp "v[0-9"`)
+
+	// and from the command line
+	opts = config{}
+	require.NoError(t, opts.Endpoint.Set("http://localhost:8080"))
+	assert.Equal(t, "localhost:8080", opts.Endpoint.Value.Host)
+	require.Error(t, opts.Endpoint.Set("localhost"))
+	require.NoError(t, opts.Pattern.Set("^a+$"))
+	assert.True(t, opts.Pattern.Value.MatchString("aaa"))
+	require.Error(t, opts.Pattern.Set("("))
+}
+
+func TestMergeStringConvertedPointers(t *testing.T) {
+	type config struct {
+		Endpoint *url.URL
+		Pattern  *regexp.Regexp
+		Zone     *time.Location
+		URL      URLOption
+		Location LocationOption
+		Regexp   RegexpOption
+	}
+	endpoint, err := url.Parse("https://example.com/api")
+	require.NoError(t, err)
+	zone, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	src := config{
+		Endpoint: endpoint,
+		Pattern:  regexp.MustCompile("^a+$"),
+		Zone:     zone,
+		URL:      NewOption(endpoint),
+		Location: NewOption(zone),
+		Regexp:   NewOption(regexp.MustCompile("^b+$")),
+	}
+	dst := config{}
+	require.NoError(t, Merge(&dst, &src))
+	assert.Equal(t, src, dst)
+	assert.Equal(t, "America/New_York", dst.Zone.String())
+	assert.Equal(t, "America/New_York", dst.Location.Value.String())
+
+	// string values are converted
+	dst = config{}
+	err = Merge(&dst, map[string]any{
+		"endpoint": "https://example.com/api",
+		"zone":     "America/New_York",
+		"regexp":   "^b+$",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, endpoint, dst.Endpoint)
+	assert.Equal(t, "America/New_York", dst.Zone.String())
+	assert.True(t, dst.Regexp.Value.MatchString("bb"))
+}
@@ -0,0 +1,140 @@
+package figtree
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// WithUsageTracking records the options loaded by LoadAllConfigs and
+// LoadAllConfigSources so the options that are never read with
+// Option.Get, MapOption.Map or ListOption.Slice can be reported with
+// UnreadFields.  Each loaded option records its key path and the load in
+// its Source, so only reads of the options from the last load of the
+// FigTree are reported, and reading one option does not mark other options
+// with the same source, like overrides, as read.  Defaults are not tracked.
+func WithUsageTracking() CreateOption {
+	return func(f *FigTree) {
+		if f.usage == nil {
+			f.usage = &usageTracker{}
+		}
+	}
+}
+
+func (f *FigTree) WithUsageTracking() {
+	WithUsageTracking()(f)
+}
+
+// UnreadFields returns the sorted key paths, like `map1.key1`, of the
+// options loaded by the last LoadAllConfigs or LoadAllConfigSources that
+// have not been read since.  It returns nil unless WithUsageTracking was
+// used.
+func (f *FigTree) UnreadFields() []string {
+	if f.usage == nil {
+		return nil
+	}
+	return f.usage.unread()
+}
+
+// usageField is the read state of a key path loaded with usage tracking.
+// It is recorded in the Source of each option loaded for the key path, so
+// Get can mark the field read.
+type usageField struct {
+	read int32
+}
+
+func (u *usageField) markRead() {
+	if u != nil && atomic.LoadInt32(&u.read) == 0 {
+		atomic.StoreInt32(&u.read, 1)
+	}
+}
+
+type usageTracker struct {
+	mu sync.Mutex
+	// fields are the read state of the options from the last load by key
+	// path
+	fields map[string]*usageField
+}
+
+// track replaces the tracked fields with the options loaded into options.
+func (u *usageTracker) track(options any) {
+	if u == nil {
+		return
+	}
+	fields := map[string]*usageField{}
+	trackUsage(reflect.ValueOf(options), nil, fields)
+	u.mu.Lock()
+	u.fields = fields
+	u.mu.Unlock()
+}
+
+// trackUsage records the usageField for the key path in the source of each
+// defined option in v that is not a default.
+func trackUsage(v reflect.Value, path []string, fields map[string]*usageField) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			trackUsage(v.Elem(), path, fields)
+		}
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		trackUsage(elem, path, fields)
+		v.Set(elem)
+	case reflect.Struct:
+		if !v.CanAddr() {
+			return
+		}
+		if option, ok := v.Addr().Interface().(option); ok {
+			source := option.GetSource()
+			if !option.IsDefined() || sourceKind(source.Name) == defaultSource {
+				return
+			}
+			key := strings.Join(path, ".")
+			field, ok := fields[key]
+			if !ok {
+				field = &usageField{}
+				fields[key] = field
+			}
+			source.usage = field
+			option.SetSource(source)
+			return
+		}
+		for name, field := range populateYAMLMaps(v) {
+			if field.StructField.PkgPath != "" || field.StructField.Anonymous {
+				continue
+			}
+			trackUsage(field.Value, append(path[:len(path):len(path)], name), fields)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			trackUsage(elem, append(path[:len(path):len(path)], fmt.Sprint(key.Interface())), fields)
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			trackUsage(v.Index(i), path, fields)
+		}
+	}
+}
+
+func (u *usageTracker) unread() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	unread := []string{}
+	for key, field := range u.fields {
+		if atomic.LoadInt32(&field.read) == 0 {
+			unread = append(unread, key)
+		}
+	}
+	sort.Strings(unread)
+	return unread
+}
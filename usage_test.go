@@ -0,0 +1,68 @@
+package figtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnreadFields(t *testing.T) {
+	src, err := SourceFromString("usage.yml", `
+str1: used
+int1: 1
+float1: 1.5
+map1:
+  key1: a
+  key2: b
+arr1: [x, y]
+`)
+	require.NoError(t, err)
+
+	opts := TestOptions{Bool1: NewBoolOption(true)}
+	fig := newFigTreeFromEnv(WithUsageTracking())
+	err = fig.LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.NoError(t, err)
+
+	// defaults are not reported
+	assert.Equal(t, []string{"arr1", "float1", "int1", "map1.key1", "map1.key2", "str1"}, fig.UnreadFields())
+
+	assert.Equal(t, "used", opts.String1.Get())
+	assert.Equal(t, "b", opts.Map1["key2"].Get())
+	assert.Equal(t, []string{"x", "y"}, opts.Array1.Slice())
+	// reading the Value field directly is not tracked
+	assert.Equal(t, 1, opts.Int1.Value)
+	assert.Equal(t, []string{"float1", "int1", "map1.key1"}, fig.UnreadFields())
+
+	assert.Nil(t, newFigTreeFromEnv().UnreadFields())
+}
+
+func TestUnreadFieldsScope(t *testing.T) {
+	src, err := SourceFromString("usage.yml", "str1: a\nint1: 1\n")
+	require.NoError(t, err)
+	fig := newFigTreeFromEnv(WithUsageTracking(), WithOverrides(map[string]any{
+		"float1": 1.5,
+		"bool1":  true,
+	}))
+	other := newFigTreeFromEnv(WithUsageTracking())
+
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &opts))
+	otherOpts := TestOptions{}
+	require.NoError(t, other.LoadAllConfigSources([]ConfigSource{src}, &otherOpts))
+
+	// overrides all have the same source, but are tracked separately
+	assert.True(t, opts.Bool1.Get())
+	assert.Equal(t, "a", opts.String1.Get())
+	assert.Equal(t, []string{"float1", "int1"}, fig.UnreadFields())
+	// reads are not shared with other FigTrees that loaded the same file
+	assert.Equal(t, []string{"int1", "str1"}, other.UnreadFields())
+
+	// reads are not carried over into a reload
+	reloaded := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigSources([]ConfigSource{src}, &reloaded))
+	assert.Equal(t, []string{"bool1", "float1", "int1", "str1"}, fig.UnreadFields())
+	// reading options from an earlier load is not reported
+	assert.Equal(t, 1, opts.Int1.Get())
+	assert.Equal(t, []string{"bool1", "float1", "int1", "str1"}, fig.UnreadFields())
+}
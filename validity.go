@@ -0,0 +1,195 @@
+package figtree
+
+import (
+	"time"
+
+	"emperror.dev/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// WithClock sets the function used to get the current time when checking
+// the valid-from and valid-until config pragmas, otherwise time.Now is used.
+func WithClock(now func() time.Time) CreateOption {
+	return func(f *FigTree) {
+		f.clock = now
+	}
+}
+
+func (f *FigTree) WithClock(now func() time.Time) {
+	WithClock(now)(f)
+}
+
+func (f *FigTree) now() time.Time {
+	if f.clock != nil {
+		return f.clock()
+	}
+	return timeNow()
+}
+
+// validityTimeFormats are the accepted formats for valid-from and
+// valid-until, times without a zone are UTC.
+var validityTimeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// filterValidity returns config without the sections that are not valid at
+// the current time, or nil if the whole document is not valid.  A document
+// is limited to a time range with the config pragma:
+//
+//	config:
+//	  valid-from: 2024-12-01
+//	  valid-until: 2025-01-01T00:00:00Z
+//
+// Nested sections are limited with a config key that only contains
+// valid-from and valid-until, the config key is removed from sections that
+// are valid.  valid-from is inclusive and valid-until is exclusive.  config
+// is not modified, modified nodes are copied.
+func (f *FigTree) filterValidity(file string, config *yaml.Node) (*yaml.Node, error) {
+	v := validityFilter{file: file, now: f.now()}
+	node, keep, err := v.filter(config, true)
+	if err != nil || !keep {
+		return nil, err
+	}
+	return node, nil
+}
+
+type validityFilter struct {
+	file string
+	now  time.Time
+}
+
+// filter returns node with the invalid sections removed, and false if node
+// itself is not valid.
+func (v validityFilter) filter(node *yaml.Node, top bool) (*yaml.Node, bool, error) {
+	if node == nil {
+		return nil, true, nil
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return node, true, nil
+		}
+		filtered, keep, err := v.filter(node.Content[0], top)
+		if err != nil || !keep {
+			return nil, keep, err
+		}
+		if filtered == node.Content[0] {
+			return node, true, nil
+		}
+		return copyWithContent(node, []*yaml.Node{filtered}), true, nil
+	case yaml.MappingNode:
+		content := make([]*yaml.Node, 0, len(node.Content))
+		changed := false
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "config" && value.Kind == yaml.MappingNode {
+				valid, pragma, err := v.valid(value, top)
+				if err != nil {
+					return nil, false, err
+				}
+				if !valid {
+					return nil, false, nil
+				}
+				if pragma && !top {
+					// remove the validity pragma from the section
+					changed = true
+					continue
+				}
+			}
+			filtered, keep, err := v.filter(value, false)
+			if err != nil {
+				return nil, false, err
+			}
+			if !keep || filtered != value {
+				changed = true
+			}
+			if keep {
+				content = append(content, key, filtered)
+			}
+		}
+		if !changed {
+			return node, true, nil
+		}
+		return copyWithContent(node, content), true, nil
+	case yaml.SequenceNode:
+		content := make([]*yaml.Node, 0, len(node.Content))
+		changed := false
+		for _, item := range node.Content {
+			filtered, keep, err := v.filter(item, false)
+			if err != nil {
+				return nil, false, err
+			}
+			if !keep || filtered != item {
+				changed = true
+			}
+			if keep {
+				content = append(content, filtered)
+			}
+		}
+		if !changed {
+			return node, true, nil
+		}
+		return copyWithContent(node, content), true, nil
+	}
+	return node, true, nil
+}
+
+// valid returns true if the config pragma allows the current time.  pragma
+// is false if the config pragma in a section has keys other than
+// valid-from and valid-until, in which case it is regular config data.
+func (v validityFilter) valid(config *yaml.Node, top bool) (valid, pragma bool, err error) {
+	var from, until *yaml.Node
+	for i := 0; i+1 < len(config.Content); i += 2 {
+		switch config.Content[i].Value {
+		case "valid-from":
+			from = config.Content[i+1]
+		case "valid-until":
+			until = config.Content[i+1]
+		default:
+			if !top {
+				return true, false, nil
+			}
+		}
+	}
+	if from == nil && until == nil {
+		return true, false, nil
+	}
+	if from != nil {
+		t, err := v.parseTime(from)
+		if err != nil {
+			return false, true, err
+		}
+		if v.now.Before(t) {
+			return false, true, nil
+		}
+	}
+	if until != nil {
+		t, err := v.parseTime(until)
+		if err != nil {
+			return false, true, err
+		}
+		if !v.now.Before(t) {
+			return false, true, nil
+		}
+	}
+	return true, true, nil
+}
+
+func (v validityFilter) parseTime(node *yaml.Node) (time.Time, error) {
+	for _, format := range validityTimeFormats {
+		if t, err := time.Parse(format, node.Value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("%s: invalid time %q, expected RFC3339 or YYYY-MM-DD", sourceLine(v.file, node), node.Value)
+}
+
+// copyWithContent returns a shallow copy of node with content.
+func copyWithContent(node *yaml.Node, content []*yaml.Node) *yaml.Node {
+	cp := *node
+	cp.Content = content
+	return &cp
+}
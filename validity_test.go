@@ -0,0 +1,87 @@
+package figtree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigValidity(t *testing.T) {
+	promo, err := SourceFromString("promo.yml", `
+config:
+  valid-from: 2024-11-29
+  valid-until: 2024-12-02T00:00:00Z
+str1: promo
+`)
+	require.NoError(t, err)
+	base, err := SourceFromString("base.yml", `
+str1: base
+maintenance:
+  config:
+    valid-from: "2024-12-01T02:00:00Z"
+    valid-until: "2024-12-01T04:00:00Z"
+  replicas: 0
+banners:
+  - text: always
+  - config:
+      valid-until: 2024-12-01
+    text: early
+`)
+	require.NoError(t, err)
+	sources := []ConfigSource{promo, base}
+
+	type banner struct {
+		Text StringOption `yaml:"text"`
+	}
+	type options struct {
+		String1     StringOption `yaml:"str1"`
+		Maintenance *struct {
+			Replicas IntOption `yaml:"replicas"`
+		} `yaml:"maintenance"`
+		Banners []banner `yaml:"banners"`
+	}
+	load := func(now string) options {
+		t.Helper()
+		clock, err := time.Parse(time.RFC3339, now)
+		require.NoError(t, err)
+		opts := options{}
+		fig := newFigTreeFromEnv(WithClock(func() time.Time { return clock }))
+		require.NoError(t, fig.LoadAllConfigSources(sources, &opts))
+		return opts
+	}
+
+	// before the promo, the early banner is still valid
+	opts := load("2024-11-01T00:00:00Z")
+	assert.Equal(t, StringOption{tSrc("base.yml", 2, 7), true, "base"}, opts.String1)
+	assert.Nil(t, opts.Maintenance)
+	assert.Equal(t, []banner{
+		{StringOption{tSrc("base.yml", 9, 11), true, "always"}},
+		{StringOption{tSrc("base.yml", 12, 11), true, "early"}},
+	}, opts.Banners)
+
+	// during the promo and the maintenance window
+	opts = load("2024-12-01T03:00:00Z")
+	assert.Equal(t, StringOption{tSrc("promo.yml", 5, 7), true, "promo"}, opts.String1)
+	require.NotNil(t, opts.Maintenance)
+	assert.Equal(t, IntOption{tSrc("base.yml", 7, 13), true, 0}, opts.Maintenance.Replicas)
+	assert.Equal(t, []banner{{StringOption{tSrc("base.yml", 9, 11), true, "always"}}}, opts.Banners)
+
+	// valid-until is exclusive
+	opts = load("2024-12-02T00:00:00Z")
+	assert.Equal(t, StringOption{tSrc("base.yml", 2, 7), true, "base"}, opts.String1)
+
+	// the sources are not modified
+	opts = load("2024-11-01T00:00:00Z")
+	assert.Len(t, opts.Banners, 2)
+}
+
+func TestLoadConfigValidityInvalidTime(t *testing.T) {
+	src, err := SourceFromString("bad.yml", "config:\n  valid-from: tomorrow\nstr1: bad\n")
+	require.NoError(t, err)
+	opts := TestOptions{}
+	err = newFigTreeFromEnv().LoadAllConfigSources([]ConfigSource{src}, &opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `bad.yml:2:15: invalid time "tomorrow"`)
+}
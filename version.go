@@ -0,0 +1,55 @@
+package figtree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// FileVersion identifies the contents of a config file at the time it was
+// read, so changes can be detected without reading the file again.
+type FileVersion struct {
+	// Path is the absolute path of the file.
+	Path    string    `json:"path" yaml:"path"`
+	ModTime time.Time `json:"mod-time" yaml:"mod-time"`
+	Size    int64     `json:"size" yaml:"size"`
+	// SHA256 is the hex encoded sha256 digest of the file contents.
+	SHA256 string `json:"sha256" yaml:"sha256"`
+	// statFile is the stat of the file system the file was read from, see
+	// WithFS.
+	statFile func(name string) (os.FileInfo, error)
+}
+
+func newFileVersion(file string, stat os.FileInfo, data []byte, statFile func(string) (os.FileInfo, error)) *FileVersion {
+	sum := sha256.Sum256(data)
+	return &FileVersion{
+		Path:     file,
+		ModTime:  stat.ModTime(),
+		Size:     stat.Size(),
+		SHA256:   hex.EncodeToString(sum[:]),
+		statFile: statFile,
+	}
+}
+
+// Modified returns true if the modification time or size of the file is
+// different from when it was read, or if the file no longer exists.  The
+// file is checked in the file system it was read from, see WithFS.  The
+// file contents are not read, so a modification that preserves both is not
+// detected, compare SHA256 digests for that.
+func (v *FileVersion) Modified() (bool, error) {
+	statFile := v.statFile
+	if statFile == nil {
+		statFile = os.Stat
+	}
+	stat, err := statFile(v.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return !stat.ModTime().Equal(v.ModTime) || stat.Size() != v.Size, nil
+}
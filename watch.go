@@ -0,0 +1,192 @@
+package figtree
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// WatchFunc is called by Watch after the configs have been reloaded, with a
+// pointer to the newly loaded options and the key paths, like "map1.key1",
+// of the values that changed.
+type WatchFunc func(options any, changed []string)
+
+// Watch loads configFile into options like LoadAllConfigs, then checks the
+// config files every interval until ctx is done.  When any config file that
+// LoadAllConfigs would read, or any file included or extended by them, is
+// created, modified or removed the configs are loaded again into a copy of
+// options as it was before the initial load, so values set in code are kept,
+// and fn is called with it if any values changed.  options itself is only
+// modified by the initial load, so the caller decides when to use the
+// reloaded options.  Errors loading the modified configs are logged and the
+// configs are loaded again on the next modification.  The configs are also
+// loaded again whenever a ConfigWatcher provider, see WithConfigProviders,
+// reports that its configs changed.  Watch returns the ctx error when ctx is
+// done.
+func (f *FigTree) Watch(ctx context.Context, configFile string, options any, interval time.Duration, fn WatchFunc) error {
+	optionsType := reflect.TypeOf(options)
+	if optionsType == nil || optionsType.Kind() != reflect.Pointer {
+		return errors.Errorf("options argument [%#v] must be a pointer", options)
+	}
+	// every reload starts from the options as they were before loading
+	template := DeepCopy(options)
+	sources, err := f.loadAllConfigs(ctx, configFile, options)
+	if err != nil {
+		return err
+	}
+	previous := Normalize(options)
+	files := f.watchPaths(configFile, sources)
+	state := f.watchState(files)
+	notified := f.watchProviders(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-ticker.C:
+			current := f.watchState(files)
+			if reflect.DeepEqual(state, current) {
+				continue
+			}
+			state = current
+		case <-notified:
+		}
+		reloaded := DeepCopy(template)
+		sources, err := f.loadAllConfigs(ctx, configFile, reloaded)
+		if err != nil {
+			if ctx.Err() != nil {
+				return errors.WithStack(ctx.Err())
+			}
+			f.log().Debugf("Failed to reload %s: %s", configFile, err)
+			continue
+		}
+		// the included files may have changed
+		files = f.watchPaths(configFile, sources)
+		state = f.watchState(files)
+		normalized := Normalize(reloaded)
+		changed := changedKeys(previous, normalized)
+		previous = normalized
+		if len(changed) > 0 {
+			fn(reloaded, changed)
+		}
+	}
+}
+
+// watchProviders watches the ConfigWatcher providers until ctx is done, the
+// returned channel receives a value when any of their configs change.
+func (f *FigTree) watchProviders(ctx context.Context) <-chan struct{} {
+	notified := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case notified <- struct{}{}:
+		default:
+			// a reload is already pending
+		}
+	}
+	for _, provider := range f.providers {
+		if watcher, ok := provider.(ConfigWatcher); ok {
+			go func() {
+				if err := watcher.WatchConfigs(ctx, notify); err != nil && ctx.Err() == nil {
+					f.log().Debugf("Failed to watch config provider: %s", err)
+				}
+			}()
+		}
+	}
+	return notified
+}
+
+// watchFileState is the state of a watched file, files that do not exist
+// have a zero state.
+type watchFileState struct {
+	ModTime int64
+	Size    int64
+}
+
+// watchPaths returns every file path that LoadAllConfigs could read for
+// configFile, whether the file currently exists or not, including the
+// WithProfile profile files, along with the files read for sources, like
+// included and extended files.
+func (f *FigTree) watchPaths(configFile string, sources []ConfigSource) []string {
+	dirs := []string{"/etc"}
+	if f.home != "" {
+		dirs = append(dirs, f.home)
+	}
+	for dir := filepath.Clean(f.workDir); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if f.noParentTraversal || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	files := []string{}
+	seen := map[string]bool{}
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	for _, dir := range dirs {
+		for _, name := range f.searchFileNames(configFile) {
+			add(filepath.Join(dir, name))
+			if f.profile != "" {
+				add(filepath.Join(dir, profileFileName(name, f.profile)))
+			}
+		}
+	}
+	for _, source := range sources {
+		if source.Version != nil && source.Version.Path != "" {
+			add(source.Version.Path)
+		}
+	}
+	return files
+}
+
+func (f *FigTree) watchState(files []string) map[string]watchFileState {
+	state := map[string]watchFileState{}
+	for _, file := range files {
+		if stat, err := f.stat(file); err == nil {
+			state[file] = watchFileState{
+				ModTime: stat.ModTime().UnixNano(),
+				Size:    stat.Size(),
+			}
+		}
+	}
+	return state
+}
+
+// changedKeys returns the sorted key paths of the values that differ between
+// the Normalized values a and b.
+func changedKeys(a, b any) []string {
+	changed := []string{}
+	collectChangedKeys(a, b, nil, &changed)
+	sort.Strings(changed)
+	return changed
+}
+
+func collectChangedKeys(a, b any, keyPath []string, changed *[]string) {
+	aMap, aOK := a.(map[string]any)
+	bMap, bOK := b.(map[string]any)
+	if !aOK || !bOK {
+		if !reflect.DeepEqual(a, b) {
+			*changed = append(*changed, strings.Join(keyPath, "."))
+		}
+		return
+	}
+	keys := map[string]struct{}{}
+	for k := range aMap {
+		keys[k] = struct{}{}
+	}
+	for k := range bMap {
+		keys[k] = struct{}{}
+	}
+	for k := range keys {
+		collectChangedKeys(aMap[k], bMap[k], append(keyPath[:len(keyPath):len(keyPath)], k), changed)
+	}
+}
@@ -0,0 +1,123 @@
+package figtree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml": "str1: before\nint1: 1\n",
+	})
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(sub, 0o755))
+
+	type update struct {
+		options *TestOptions
+		changed []string
+	}
+	updates := make(chan update)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := TestOptions{}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(sub))
+	done := make(chan error)
+	go func() {
+		done <- fig.Watch(ctx, "figtree.yml", &opts, 5*time.Millisecond, func(options any, changed []string) {
+			updates <- update{options.(*TestOptions), changed}
+		})
+	}()
+
+	next := func() update {
+		t.Helper()
+		select {
+		case u := <-updates:
+			return u
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for watch update")
+		}
+		return update{}
+	}
+
+	// wait for the initial load before modifying the configs
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "figtree.yml"), []byte("str1: after!\nint1: 1\n"), 0o644))
+	u := next()
+	assert.Equal(t, []string{"str1"}, u.changed)
+	assert.Equal(t, StringOption{tSrc("../figtree.yml", 1, 7), true, "after!"}, u.options.String1)
+
+	// a new config file in a watched directory is loaded
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "figtree.yml"), []byte("int1: 2\nmap1: {key: value}\n"), 0o644))
+	u = next()
+	assert.Equal(t, []string{"int1", "map1"}, u.changed)
+	assert.Equal(t, IntOption{tSrc("figtree.yml", 1, 7), true, 2}, u.options.Int1)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+	// the original options are only modified by the initial load
+	assert.Equal(t, StringOption{tSrc("../figtree.yml", 1, 7), true, "before"}, opts.String1)
+}
+
+func TestWatchIncludesAndDefaults(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"figtree.yml": "config: {include: inc.yml}\nextends: base.yml\nstr1: main\n",
+		"inc.yml":     "int1: 1\n",
+		"base.yml":    "bool1: true\n",
+	})
+
+	updates := make(chan []string)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// values set in code are kept for every reload
+	opts := TestOptions{Float1: NewFloat32Option(2.5)}
+	fig := newFigTreeFromEnv(WithHome(dir), WithCwd(dir), WithoutParentTraversal(), WithExtends(), WithProfile("prod"))
+	var reloaded *TestOptions
+	done := make(chan error)
+	go func() {
+		done <- fig.Watch(ctx, "figtree.yml", &opts, 5*time.Millisecond, func(options any, changed []string) {
+			reloaded = options.(*TestOptions)
+			updates <- changed
+		})
+	}()
+
+	next := func() []string {
+		t.Helper()
+		select {
+		case changed := <-updates:
+			return changed
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for watch update")
+		}
+		return nil
+	}
+
+	// wait for the initial load before modifying the configs
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inc.yml"), []byte("int1: 2\n"), 0o644))
+	assert.Equal(t, []string{"int1"}, next())
+	assert.Equal(t, 2, reloaded.Int1.Value)
+	assert.Equal(t, NewFloat32Option(2.5), reloaded.Float1)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.yml"), []byte("bool1: false\n"), 0o644))
+	assert.Equal(t, []string{"bool1"}, next())
+
+	// the profile file is watched before it exists
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "figtree.prod.yml"), []byte("str1: prod\n"), 0o644))
+	assert.Equal(t, []string{"str1"}, next())
+	assert.Equal(t, "prod", reloaded.String1.Value)
+	assert.Equal(t, NewFloat32Option(2.5), reloaded.Float1)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
@@ -0,0 +1,204 @@
+package figtree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllConfigsFrom(t *testing.T) {
+	dirs := []string{"d1", "d1/d2", "d1/d2/d3"}
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	// load each directory the old way, via os.Chdir
+	expected := make([]TestOptions, len(dirs))
+	for i, dir := range dirs {
+		func() {
+			require.NoError(t, os.Chdir(dir))
+			defer func() {
+				require.NoError(t, os.Chdir(cwd))
+			}()
+			fig := newFigTreeFromEnv()
+			require.NoError(t, fig.LoadAllConfigs("figtree.yml", &expected[i]))
+		}()
+	}
+
+	// then concurrently without changing directories
+	fig := newFigTreeFromEnv()
+	got := make([]TestOptions, len(dirs))
+	wg := sync.WaitGroup{}
+	errs := make([]error, len(dirs))
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func(i int, dir string) {
+			defer wg.Done()
+			errs[i] = fig.LoadAllConfigsFrom(dir, "figtree.yml", &got[i])
+		}(i, dir)
+	}
+	wg.Wait()
+	for i := range dirs {
+		require.NoError(t, errs[i])
+		assert.Exactly(t, expected[i], got[i], dirs[i])
+	}
+}
+
+func TestRelativeWorkDir(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	fig := newFigTreeFromEnv(WithCwd("d1/d2"))
+	assert.Equal(t, filepath.Join(cwd, "d1/d2"), fig.workDir)
+
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigs("figtree.yml", &opts))
+	assert.Equal(t, StringOption{tSrc("figtree.yml", 1, 7), true, "d2str1val1"}, opts.String1)
+}
+
+func TestFindParentPathsHomeSibling(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"home/figtree.yml":       "str1: home\n",
+		"homework/figtree.yml":   "str1: homework\n",
+		"homework/a/figtree.yml": "str1: a\n",
+	})
+	home := filepath.Join(dir, "home")
+	cwd := filepath.Join(dir, "homework", "a")
+	paths := FindParentPaths(home, cwd, "figtree.yml")
+	assert.Equal(t, []string{
+		filepath.Join(home, "figtree.yml"),
+		filepath.Join(dir, "homework", "figtree.yml"),
+		filepath.Join(cwd, "figtree.yml"),
+	}, paths)
+}
+
+func TestLoadAllConfigsDuplicateFiles(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repo/figtree.yml": "str1: repo\narr1: [a]\n",
+	})
+	dir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "repo/sub"), 0o755))
+	require.NoError(t, os.Symlink("../figtree.yml", filepath.Join(dir, "repo/sub/figtree.yml")))
+
+	logger := &recordingLogger{}
+	fig := newFigTreeFromEnv(
+		WithHome(filepath.Join(dir, "home")),
+		WithCwd(filepath.Join(dir, "repo/sub")),
+		WithSourceNames(SourceNameAbsolute),
+		WithLogger(logger),
+	)
+	opts := TestOptions{}
+	err = fig.LoadAllConfigs("figtree.yml", &opts)
+	require.NoError(t, err)
+
+	// the file is only merged once, from the nearest path
+	nearest := filepath.Join(dir, "repo/sub/figtree.yml")
+	other := filepath.Join(dir, "repo/figtree.yml")
+	assert.Equal(t, StringOption{tSrc(nearest, 1, 7), true, "repo"}, opts.String1)
+	assert.Equal(t, ListStringOption{{tSrc(nearest, 2, 8), true, "a"}}, opts.Array1)
+	assert.Contains(t, logger.messages, fmt.Sprintf("Skipping config %s, it is the same file as %s", other, nearest))
+
+	// and the alias is reported
+	sources, err := fig.ReadAllConfigs("figtree.yml")
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.Equal(t, []string{other}, sources[0].Aliases)
+	report := NewLoadReport(sources, &opts)
+	assert.Contains(t, RenderSourceTree(report), fmt.Sprintf("  figtree.yml (also found as %s)\n", other))
+}
+
+func TestLoadAllConfigsWithoutParentTraversal(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"home/figtree.yml":        "str1: home\nmap1: {home: a}\n",
+		"repo/figtree.yml":        "str1: repo\nmap1: {repo: b}\n",
+		"repo/sub/figtree.yml":    "map1: {sub: c}\n",
+		"repo/sub/a/.placeholder": "",
+	})
+	fig := newFigTreeFromEnv(
+		WithHome(filepath.Join(dir, "home")),
+		WithCwd(filepath.Join(dir, "repo/sub")),
+		WithoutParentTraversal(),
+	)
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadAllConfigs("figtree.yml", &opts))
+	// repo/figtree.yml is never read
+	assert.Equal(t, "home", opts.String1.Value)
+	assert.Len(t, opts.Map1, 2)
+	assert.Contains(t, opts.Map1, "home")
+	assert.Contains(t, opts.Map1, "sub")
+
+	// from a directory without a config only home is loaded
+	fig.WithCwd(filepath.Join(dir, "repo/sub/a"))
+	sources, err := fig.ReadAllConfigs("figtree.yml")
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.Equal(t, "../../../home/figtree.yml", sources[0].Filename)
+}
+
+func TestLoadFirstConfig(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"home/figtree.yml":      "str1: home\nint1: 1\n",
+		"repo/figtree.yml":      "str1: repo\nbool1: true\n",
+		"repo/sub/.placeholder": "",
+	})
+	fig := newFigTreeFromEnv(
+		WithHome(filepath.Join(dir, "home")),
+		WithCwd(filepath.Join(dir, "repo/sub")),
+		WithOverrides(map[string]any{"float1": 1.5}),
+	)
+	opts := TestOptions{}
+	require.NoError(t, fig.LoadFirstConfig("figtree.yml", &opts))
+	// only the nearest config is loaded, along with the overrides
+	assert.Equal(t, StringOption{tSrc("../figtree.yml", 1, 7), true, "repo"}, opts.String1)
+	assert.Equal(t, BoolOption{tSrc("../figtree.yml", 2, 8), true, true}, opts.Bool1)
+	assert.Equal(t, IntOption{}, opts.Int1)
+	assert.Equal(t, float32(1.5), opts.Float1.Value)
+
+	// with no config files found only the overrides are loaded
+	opts = TestOptions{}
+	require.NoError(t, fig.LoadFirstConfig("missing.yml", &opts))
+	assert.Equal(t, StringOption{}, opts.String1)
+	assert.Equal(t, float32(1.5), opts.Float1.Value)
+}
+
+func TestLoadAllConfigsWithRequireConfig(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"home/other.yml": "str1: home\n",
+		"repo/.keep":     "",
+	})
+	home := filepath.Join(dir, "home")
+	repo := filepath.Join(dir, "repo")
+	fig := newFigTreeFromEnv(WithHome(home), WithCwd(repo), WithRequireConfig(), WithoutParentTraversal())
+
+	opts := TestOptions{}
+	err := fig.LoadAllConfigs("figtree.yml", &opts)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrConfigNotFound))
+	var notFound ConfigNotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "figtree.yml", notFound.File)
+	assert.Equal(t, []string{
+		filepath.Join(repo, "figtree.yml"),
+		filepath.Join(home, "figtree.yml"),
+		"/etc/figtree.yml",
+	}, notFound.SearchPath)
+
+	err = fig.LoadFirstConfig("figtree.yml", &opts)
+	assert.True(t, errors.Is(err, ErrConfigNotFound))
+
+	err = fig.LoadConfig("figtree.yml", &opts)
+	assert.True(t, errors.Is(err, ErrConfigNotFound))
+
+	// an empty config is still a config
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "figtree.yml"), nil, 0o644))
+	require.NoError(t, fig.LoadConfig("figtree.yml", &opts))
+
+	// and any config found satisfies the requirement
+	require.NoError(t, fig.LoadAllConfigs("other.yml", &opts))
+	assert.Equal(t, "home", opts.String1.Value)
+}